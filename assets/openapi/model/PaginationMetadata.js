@@ -56,6 +56,9 @@ class PaginationMetadata {
             if (data.hasOwnProperty('total')) {
                 obj['total'] = ApiClient.convertToType(data['total'], 'Number');
             }
+            if (data.hasOwnProperty('next')) {
+                obj['next'] = ApiClient.convertToType(data['next'], 'String');
+            }
         }
         return obj;
     }
@@ -78,6 +81,12 @@ PaginationMetadata.prototype['limit'] = undefined;
  */
 PaginationMetadata.prototype['total'] = undefined;
 
+/**
+ * Opaque cursor of the next page, if there is one
+ * @member {String} next
+ */
+PaginationMetadata.prototype['next'] = undefined;
+
 
 
 
@@ -57,6 +57,9 @@ class TracksStats {
             if (data.hasOwnProperty('numberOfTracksWithLyrics')) {
                 obj['numberOfTracksWithLyrics'] = ApiClient.convertToType(data['numberOfTracksWithLyrics'], 'Number');
             }
+            if (data.hasOwnProperty('numberOfInstrumentalTracks')) {
+                obj['numberOfInstrumentalTracks'] = ApiClient.convertToType(data['numberOfInstrumentalTracks'], 'Number');
+            }
         }
         return obj;
     }
@@ -74,6 +77,12 @@ TracksStats.prototype['numberOfTracks'] = undefined;
  */
 TracksStats.prototype['numberOfTracksWithLyrics'] = undefined;
 
+/**
+ * Number of tracks without lyrics, they are not counted as tracks whose lyrics are missing
+ * @member {Number} numberOfInstrumentalTracks
+ */
+TracksStats.prototype['numberOfInstrumentalTracks'] = undefined;
+
 
 
 
@@ -2,16 +2,29 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/imba28/spolyr/pkg/certs"
+	"github.com/imba28/spolyr/pkg/db"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/acme"
 	"strings"
+	"time"
 )
 
 type config struct {
 	databaseUsername         string
 	databasePassword         string
 	databaseHost             string
+	databaseURI              string
+	databaseName             string
+	databaseTLSCAFile        string
+	databaseMinPoolSize      uint64
+	databaseMaxPoolSize      uint64
+	databaseConnectTimeout   int
+	databaseSelectionTimeout int
+	databaseOperationTimeout int
+	maxLyricsImportErrors    int
 	httpPort                 int
 	geniusAPIToken           string
 	spotifyOAuthClientId     string
@@ -24,7 +37,30 @@ type config struct {
 
 	supportedLanguages []string
 
-	debug bool
+	autoMigrate bool
+
+	orphanPolicy        string
+	orphanRetentionDays int
+
+	debug    bool
+	logLevel string
+
+	publicDir string
+
+	cacheSize       int
+	cacheTTLSeconds int
+
+	shutdownTimeoutSeconds int
+
+	httpsPort     int
+	httpRedirect  bool
+	tlsCertFile   string
+	tlsKeyFile    string
+	acmeDomains   []string
+	acmeEmail     string
+	acmeDirectory string
+	acmeCacheDir  string
+	acmeCAFile    string
 }
 
 func initConfig(cmd *cobra.Command) error {
@@ -51,6 +87,73 @@ func initConfig(cmd *cobra.Command) error {
 	return nil
 }
 
+func initDatabaseFlags(flags *pflag.FlagSet, c *config) {
+	flags.StringVarP(&c.databaseUsername, "database_user", "", "", "Username of mongodb user (default: "+defaultDatabaseUser+" unless database_uri is set)")
+	flags.StringVarP(&c.databasePassword, "database_password", "", "", "Password of mongodb user (default: "+defaultDatabasePassword+" unless database_uri is set)")
+	flags.StringVarP(&c.databaseHost, "database_host", "", "127.0.0.1", "Host of mongodb instance")
+	flags.StringVarP(&c.databaseURI, "database_uri", "", "", "MongoDB connection string, e.g. to connect to a replica set. Takes precedence over database_host")
+	flags.StringVarP(&c.databaseName, "database_name", "", "", "Name of the database (default: database of database_uri or "+db.DefaultDatabaseName+")")
+	flags.StringVarP(&c.databaseTLSCAFile, "database_tls_ca_file", "", "", "PEM encoded root certificates used to verify the mongodb server. Enables tls")
+	flags.Uint64VarP(&c.databaseMinPoolSize, "database_min_pool_size", "", 0, "Minimum number of connections per mongodb server")
+	flags.Uint64VarP(&c.databaseMaxPoolSize, "database_max_pool_size", "", 100, "Maximum number of connections per mongodb server")
+	flags.IntVarP(&c.databaseConnectTimeout, "database_connect_timeout", "", 30, "Number of seconds to wait for a connection to a mongodb server")
+	flags.IntVarP(&c.databaseSelectionTimeout, "database_server_selection_timeout", "", 30, "Number of seconds to wait for a suitable mongodb server, e.g. the primary of a replica set")
+	flags.IntVarP(&c.databaseOperationTimeout, "database_operation_timeout", "", 30, "Number of seconds a single database operation, e.g. a search, may take. 0 disables the timeout")
+	flags.IntVarP(&c.maxLyricsImportErrors, "max_lyrics_import_errors", "", db.DefaultMaxLyricsImportErrorCount, "Number of failed lyrics imports after which a track is no longer included in syncs")
+}
+
+// defaultDatabaseUser and defaultDatabasePassword are the credentials of the MongoDB container of the docker-compose
+// setup. They are only used to connect to database_host, a database_uri without credentials might not require any.
+const (
+	defaultDatabaseUser     = "root"
+	defaultDatabasePassword = "example"
+)
+
+func (c *config) database() db.Config {
+	uri, username, password := c.databaseURI, c.databaseUsername, c.databasePassword
+	if uri == "" {
+		uri = db.HostURI(c.databaseHost)
+		if username == "" {
+			username = defaultDatabaseUser
+		}
+		if password == "" {
+			password = defaultDatabasePassword
+		}
+	}
+	return db.Config{
+		URI:                       uri,
+		Username:                  username,
+		Password:                  password,
+		Database:                  c.databaseName,
+		TLSCAFile:                 c.databaseTLSCAFile,
+		MinPoolSize:               c.databaseMinPoolSize,
+		MaxPoolSize:               c.databaseMaxPoolSize,
+		ConnectTimeout:            time.Duration(c.databaseConnectTimeout) * time.Second,
+		ServerSelectionTimeout:    time.Duration(c.databaseSelectionTimeout) * time.Second,
+		OperationTimeout:          time.Duration(c.databaseOperationTimeout) * time.Second,
+		MaxLyricsImportErrorCount: c.maxLyricsImportErrors,
+	}
+}
+
+func (c *config) migrationMode() db.MigrationMode {
+	if c.autoMigrate {
+		return db.MigrationsAuto
+	}
+	return db.MigrationsRequired
+}
+
+func (c *config) certs() certs.Config {
+	return certs.Config{
+		CertFile:      c.tlsCertFile,
+		KeyFile:       c.tlsKeyFile,
+		ACMEDomains:   c.acmeDomains,
+		ACMEEmail:     c.acmeEmail,
+		ACMEDirectory: c.acmeDirectory,
+		ACMECacheDir:  c.acmeCacheDir,
+		ACMECAFile:    c.acmeCAFile,
+	}
+}
+
 func initFlags(cmd *cobra.Command, c *config) {
 	cmd.Flags().StringVarP(&c.spotifyOAuthClientId, "spotify_id", "", "", "Spotify OAuth2 client id")
 	cmd.Flags().StringVarP(&c.spotifyOAuthClientSecret, "spotify_secret", "", "", "Spotify OAuth2 client secret")
@@ -59,15 +162,33 @@ func initFlags(cmd *cobra.Command, c *config) {
 	_ = cmd.MarkFlagRequired("spotify_secret")
 	_ = cmd.MarkFlagRequired("genius_api_token")
 
-	cmd.Flags().StringVarP(&c.databaseUsername, "database_user", "", "root", "Username of mongodb user")
-	cmd.Flags().StringVarP(&c.databasePassword, "database_password", "", "example", "Password of mongodb user")
-	cmd.Flags().StringVarP(&c.databaseHost, "database_host", "", "127.0.0.1", "Host of mongodb instance")
+	initDatabaseFlags(cmd.Flags(), c)
+	cmd.Flags().BoolVarP(&c.autoMigrate, "auto_migrate", "", true, "Apply pending database migrations on startup. If disabled, Spolyr refuses to start until `spolyr migrate up` has been run")
 
 	cmd.Flags().BoolVarP(&c.debug, "debug", "d", false, "Start api in debug mode. Enables cors for local development.")
+	cmd.Flags().StringVarP(&c.publicDir, "public_dir", "", "", "Serve the frontend from this directory instead of the files embedded into the binary")
+	cmd.Flags().StringVarP(&c.logLevel, "log_level", "", "info", "Minimum level of log entries: debug, info, warn or error")
 	cmd.Flags().IntVarP(&c.httpPort, "http_port", "", 8080, "Port Spolyr should bind to")
+	cmd.Flags().IntVarP(&c.shutdownTimeoutSeconds, "shutdown_timeout", "", 30, "Number of seconds to wait for requests, lyrics syncs and webhooks to finish on shutdown")
 	cmd.Flags().StringVarP(&c.secret, "session_key", "", "dev", "Secret value used for validating session data")
 	cmd.Flags().StringSliceVarP(&c.supportedLanguages, "supported_languages", "", []string{}, "List of languages used for language specific database queries")
 
+	cmd.Flags().IntVarP(&c.cacheSize, "cache_size", "", 1000, "Maximum number of cached track lookups, searches and detected languages. 0 disables the cache")
+	cmd.Flags().IntVarP(&c.cacheTTLSeconds, "cache_ttl", "", 300, "Number of seconds track lookups, searches and detected languages are cached")
+
+	cmd.Flags().StringVarP(&c.orphanPolicy, "orphan_policy", "", "keep", "What to do with tracks removed from the library and all imported playlists: keep, hide or delete")
+	cmd.Flags().IntVarP(&c.orphanRetentionDays, "orphan_retention_days", "", 30, "Number of days after which orphaned tracks are deleted if orphan_policy is delete")
+
+	cmd.Flags().IntVarP(&c.httpsPort, "https_port", "", 8443, "Port of the https listener if tls is enabled")
+	cmd.Flags().BoolVarP(&c.httpRedirect, "http_redirect", "", false, "If tls is enabled, redirect requests on http_port to https. Health checks and ACME challenges are always served on http_port")
+	cmd.Flags().StringVarP(&c.tlsCertFile, "tls_cert_file", "", "", "PEM encoded certificate (chain) used to serve https")
+	cmd.Flags().StringVarP(&c.tlsKeyFile, "tls_key_file", "", "", "PEM encoded private key of tls_cert_file")
+	cmd.Flags().StringSliceVarP(&c.acmeDomains, "acme_domains", "", []string{}, "Obtain certificates for these domains automatically using ACME")
+	cmd.Flags().StringVarP(&c.acmeEmail, "acme_email", "", "", "Contact address of the ACME account")
+	cmd.Flags().StringVarP(&c.acmeDirectory, "acme_directory", "", acme.LetsEncryptURL, "Directory url of the ACME certificate authority")
+	cmd.Flags().StringVarP(&c.acmeCacheDir, "acme_cache_dir", "", "certs", "Directory the ACME account key and certificates are stored in")
+	cmd.Flags().StringVarP(&c.acmeCAFile, "acme_ca_file", "", "", "Additional PEM encoded root certificates trusted when connecting to the ACME directory")
+
 	cmd.Flags().StringVarP(&c.protocol, "protocol", "", "http", "Public http protocol. Pick https if Spolyr resides behind a reverse proxy using TLS")
 	cmd.Flags().StringVarP(&c.domain, "domain", "", "localhost", "Public hostname")
 	cmd.Flags().IntVarP(&c.httpPublicPort, "http_public_port", "", 8080, "Public http port")
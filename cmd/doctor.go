@@ -1,20 +1,31 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/dmolesUC/go-spinner"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/doctor"
 	"github.com/imba28/spolyr/pkg/language"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
 )
 
+type doctorOptions struct {
+	fix  bool
+	json bool
+}
+
 func NewDoctorCommand() *cobra.Command {
 	config := &config{}
+	o := &doctorOptions{}
 
 	c := &cobra.Command{
-		Use: "doctor",
-		Run: doctor(config),
+		Use:   "doctor",
+		Short: "Checks the integrity of the track index and optionally repairs it",
+		Run:   runDoctor(config, o),
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			err := initConfig(cmd)
 			if err != nil {
@@ -24,13 +35,15 @@ func NewDoctorCommand() *cobra.Command {
 	}
 
 	initFlags(c, config)
+	c.Flags().BoolVarP(&o.fix, "fix", "", false, "Apply repairs instead of only reporting problems")
+	c.Flags().BoolVarP(&o.json, "json", "", false, "Print the report as JSON")
 
 	return c
 }
 
-func doctor(c *config) func(cmd *cobra.Command, args []string) {
+func runDoctor(c *config, o *doctorOptions) func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		dbConn, err := db.New(c.databaseUsername, c.databasePassword, "spolyr", c.databaseHost, 3)
+		dbConn, err := db.New(c.database(), db.WithMigrationMode(c.migrationMode()))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -46,40 +59,65 @@ func doctor(c *config) func(cmd *cobra.Command, args []string) {
 			d = language.New()
 		}
 
-		s := spinner.StartNew("Setting language of tracks...")
-		defer func() {
+		doc := doctor.New(dbConn.Tracks, dbConn, d)
+
+		var s *spinner.Spinner
+		if !o.json {
+			s = spinner.StartNew("Examining tracks...")
+			doc.Progress = func(current, total int) {
+				s.Title = fmt.Sprintf("Examining tracks (%d/%d)...", current, total)
+			}
+		}
+
+		report, err := doc.Examine(context.Background(), o.fix)
+		if s != nil {
 			s.Stop()
-			fmt.Println("Setting language of tracks: Completed")
-		}()
-
-		p := 1
-		i := 1
-		success := 0
-		for {
-			tracks, n, err := dbConn.Tracks.AllTracks(p, 25)
-			if err != nil {
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if o.json {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
 				log.Fatal(err)
 			}
-			for _, t := range tracks {
-				if t.Loaded && t.Language == "" {
-					lang, err := d.Detect(t.Lyrics)
-					if err == nil {
-						t.Language = lang
-						err = dbConn.Tracks.Save(t)
-						if err != nil {
-							log.Fatal(err)
-						}
-						success++
-					}
-				}
-				s.Title = fmt.Sprintf("Setting language of tracks (%d/%d)...", i, n)
-				i++
-			}
+			return
+		}
+
+		printReport(report)
+	}
+}
+
+func printReport(r doctor.Report) {
+	fmt.Printf("Examined %d tracks, found %d issues\n", r.Tracks, len(r.Issues))
 
-			if len(tracks) < 25 {
-				break
+	for _, issue := range r.Issues {
+		state := " "
+		switch {
+		case issue.Error != "":
+			state = "!"
+		case issue.Fixed:
+			state = "x"
+		}
+		fmt.Printf("[%s] %s: %s\n", state, issue.Check, issue.Message)
+		if issue.Error != "" {
+			fmt.Printf("    repair failed: %s\n", issue.Error)
+		}
+	}
+
+	if r.DryRun {
+		fixable := 0
+		for _, issue := range r.Issues {
+			if issue.Fixable {
+				fixable++
 			}
-			p++
 		}
+		if fixable > 0 {
+			fmt.Printf("%d issues can be repaired automatically. Run again with --fix to apply the repairs.\n", fixable)
+		}
+		return
 	}
+	fmt.Printf("Repaired %d of %d issues\n", r.Fixed(), len(r.Issues))
 }
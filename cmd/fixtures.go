@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"github.com/imba28/spolyr/pkg/db"
 	"github.com/spf13/cobra"
 	"log"
@@ -71,7 +72,7 @@ func NewFixturesCommand() *cobra.Command {
 
 func fixtures(c *config) func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
-		dbConn, err := db.New(c.databaseUsername, c.databasePassword, "spolyr", c.databaseHost, 3)
+		dbConn, err := db.New(c.database(), db.WithMigrationMode(c.migrationMode()))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -79,7 +80,7 @@ func fixtures(c *config) func(cmd *cobra.Command, args []string) {
 		log.Println("Creating fixtures...")
 
 		for i := range trackFixtures {
-			if err := dbConn.Tracks.Save(&trackFixtures[i]); err != nil {
+			if err := dbConn.Tracks.Save(context.Background(), &trackFixtures[i]); err != nil {
 				log.Fatal(err)
 			}
 		}
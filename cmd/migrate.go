@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/spf13/cobra"
+	"log"
+	"strconv"
+)
+
+func NewMigrateCommand() *cobra.Command {
+	config := &config{}
+
+	c := &cobra.Command{
+		Use:   "migrate",
+		Short: "Inspects and changes the schema version of the database",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			err := initConfig(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	initDatabaseFlags(c.PersistentFlags(), config)
+
+	c.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Prints the current schema version and pending migrations",
+		Args:  cobra.NoArgs,
+		Run: withMigrator(config, func(m *db.Migrator, args []string) error {
+			return printMigrationStatus(m)
+		}),
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Applies all pending migrations",
+		Args:  cobra.NoArgs,
+		Run: withMigrator(config, func(m *db.Migrator, args []string) error {
+			if err := m.Up(); err != nil {
+				return err
+			}
+			return printMigrationStatus(m)
+		}),
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "down N",
+		Short: "Rolls back the last N migrations",
+		Args:  cobra.ExactArgs(1),
+		Run: withMigrator(config, func(m *db.Migrator, args []string) error {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+			if err := m.Down(n); err != nil {
+				return err
+			}
+			return printMigrationStatus(m)
+		}),
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "goto VERSION",
+		Short: "Migrates up or down to VERSION",
+		Args:  cobra.ExactArgs(1),
+		Run: withMigrator(config, func(m *db.Migrator, args []string) error {
+			v, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid version %q", args[0])
+			}
+			if err := m.Goto(uint(v)); err != nil {
+				return err
+			}
+			return printMigrationStatus(m)
+		}),
+	})
+	c.AddCommand(&cobra.Command{
+		Use:   "force VERSION",
+		Short: "Sets the schema version to VERSION without running migrations and clears the dirty flag",
+		Args:  cobra.ExactArgs(1),
+		Run: withMigrator(config, func(m *db.Migrator, args []string) error {
+			v, err := strconv.Atoi(args[0])
+			if err != nil || v < -1 {
+				return fmt.Errorf("invalid version %q", args[0])
+			}
+			if err := m.Force(v); err != nil {
+				return err
+			}
+			return printMigrationStatus(m)
+		}),
+	})
+
+	return c
+}
+
+func withMigrator(c *config, f func(m *db.Migrator, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		dbConn, err := db.New(c.database(), db.WithMigrationMode(db.MigrationsManual))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		m, err := dbConn.Migrator()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := f(m, args); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func printMigrationStatus(m *db.Migrator) error {
+	s, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	if s.Applied {
+		fmt.Printf("Current version: %d\n", s.Version)
+	} else {
+		fmt.Println("Current version: none")
+	}
+	if s.Dirty {
+		fmt.Println("The database is in a dirty state. Fix the failed migration manually and run `spolyr migrate force VERSION`.")
+	}
+
+	if len(s.Pending) == 0 {
+		fmt.Println("No pending migrations")
+		return nil
+	}
+	fmt.Printf("%d pending migrations:\n", len(s.Pending))
+	for _, v := range s.Pending {
+		fmt.Printf("  %d\n", v)
+	}
+	return nil
+}
@@ -1,13 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/imba28/spolyr/pkg/api"
+	"github.com/imba28/spolyr/pkg/cache"
+	"github.com/imba28/spolyr/pkg/certs"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/frontend"
 	"github.com/imba28/spolyr/pkg/language"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/imba28/spolyr/pkg/metrics"
+	"github.com/imba28/spolyr/pkg/orphans"
 	"github.com/spf13/cobra"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -32,6 +43,28 @@ func NewWebCommand() *cobra.Command {
 
 func web(c *config) func(cmd *cobra.Command, args []string) {
 	return func(cmd *cobra.Command, args []string) {
+		level, err := logging.ParseLevel(c.logLevel)
+		if err != nil {
+			log.Fatal(err)
+		}
+		logger := logging.New(os.Stderr, level)
+		logging.SetDefault(logger)
+
+		var certManager *certs.Manager
+		if c.certs().Enabled() {
+			certManager, err = certs.New(c.certs())
+			if err != nil {
+				log.Fatal(err)
+			}
+			// redirects and cookies refer to the https listener unless a different public url has been configured
+			if !cmd.Flags().Changed("protocol") {
+				c.protocol = "https"
+			}
+			if !cmd.Flags().Changed("http_public_port") {
+				c.httpPublicPort = c.httpsPort
+			}
+		}
+
 		env := api.Prod
 		if c.debug {
 			env = api.Dev
@@ -48,34 +81,184 @@ func web(c *config) func(cmd *cobra.Command, args []string) {
 			languageDetector = language.New()
 		}
 
+		orphanPolicy, err := orphans.ParsePolicy(c.orphanPolicy)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		dbConn, err := db.New(
-			c.databaseUsername,
-			c.databasePassword,
-			"spolyr",
-			c.databaseHost,
-			3)
+			c.database(),
+			db.WithMigrationMode(c.migrationMode()),
+			db.WithCommandMonitor(metrics.MongoMonitor()),
+			db.WithCommandMonitor(logging.MongoMonitor()))
 		if err != nil {
 			log.Fatal(err)
 		}
+		metrics.Registry.MustRegister(metrics.NewTrackCollector(dbConn.Tracks))
 
-		s := api.NewServer(
+		options := []api.ServerOptions{
 			api.WithDatabase(dbConn),
 			api.WithSecret([]byte(c.secret)),
 			api.WithLanguageDetector(languageDetector),
 			api.WithGeniusAPI(c.geniusAPIToken),
 			api.WithOAuth(c.spotifyOAuthClientId, c.spotifyOAuthClientSecret),
 			api.WithEnv(env),
-			api.WithReverseProxy(c.protocol, c.domain, c.httpPublicPort))
+			api.WithOrphanPolicy(orphanPolicy, time.Duration(c.orphanRetentionDays)*24*time.Hour),
+			api.WithReverseProxy(c.protocol, c.domain, c.httpPublicPort),
+		}
+		if c.cacheSize > 0 {
+			options = append(options, api.WithCache(cache.NewLRU(c.cacheSize), time.Duration(c.cacheTTLSeconds)*time.Second))
+		}
+		if c.publicDir != "" {
+			options = append(options, api.WithFrontend(os.DirFS(c.publicDir)))
+		} else if !frontend.Built() {
+			logger.Warn("the frontend has not been embedded into the binary, run `make frontend` before building spolyr or set PUBLIC_DIR")
+		}
+		s := api.NewServer(options...)
+
+		// requests are cancelled using this context if they do not finish in time during shutdown, e.g. long imports
+		requestCtx, cancelRequests := context.WithCancel(context.Background())
+		defer cancelRequests()
 
 		srv := &http.Server{
 			Handler:      s,
 			Addr:         fmt.Sprintf(":%d", c.httpPort),
 			WriteTimeout: 1 * time.Minute,
 			ReadTimeout:  10 * time.Second,
+			BaseContext: func(net.Listener) context.Context {
+				return requestCtx
+			},
 		}
+		servers := []*http.Server{srv}
 
-		log.Printf("Starting web server http://127.0.0.1:%d", c.httpPort)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		log.Fatal(srv.ListenAndServe())
+		serveErr := make(chan error, 2)
+		if certManager == nil {
+			go func() {
+				serveErr <- srv.ListenAndServe()
+			}()
+			logger.Info("starting web server", "address", fmt.Sprintf("http://127.0.0.1:%d", c.httpPort))
+		} else {
+			srv.Addr = fmt.Sprintf(":%d", c.httpsPort)
+			srv.TLSConfig = certManager.TLSConfig()
+			go func() {
+				serveErr <- srv.ListenAndServeTLS("", "")
+			}()
+			logger.Info("starting web server", "address", fmt.Sprintf("https://127.0.0.1:%d", c.httpsPort))
+
+			plain := &http.Server{
+				Handler:      plainHTTPHandler(s, certManager, c),
+				Addr:         fmt.Sprintf(":%d", c.httpPort),
+				WriteTimeout: 10 * time.Second,
+				ReadTimeout:  10 * time.Second,
+			}
+			servers = append(servers, plain)
+			go func() {
+				serveErr <- plain.ListenAndServe()
+			}()
+			logger.Info("serving health checks and acme challenges over http", "address", fmt.Sprintf("http://127.0.0.1:%d", c.httpPort), "redirect", c.httpRedirect)
+		}
+
+		select {
+		case err := <-serveErr:
+			logger.Error("web server stopped", "error", err)
+			os.Exit(1)
+		case <-ctx.Done():
+			stop()
+		}
+
+		timeout := time.Duration(c.shutdownTimeoutSeconds) * time.Second
+		logger.Info("shutting down", "timeout", timeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		summary := shutdown(shutdownCtx, servers, cancelRequests, s, dbConn)
+		summary.log(logger)
+		if !summary.clean() {
+			os.Exit(1)
+		}
+	}
+}
+
+// plainHTTPHandler returns the handler of the http listener that runs next to the https listener. Health checks stay
+// reachable over plain http, e.g. for the health check of the container, and ACME http-01 challenges are answered.
+// Other requests are redirected to https if enabled.
+func plainHTTPHandler(app http.Handler, certManager *certs.Manager, c *config) http.Handler {
+	fallback := http.NotFoundHandler()
+	if c.httpRedirect {
+		fallback = certs.Redirect(c.httpPublicPort)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", app)
+	mux.Handle("/readyz", app)
+	mux.Handle("/", certManager.HTTPHandler(fallback))
+	return mux
+}
+
+// shutdownSummary records the outcome of every step of the shutdown.
+type shutdownSummary struct {
+	duration   time.Duration
+	http       error
+	sync       error
+	duplicates error
+	webhooks   error
+	database   error
+}
+
+func (s shutdownSummary) clean() bool {
+	return s.http == nil && s.sync == nil && s.duplicates == nil && s.webhooks == nil && s.database == nil
+}
+
+func (s shutdownSummary) log(logger *logging.Logger) {
+	keyValues := []interface{}{"duration", s.duration}
+	for _, step := range []struct {
+		name string
+		err  error
+	}{{"http", s.http}, {"lyrics_sync", s.sync}, {"duplicate_detection", s.duplicates}, {"webhooks", s.webhooks}, {"database", s.database}} {
+		result := "ok"
+		if step.err != nil {
+			result = step.err.Error()
+		}
+		keyValues = append(keyValues, step.name, result)
+	}
+
+	if s.clean() {
+		logger.Info("shutdown finished", keyValues...)
+	} else {
+		logger.Error("shutdown finished with errors", keyValues...)
+	}
+}
+
+// shutdown drains the http servers while a running lyrics sync and duplicate detection are stopped. Requests that do not finish in time are
+// cancelled. Afterwards, it waits for pending webhook deliveries and disconnects from the database.
+func shutdown(ctx context.Context, servers []*http.Server, cancelRequests context.CancelFunc, s *api.Server, dbConn *db.Repositories) shutdownSummary {
+	var summary shutdownSummary
+	start := time.Now()
+
+	syncStopped := make(chan error, 1)
+	go func() {
+		syncStopped <- s.StopSync(ctx)
+	}()
+	detectionStopped := make(chan error, 1)
+	go func() {
+		detectionStopped <- s.StopDuplicateDetection(ctx)
+	}()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			summary.http = err
+			cancelRequests()
+			_ = srv.Close()
+		}
 	}
+	summary.sync = <-syncStopped
+	summary.duplicates = <-detectionStopped
+	summary.webhooks = s.WaitForWebhooks(ctx)
+	summary.database = dbConn.Close(ctx)
+
+	summary.duration = time.Since(start)
+	return summary
 }
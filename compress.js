@@ -0,0 +1,37 @@
+// Writes gzip and brotli compressed variants next to the files of the compiled frontend. The web server sends them to
+// clients supporting these encodings instead of compressing every response on the fly.
+const fs = require('fs');
+const path = require('path');
+const zlib = require('zlib');
+
+const outputDir = path.join(__dirname, 'pkg', 'frontend', 'build', 'public');
+const extensions = ['.html', '.js', '.css', '.svg', '.json', '.txt', '.ico', '.ttf', '.eot'];
+// compressing tiny files does not pay off
+const minSize = 1024;
+
+/**
+ * Returns the paths of all files in dir and its subdirectories.
+ * @param {string} dir
+ * @return {string[]}
+ */
+function files(dir) {
+  return fs.readdirSync(dir, {withFileTypes: true}).flatMap((entry) => {
+    const file = path.join(dir, entry.name);
+    return entry.isDirectory() ? files(file) : [file];
+  });
+}
+
+for (const file of files(outputDir)) {
+  if (!extensions.includes(path.extname(file))) {
+    continue;
+  }
+  const content = fs.readFileSync(file);
+  if (content.length < minSize) {
+    continue;
+  }
+
+  fs.writeFileSync(file + '.gz', zlib.gzipSync(content, {level: zlib.constants.Z_BEST_COMPRESSION}));
+  fs.writeFileSync(file + '.br', zlib.brotliCompressSync(content, {
+    params: {[zlib.constants.BROTLI_PARAM_QUALITY]: zlib.constants.BROTLI_MAX_QUALITY},
+  }));
+}
@@ -11,6 +11,7 @@ require (
 	github.com/imba28/lyric-api-go v0.1.12
 	github.com/jarcoal/httpmock v1.2.0
 	github.com/pemistahl/lingua-go v1.0.5
+	github.com/prometheus/client_golang v1.12.2
 	github.com/rs/cors v1.8.2
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/pflag v1.0.5
@@ -18,5 +19,6 @@ require (
 	github.com/stretchr/testify v1.7.1
 	github.com/zmb3/spotify/v2 v2.3.0
 	go.mongodb.org/mongo-driver v1.9.1
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	golang.org/x/oauth2 v0.0.0-20220909003341-f21342109be1
 )
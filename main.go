@@ -14,6 +14,7 @@ func main() {
 	rootCmd.AddCommand(cmd.NewDoctorCommand())
 	rootCmd.AddCommand(cmd.NewWebCommand())
 	rootCmd.AddCommand(cmd.NewFixturesCommand())
+	rootCmd.AddCommand(cmd.NewMigrateCommand())
 
 	err := rootCmd.Execute()
 	if err != nil {
@@ -1,14 +1,25 @@
 package api
 
 import (
+	"context"
 	"github.com/gorilla/mux"
+	"github.com/imba28/spolyr/pkg/cache"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/frontend"
+	"github.com/imba28/spolyr/pkg/health"
 	jwt2 "github.com/imba28/spolyr/pkg/jwt"
+	"github.com/imba28/spolyr/pkg/logging"
 	"github.com/imba28/spolyr/pkg/lyrics"
+	"github.com/imba28/spolyr/pkg/metrics"
 	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/orphans"
+	"github.com/imba28/spolyr/pkg/webhooks"
 	"github.com/rs/cors"
+	"io/fs"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type languageDetector interface {
@@ -16,15 +27,43 @@ type languageDetector interface {
 }
 
 func (s *Server) apiHandler() http.Handler {
-	fetcher := lyrics.New(s.geniusAPIToken, 3, s.languageDetector)
-	syncer := lyrics.NewSyncer(fetcher, s.db.Tracks)
+	var tracks db.TrackRepository = s.db.Tracks
+	var languages languageDetector = s.languageDetector
+	if s.cache != nil {
+		tracks = cache.NewTrackRepository(tracks, s.cache, s.cacheTTL)
+		languages = cache.NewLanguageDetector(languages, s.cache, s.cacheTTL)
+	}
+
+	fetcher := lyrics.New(s.geniusAPIToken, 3, languages)
+	syncer := lyrics.NewSyncer(fetcher, tracks)
+	s.syncer = syncer
+	detector := duplicates.New(tracks)
+	s.detections = duplicates.NewScheduler(detector)
+	reconciler := orphans.New(tracks, s.orphanPolicy, s.orphanRetention)
+	syncer.OnFinished(func(r lyrics.SyncResult) {
+		s.dispatcher.Dispatch(webhooks.EventLyricsSyncFinished, webhooks.LyricsSyncData{
+			Total:      r.Total,
+			Successful: r.Successful,
+			Failed:     r.Failed,
+			Cancelled:  r.Cancelled,
+		})
+	})
 
 	authApiController := openapi.NewAuthApiController(newAuthApiService(s.oauthClientID, s.oauthClientSecret, s.secret, s.publicProtocol, s.publicDomain, s.publicHttpPort))
-	importController := openapi.NewImportApiController(newImportApiService(s.db.Tracks, syncer, fetcher, s.languageDetector))
-	tracksApiController := openapi.NewTracksApiController(newTracksApiService(s.db.Tracks, s.languageDetector))
-	playlistController := openapi.NewPlaylistsApiController(newPlaylistApiService())
+	importController := openapi.NewImportApiController(newImportApiService(tracks, s.db.Playlists, s.db.History, syncer, fetcher, languages, s.detections, reconciler, s.dispatcher))
+	tracksApiController := openapi.NewTracksApiController(newTracksApiService(tracks, languages, s.orphanPolicy.HidesOrphans(), s.dispatcher))
+	playlistController := openapi.NewPlaylistsApiController(newPlaylistApiService(s.db.Playlists, tracks))
+	duplicatesController := openapi.NewDuplicatesApiController(newDuplicatesApiService(tracks, detector))
+	orphansController := openapi.NewOrphansApiController(newOrphansApiService(tracks, reconciler))
+	historyController := openapi.NewHistoryApiController(newHistoryApiService(s.db.History))
+	playerController := openapi.NewPlayerApiController(newPlayerApiService(tracks, fetcher))
+	reviewController := openapi.NewReviewApiController(newReviewApiService(tracks, fetcher, lyrics.Providers(s.geniusAPIToken), s.dispatcher))
+	webhooksController := openapi.NewWebhooksApiController(newWebhooksApiService(s.db.Webhooks))
+
+	r := openapi.NewRouter(authApiController, tracksApiController, importController, playlistController, duplicatesController, orphansController, historyController, playerController, reviewController, webhooksController)
 
-	r := openapi.NewRouter(authApiController, tracksApiController, importController, playlistController)
+	r.Use(metrics.Middleware)
+	r.Use(etagMiddleware("TracksGet", "TracksIdGet"))
 
 	var handler http.Handler = r
 
@@ -33,14 +72,14 @@ func (s *Server) apiHandler() http.Handler {
 			AllowCredentials: true,
 			AllowedOrigins:   []string{"https://localhost:8081", "https://127.0.0.1:8081"},
 			AllowedHeaders:   []string{"User-Agent", "Content-Type"},
-			AllowedMethods:   []string{"GET", "POST", "PATCH", "OPTIONS"},
+			AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
 			MaxAge:           3600,
 			Debug:            true,
 		})
 		handler = c.Handler(r)
 	}
 
-	return AuthenticationMiddleware(jwt2.New(s.secret))(handler)
+	return logging.Middleware(AuthenticationMiddleware(jwt2.New(s.secret))(handler))
 }
 
 type Server struct {
@@ -51,6 +90,18 @@ type Server struct {
 	secret            []byte
 	languageDetector  languageDetector
 
+	cache    cache.Backend
+	cacheTTL time.Duration
+
+	orphanPolicy    orphans.Policy
+	orphanRetention time.Duration
+
+	frontend fs.FS
+
+	dispatcher *webhooks.Dispatcher
+	syncer     *lyrics.Syncer
+	detections *duplicates.Scheduler
+
 	env    Env
 	router *mux.Router
 
@@ -59,18 +110,64 @@ type Server struct {
 	publicProtocol string
 
 	sync.Once
+	// initMu guards isInitialized and is held while the server is initialized.
+	initMu        sync.Mutex
+	isInitialized bool
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.Do(func() {
+		s.initMu.Lock()
+		defer s.initMu.Unlock()
 		s.init()
+		s.isInitialized = true
 	})
 	s.router.ServeHTTP(w, r)
 }
 
+// StopSync cancels a running lyrics sync and waits until the lyrics fetched so far have been saved. No new syncs can be
+// started afterwards.
+func (s *Server) StopSync(ctx context.Context) error {
+	if !s.initialized() {
+		return nil
+	}
+	return s.syncer.Shutdown(ctx)
+}
+
+// StopDuplicateDetection cancels a running duplicate detection and waits until it has stopped. No new detections are
+// started afterwards.
+func (s *Server) StopDuplicateDetection(ctx context.Context) error {
+	if !s.initialized() {
+		return nil
+	}
+	return s.detections.Shutdown(ctx)
+}
+
+// WaitForWebhooks blocks until all pending webhook deliveries have finished or ctx is done.
+func (s *Server) WaitForWebhooks(ctx context.Context) error {
+	if !s.initialized() {
+		return nil
+	}
+	return s.dispatcher.Shutdown(ctx)
+}
+
+// initialized reports whether the server has handled a request and therefore might run syncs or deliver webhooks.
+// It waits for an initialization in progress, but does not initialize the server itself, so shutting down a server
+// that never handled a request does not build the api.
+func (s *Server) initialized() bool {
+	s.initMu.Lock()
+	defer s.initMu.Unlock()
+	return s.isInitialized
+}
+
 func (s *Server) init() {
+	s.dispatcher = webhooks.New(s.db.Webhooks)
+
+	s.router.Handle("/metrics", metrics.Handler())
+	s.router.Handle("/healthz", health.Liveness())
+	s.router.Handle("/readyz", newHealthChecker(s.db, s.geniusAPIToken).Readiness())
 	s.router.PathPrefix("/api").Handler(s.apiHandler())
-	s.router.PathPrefix("/").Handler(spaFileHandler("public"))
+	s.router.PathPrefix("/").Handler(spaFileHandler(s.frontend))
 }
 
 func NewServer(options ...ServerOptions) *Server {
@@ -79,6 +176,9 @@ func NewServer(options ...ServerOptions) *Server {
 		router: mux.NewRouter(),
 		env:    Prod,
 
+		orphanPolicy: orphans.PolicyKeep,
+		frontend:     frontend.FS(),
+
 		publicDomain:   "localhost",
 		publicProtocol: "http",
 		publicHttpPort: 8080,
@@ -134,6 +234,31 @@ func WithReverseProxy(protocol, domain string, port int) ServerOptions {
 	}
 }
 
+// WithOrphanPolicy sets how tracks are treated that have been removed from the library and all imported playlists.
+// Using orphans.PolicyDelete, orphaned tracks are deleted after the retention period.
+func WithOrphanPolicy(policy orphans.Policy, retention time.Duration) ServerOptions {
+	return func(s *Server) {
+		s.orphanPolicy = policy
+		s.orphanRetention = retention
+	}
+}
+
+// WithFrontend serves the single page application from files instead of the frontend embedded into the binary.
+func WithFrontend(files fs.FS) ServerOptions {
+	return func(s *Server) {
+		s.frontend = files
+	}
+}
+
+// WithCache caches track reads and detected languages in backend for at most ttl. Writes through the api invalidate
+// the cached tracks immediately.
+func WithCache(backend cache.Backend, ttl time.Duration) ServerOptions {
+	return func(s *Server) {
+		s.cache = backend
+		s.cacheTTL = ttl
+	}
+}
+
 type Env int
 
 const (
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_shutdown_without_requests(t *testing.T) {
+	s := NewServer()
+
+	assert.Nil(t, s.StopSync(context.Background()))
+	assert.Nil(t, s.StopDuplicateDetection(context.Background()))
+	assert.Nil(t, s.WaitForWebhooks(context.Background()))
+	assert.Nil(t, s.syncer, "shutting down should not initialize the server")
+	assert.Nil(t, s.detections)
+	assert.Nil(t, s.dispatcher)
+}
+
+func TestServer_routes_requests_after_shutdown(t *testing.T) {
+	s := NewServer(WithDatabase(&db.Repositories{}))
+	assert.Nil(t, s.StopSync(context.Background()))
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
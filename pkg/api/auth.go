@@ -22,6 +22,7 @@ const (
 	jwtRefreshKey
 	jwtAccessKey
 	spotifyOauthClientKey
+	tlsListenerKey
 
 	accessTokenExpiry  = time.Minute * 10
 	refreshTokenExpiry = time.Hour * 24
@@ -32,7 +33,11 @@ var (
 		spotifyauth.ScopeUserLibraryRead,
 		spotifyauth.ScopeUserReadEmail,
 		spotifyauth.ScopePlaylistReadCollaborative,
-		spotifyauth.ScopePlaylistReadPrivate}
+		spotifyauth.ScopePlaylistReadPrivate,
+		spotifyauth.ScopeUserFollowRead,
+		spotifyauth.ScopeUserReadRecentlyPlayed,
+		spotifyauth.ScopeUserTopRead,
+		spotifyauth.ScopeUserReadCurrentlyPlaying}
 	scope = strings.Join(permissions, " ")
 	auth  = spotifyauth.New(spotifyauth.WithScopes(scope))
 
@@ -77,6 +82,13 @@ func (a AuthApiService) redirectUrl() string {
 	return fmt.Sprintf("%s://%s%s/auth/callback", a.publicHttpProtocol, a.publicHostname, publicPort)
 }
 
+// servedOverTLS reports whether the request has been received by the https listener of Spolyr itself, as opposed to a
+// reverse proxy terminating TLS.
+func servedOverTLS(ctx context.Context) bool {
+	v, _ := ctx.Value(tlsListenerKey).(bool)
+	return v
+}
+
 func isAuthenticated(ctx context.Context) bool {
 	t := accessTokenFromContext(ctx)
 	return t != nil
@@ -91,7 +103,7 @@ func hasValidRefreshToken(ctx context.Context) bool {
 func AuthenticationMiddleware(j jwt2.JWT) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := r.Context()
+			ctx := context.WithValue(r.Context(), tlsListenerKey, r.TLS != nil)
 
 			if r.Method != http.MethodOptions {
 				if c, err := r.Cookie("jwt"); err == nil {
@@ -123,9 +135,15 @@ type AuthApiService struct {
 	publicHttpPort     int
 }
 
-func (a AuthApiService) cookie(name, value, path string) http.Cookie {
+// cookie creates a session cookie. Cookies are secure if Spolyr serves https itself or is configured to be reachable
+// using https. The frontend is always served from the same origin by the https listener, whereas a reverse proxy might
+// serve it from a different one, which requires SameSite=None.
+func (a AuthApiService) cookie(ctx context.Context, name, value, path string) http.Cookie {
+	secure := a.publicHttpProtocol == "https"
 	sameSite := http.SameSiteLaxMode
-	if a.publicHttpProtocol == "https" {
+	if servedOverTLS(ctx) {
+		secure = true
+	} else if secure {
 		sameSite = http.SameSiteNoneMode
 	}
 
@@ -133,13 +151,13 @@ func (a AuthApiService) cookie(name, value, path string) http.Cookie {
 		Name:     name,
 		Path:     path,
 		Value:    value,
-		Secure:   a.publicHttpProtocol == "https",
+		Secure:   secure,
 		HttpOnly: true,
 		SameSite: sameSite,
 	}
 }
 
-func (a AuthApiService) jwtTokenHeaders(t oauth2.Token, generateRefreshToken bool) (map[string][]string, error) {
+func (a AuthApiService) jwtTokenHeaders(ctx context.Context, t oauth2.Token, generateRefreshToken bool) (map[string][]string, error) {
 	headers := make(map[string][]string)
 	var cookies []string
 
@@ -147,7 +165,7 @@ func (a AuthApiService) jwtTokenHeaders(t oauth2.Token, generateRefreshToken boo
 	if err != nil {
 		return nil, errors.New("could not sign access jwt")
 	}
-	accessTokenCookie := a.cookie("jwt", accessToken, "/api")
+	accessTokenCookie := a.cookie(ctx, "jwt", accessToken, "/api")
 	cookies = append(cookies, accessTokenCookie.String())
 
 	if generateRefreshToken {
@@ -156,7 +174,7 @@ func (a AuthApiService) jwtTokenHeaders(t oauth2.Token, generateRefreshToken boo
 		if err != nil {
 			return nil, errors.New("could not sign refresh jwt")
 		}
-		refreshTokenCookie := a.cookie("jwt-refresh", refreshToken, "/api/auth")
+		refreshTokenCookie := a.cookie(ctx, "jwt-refresh", refreshToken, "/api/auth")
 		refreshTokenCookie.Expires = time.Now().Add(refreshTokenExpiry)
 		cookies = append(cookies, refreshTokenCookie.String())
 	}
@@ -167,11 +185,11 @@ func (a AuthApiService) jwtTokenHeaders(t oauth2.Token, generateRefreshToken boo
 }
 
 func (a AuthApiService) AuthLogoutGet(ctx context.Context) (openapi.ImplResponse, error) {
-	accessTokenCookie := a.cookie("jwt", "1", "/api")
+	accessTokenCookie := a.cookie(ctx, "jwt", "1", "/api")
 	accessTokenCookie.Expires = time.Unix(0, 0)
 
 	// todo: revoke refresh token => delete from database
-	refreshTokenCookie := a.cookie("jwt-refresh", "1", "/api/auth")
+	refreshTokenCookie := a.cookie(ctx, "jwt-refresh", "1", "/api/auth")
 	refreshTokenCookie.Expires = time.Unix(0, 0)
 
 	headers := make(map[string][]string)
@@ -192,7 +210,7 @@ func (a AuthApiService) AuthLoginPost(ctx context.Context, request openapi.AuthL
 		return openapi.Response(http.StatusInternalServerError, nil), errors.New("could not get user info")
 	}
 
-	headers, err := a.jwtTokenHeaders(*t, true)
+	headers, err := a.jwtTokenHeaders(ctx, *t, true)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), err
 	}
@@ -231,7 +249,7 @@ func (a AuthApiService) AuthRefreshGet(ctx context.Context) (openapi.ImplRespons
 		return openapi.Response(http.StatusInternalServerError, nil), errors.New("refreshing oauth2 access token failed")
 	}
 
-	headers, err := a.jwtTokenHeaders(*newToken, false)
+	headers, err := a.jwtTokenHeaders(ctx, *newToken, false)
 
 	return openapi.ResponseWithHeaders(http.StatusOK, headers, nil), nil
 }
@@ -212,6 +212,46 @@ func TestAuthApiService_AuthLoginPost_secure_cookies(t *testing.T) {
 	})
 }
 
+func TestAuthApiService_cookie(t *testing.T) {
+	tests := []struct {
+		name         string
+		protocol     string
+		tls          bool
+		wantSecure   bool
+		wantSameSite http.SameSite
+	}{
+		{"plain http", "http", false, false, http.SameSiteLaxMode},
+		{"reverse proxy using https", "https", false, true, http.SameSiteNoneMode},
+		{"https listener", "http", true, true, http.SameSiteLaxMode},
+		{"https listener and public https", "https", true, true, http.SameSiteLaxMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), tlsListenerKey, tt.tls)
+			a := AuthApiService{publicHttpProtocol: tt.protocol}
+
+			c := a.cookie(ctx, "jwt", "token", "/api")
+
+			assert.Equal(t, tt.wantSecure, c.Secure)
+			assert.Equal(t, tt.wantSameSite, c.SameSite)
+			assert.True(t, c.HttpOnly)
+		})
+	}
+}
+
+func TestAuthenticationMiddleware__detects_tls_listener(t *testing.T) {
+	var viaTLS bool
+	h := AuthenticationMiddleware(jwt2.New([]byte("secret")))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viaTLS = servedOverTLS(r.Context())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost/api/tracks", nil))
+	assert.False(t, viaTLS)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "https://localhost/api/tracks", nil))
+	assert.True(t, viaTLS)
+}
+
 func TestAuthApiService_AuthLoginPost(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"net/http"
+)
+
+type duplicateDetector interface {
+	Detect(ctx context.Context) (duplicates.Result, error)
+	Merge(ctx context.Context, spotifyIDs []string) (string, []*db.Track, error)
+	Unmerge(ctx context.Context, groupID, spotifyID string) error
+	Dissolve(ctx context.Context, groupID string) error
+}
+
+type duplicateScheduler interface {
+	Schedule(ctx context.Context)
+}
+
+type duplicatesApiService struct {
+	repo     db.TrackRepository
+	detector duplicateDetector
+}
+
+func (d duplicatesApiService) DuplicatesGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	groups, total, err := d.repo.Groups(ctx, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.TrackGroup, len(groups))
+	for i := range groups {
+		data[i] = toTrackGroup(groups[i].ID, groups[i].Tracks)
+	}
+
+	return openapi.Response(http.StatusOK, openapi.DuplicatesGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+func (d duplicatesApiService) DuplicatesPost(ctx context.Context, request openapi.DuplicatesPostRequest) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	groupID, tracks, err := d.detector.Merge(ctx, request.SpotifyIds)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, toTrackGroup(groupID, tracks)), nil
+	case duplicates.ErrNotEnoughTracks:
+		return openapi.Response(http.StatusBadRequest, nil), err
+	case db.ErrTrackNotFound:
+		return openapi.Response(http.StatusNotFound, nil), err
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+}
+
+func (d duplicatesApiService) DuplicatesDetectPost(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	r, err := d.detector.Detect(ctx)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	return openapi.Response(http.StatusOK, openapi.DuplicatesDetection{
+		NumberOfGroups:        int32(r.Groups),
+		NumberOfTracksChanged: int32(r.TracksChanged),
+		NumberOfSharedLyrics:  int32(r.LyricsShared),
+	}), nil
+}
+
+func (d duplicatesApiService) DuplicatesIdDelete(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	err := d.detector.Dissolve(ctx, id)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, nil), nil
+	case db.ErrTracksNotFound:
+		return openapi.Response(http.StatusNotFound, nil), err
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+}
+
+func (d duplicatesApiService) DuplicatesIdTracksTrackIdDelete(ctx context.Context, id string, trackId string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	err := d.detector.Unmerge(ctx, id, trackId)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, nil), nil
+	case db.ErrTrackNotFound:
+		return openapi.Response(http.StatusNotFound, nil), err
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+}
+
+func toTrackGroup(id string, tracks []*db.Track) openapi.TrackGroup {
+	g := openapi.TrackGroup{
+		Id:     id,
+		Tracks: make([]openapi.TrackInfo, len(tracks)),
+	}
+	for i := range tracks {
+		g.Tracks[i] = toTrackInfo(*tracks[i])
+	}
+	return g
+}
+
+var _ openapi.DuplicatesApiServicer = &duplicatesApiService{}
+
+func newDuplicatesApiService(repo db.TrackRepository, detector duplicateDetector) duplicatesApiService {
+	return duplicatesApiService{
+		repo:     repo,
+		detector: detector,
+	}
+}
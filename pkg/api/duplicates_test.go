@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+)
+
+type duplicateDetectorMock struct {
+	mock.Mock
+}
+
+func (d *duplicateDetectorMock) Detect(ctx context.Context) (duplicates.Result, error) {
+	args := d.Called()
+	return args.Get(0).(duplicates.Result), args.Error(1)
+}
+func (d *duplicateDetectorMock) Merge(ctx context.Context, spotifyIDs []string) (string, []*db.Track, error) {
+	args := d.Called(spotifyIDs)
+	return args.String(0), args.Get(1).([]*db.Track), args.Error(2)
+}
+func (d *duplicateDetectorMock) Unmerge(ctx context.Context, groupID, spotifyID string) error {
+	return d.Called(groupID, spotifyID).Error(0)
+}
+func (d *duplicateDetectorMock) Dissolve(ctx context.Context, groupID string) error {
+	return d.Called(groupID).Error(0)
+}
+
+var _ duplicateDetector = &duplicateDetectorMock{}
+
+func TestDuplicatesApiService_DuplicatesGet(t *testing.T) {
+	repoMock := new(trackRepoMock)
+	repoMock.On("Groups", 2, 10).Return([]db.TrackGroup{
+		{ID: "a", Tracks: []*db.Track{{SpotifyID: "a", Artist: "Queen, David Bowie"}, {SpotifyID: "b"}}},
+	}, 11, nil)
+	service := duplicatesApiService{repo: repoMock}
+
+	res, err := service.DuplicatesGet(context.Background(), 2, 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.DuplicatesGet200Response)
+	assert.Equal(t, int32(11), body.Meta.Total)
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, "a", body.Data[0].Id)
+	assert.Equal(t, []string{"Queen", "David Bowie"}, body.Data[0].Tracks[0].Artists)
+}
+
+func TestDuplicatesApiService_DuplicatesPost(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := duplicatesApiService{}
+		res, err := service.DuplicatesPost(context.Background(), openapi.DuplicatesPostRequest{})
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("merges tracks", func(t *testing.T) {
+		ids := []string{"a", "b"}
+		detector := new(duplicateDetectorMock)
+		detector.On("Merge", ids).Return("a", []*db.Track{{SpotifyID: "a"}, {SpotifyID: "b"}}, nil)
+		service := duplicatesApiService{detector: detector}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.DuplicatesPost(ctx, openapi.DuplicatesPostRequest{SpotifyIds: ids})
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Len(t, res.Body.(openapi.TrackGroup).Tracks, 2)
+	})
+
+	t.Run("returns bad request if less than two tracks are given", func(t *testing.T) {
+		detector := new(duplicateDetectorMock)
+		detector.On("Merge", []string{"a"}).Return("", []*db.Track(nil), duplicates.ErrNotEnoughTracks)
+		service := duplicatesApiService{detector: detector}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.DuplicatesPost(ctx, openapi.DuplicatesPostRequest{SpotifyIds: []string{"a"}})
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestDuplicatesApiService_DuplicatesIdTracksTrackIdDelete(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := duplicatesApiService{}
+		res, err := service.DuplicatesIdTracksTrackIdDelete(context.Background(), "a", "b")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns not found if track is not part of the group", func(t *testing.T) {
+		detector := new(duplicateDetectorMock)
+		detector.On("Unmerge", "a", "c").Return(db.ErrTrackNotFound)
+		service := duplicatesApiService{detector: detector}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, _ := service.DuplicatesIdTracksTrackIdDelete(ctx, "a", "c")
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestDuplicatesApiService_DuplicatesDetectPost(t *testing.T) {
+	detector := new(duplicateDetectorMock)
+	detector.On("Detect").Return(duplicates.Result{Groups: 2, LyricsShared: 1, TracksChanged: 4}, nil)
+	service := duplicatesApiService{detector: detector}
+
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+	res, err := service.DuplicatesDetectPost(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, openapi.DuplicatesDetection{NumberOfGroups: 2, NumberOfSharedLyrics: 1, NumberOfTracksChanged: 4}, res.Body)
+}
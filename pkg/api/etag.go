@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// etagRecorder buffers the response, so its ETag can be computed before anything is sent to the client.
+type etagRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *etagRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *etagRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *etagRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+// etag returns a strong entity tag of the body.
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header contains the entity tag. Entity tags are compared using the
+// weak comparison required for If-None-Match.
+func etagMatches(ifNoneMatch, tag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMiddleware adds an ETag to successful GET responses of the given routes and answers requests with a matching
+// If-None-Match header with 304 Not Modified, so clients do not download unchanged tracks and search results again.
+func etagMiddleware(routes ...string) mux.MiddlewareFunc {
+	names := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		names[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := mux.CurrentRoute(r)
+			if r.Method != http.MethodGet || current == nil || !names[current.GetName()] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &etagRecorder{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.status != http.StatusOK {
+				w.WriteHeader(rec.status)
+				_, _ = w.Write(rec.body.Bytes())
+				return
+			}
+
+			tag := etag(rec.body.Bytes())
+			w.Header().Set("ETag", tag)
+			if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, tag) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(rec.body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newETagRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.Methods("GET").Path("/tracks/{id}").Name("TracksIdGet").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"` + mux.Vars(r)["id"] + `"}`))
+	})
+	r.Methods("GET").Path("/history").Name("HistoryGet").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	})
+	r.Use(etagMiddleware("TracksIdGet"))
+	return r
+}
+
+func TestETagMiddleware(t *testing.T) {
+	r := newETagRouter()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/tracks/1", nil))
+	tag := rec.Header().Get("ETag")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"id":"1"}`, rec.Body.String())
+	assert.NotEmpty(t, tag)
+
+	tests := []struct {
+		name        string
+		path        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"matching etag", "/tracks/1", tag, http.StatusNotModified},
+		{"weak etag", "/tracks/1", "W/" + tag, http.StatusNotModified},
+		{"list of etags", "/tracks/1", `"abc", ` + tag, http.StatusNotModified},
+		{"wildcard", "/tracks/1", "*", http.StatusNotModified},
+		{"changed body", "/tracks/2", tag, http.StatusOK},
+		{"stale etag", "/tracks/1", `"abc"`, http.StatusOK},
+		{"errors", "/tracks/missing", "*", http.StatusNotFound},
+		{"other routes", "/history", "*", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+				assert.Equal(t, tag, rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestETagMiddleware__no_etag(t *testing.T) {
+	r := newETagRouter()
+
+	for _, path := range []string{"/tracks/missing", "/history"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+		assert.Empty(t, rec.Header().Get("ETag"), path)
+	}
+}
@@ -1,32 +1,135 @@
 package api
 
 import (
+	"io"
+	"io/fs"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
-func spaFileHandler(publicFolder string) http.HandlerFunc {
-	const indexPath = "index.html"
-	staticHandler := http.FileServer(http.Dir(publicFolder))
+const indexPath = "index.html"
 
+// hashedAsset matches files containing a content hash in their name, e.g. js/app.3f9a1c2e.js. Their content never
+// changes, so browsers may cache them forever.
+var hashedAsset = regexp.MustCompile(`\.[0-9a-f]{8,}\.[a-z0-9]+$`)
+
+// precompressed lists the content encodings of the precompressed variants of a file in order of preference.
+var precompressed = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// spaFileHandler serves the files of the single page application. Unknown paths are answered with index.html, so the
+// router of the frontend can handle them.
+func spaFileHandler(files fs.FS) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		path, err := filepath.Abs(r.URL.Path)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		for _, segment := range strings.Split(name, "/") {
+			if segment == ".." {
+				http.Error(w, "invalid URL path", http.StatusBadRequest)
+				return
+			}
 		}
 
-		path = filepath.Join(publicFolder, path)
-
-		_, err = os.Stat(path)
-		if os.IsNotExist(err) {
-			http.ServeFile(w, r, filepath.Join(publicFolder, indexPath))
+		name = path.Clean(name)
+		if name == indexPath {
+			http.Redirect(w, r, "/", http.StatusMovedPermanently)
 			return
-		} else if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		if !fs.ValidPath(name) {
+			http.Error(w, "invalid URL path", http.StatusBadRequest)
 			return
 		}
-		staticHandler.ServeHTTP(w, r)
+
+		if stat, err := fs.Stat(files, name); err != nil || stat.IsDir() {
+			name = indexPath
+		}
+		serveFile(w, r, files, name)
+	}
+}
+
+// serveFile writes the file using the best precompressed variant the client accepts.
+func serveFile(w http.ResponseWriter, r *http.Request, files fs.FS, name string) {
+	f, encoding, err := openVariant(files, name, r.Header.Get("Accept-Encoding"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "file is not seekable", http.StatusInternalServerError)
+		return
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Accept-Encoding")
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+	}
+	// the type must not be sniffed from compressed content
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.Set("Content-Type", contentType)
+
+	switch {
+	case name == indexPath:
+		h.Set("Cache-Control", "no-cache")
+	case hashedAsset.MatchString(name):
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+
+	http.ServeContent(w, r, name, stat.ModTime(), content)
+}
+
+// openVariant opens the precompressed variant of the file for the first encoding accepted by the client. If there is
+// none, the file itself is opened.
+func openVariant(files fs.FS, name, acceptEncoding string) (fs.File, string, error) {
+	for _, p := range precompressed {
+		if !acceptsEncoding(acceptEncoding, p.encoding) {
+			continue
+		}
+		if f, err := files.Open(name + p.extension); err == nil {
+			return f, p.encoding, nil
+		}
+	}
+
+	f, err := files.Open(name)
+	return f, "", err
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header contains the encoding and does not reject it with q=0.
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		if strings.TrimSpace(params[0]) != encoding {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
 	}
+	return false
 }
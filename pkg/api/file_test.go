@@ -2,16 +2,20 @@ package api
 
 import (
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 )
 
 func TestSpaFileHandler(t *testing.T) {
-	h := spaFileHandler("testdata")
+	h := spaFileHandler(os.DirFS("testdata"))
 
 	assert.HTTPBodyContainsf(t, h, "GET", "/", nil, "index.html says hello", "should return index.html")
 	assert.HTTPBodyContainsf(t, h, "GET", "./", nil, "index.html says hello", "should return index.html")
 	assert.HTTPBodyContainsf(t, h, "GET", "/non-existing-file", nil, "index.html says hello", "should return index.html")
 	assert.HTTPBodyContainsf(t, h, "GET", "/foo/bar/non-existing-file", nil, "index.html says hello", "should return index.html")
+	assert.HTTPBodyContainsf(t, h, "GET", "/js", nil, "index.html says hello", "directories should return index.html")
 
 	assert.HTTPBodyContainsf(t, h, "GET", "/style.css", nil, "body {}", "/style.css should return style.css")
 
@@ -19,3 +23,56 @@ func TestSpaFileHandler(t *testing.T) {
 	assert.HTTPError(t, h, "GET", "../", nil, "invalid paths should return an error")
 
 }
+
+func TestSpaFileHandler__precompressed(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		wantEncoding   string
+		wantBody       string
+	}{
+		{"no encoding accepted", "", "", "console.log(\"hello\")\n"},
+		{"gzip", "gzip, deflate", "gzip", "compressed with gzip\n"},
+		{"brotli is preferred", "gzip, deflate, br", "br", "compressed with brotli\n"},
+		{"brotli rejected", "gzip, br;q=0", "gzip", "compressed with gzip\n"},
+		{"unsupported encoding", "deflate", "", "console.log(\"hello\")\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/js/app.3f9a1c2e.js", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+
+			spaFileHandler(os.DirFS("testdata")).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantEncoding, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+			assert.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestSpaFileHandler__cache_headers(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"index.html", "/", "no-cache"},
+		{"client side route", "/tracks/1", "no-cache"},
+		{"hashed asset", "/js/app.3f9a1c2e.js", "public, max-age=31536000, immutable"},
+		{"asset without hash", "/style.css", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			spaFileHandler(os.DirFS("testdata")).ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Cache-Control"))
+		})
+	}
+}
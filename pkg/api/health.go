@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/health"
+	"github.com/imba28/spolyr/pkg/lyrics"
+	"time"
+)
+
+const healthCheckTimeout = 3 * time.Second
+
+var (
+	errNoLyricsProviders = errors.New("no lyrics providers configured")
+	errGeniusNotSetUp    = errors.New("genius api token is not configured, lyrics are only fetched from the remaining providers")
+)
+
+type readinessStore interface {
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
+}
+
+func newHealthChecker(store readinessStore, geniusAPIToken string) *health.Checker {
+	return health.New(healthCheckTimeout,
+		health.Check{Name: "mongo", Run: store.Ping},
+		health.Check{Name: "migrations", Run: store.CheckMigrations},
+		health.Check{Name: "lyrics_providers", Optional: true, Run: func(ctx context.Context) error {
+			return checkLyricsProviders(lyrics.Providers(geniusAPIToken))
+		}},
+	)
+}
+
+func checkLyricsProviders(providers []string) error {
+	if len(providers) == 0 {
+		return errNoLyricsProviders
+	}
+	for _, p := range providers {
+		if p == "genius" {
+			return nil
+		}
+	}
+	return errGeniusNotSetUp
+}
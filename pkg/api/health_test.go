@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type readinessStoreMock struct {
+	pingErr, migrationsErr error
+}
+
+func (m readinessStoreMock) Ping(ctx context.Context) error {
+	return m.pingErr
+}
+
+func (m readinessStoreMock) CheckMigrations(ctx context.Context) error {
+	return m.migrationsErr
+}
+
+func TestHealthChecker(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      readinessStoreMock
+		token      string
+		wantCode   int
+		wantChecks map[string]health.Status
+	}{
+		{
+			name:     "ready",
+			token:    "token",
+			wantCode: http.StatusOK,
+			wantChecks: map[string]health.Status{
+				"mongo":            health.StatusOK,
+				"migrations":       health.StatusOK,
+				"lyrics_providers": health.StatusOK,
+			},
+		},
+		{
+			name:     "database is not reachable",
+			store:    readinessStoreMock{pingErr: errors.New("connection refused")},
+			token:    "token",
+			wantCode: http.StatusServiceUnavailable,
+			wantChecks: map[string]health.Status{
+				"mongo":            health.StatusFail,
+				"migrations":       health.StatusOK,
+				"lyrics_providers": health.StatusOK,
+			},
+		},
+		{
+			name:     "pending migrations",
+			store:    readinessStoreMock{migrationsErr: db.ErrPendingMigrations},
+			token:    "token",
+			wantCode: http.StatusServiceUnavailable,
+			wantChecks: map[string]health.Status{
+				"mongo":            health.StatusOK,
+				"migrations":       health.StatusFail,
+				"lyrics_providers": health.StatusOK,
+			},
+		},
+		{
+			name:     "genius is not configured",
+			wantCode: http.StatusOK,
+			wantChecks: map[string]health.Status{
+				"mongo":            health.StatusOK,
+				"migrations":       health.StatusOK,
+				"lyrics_providers": health.StatusWarn,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newHealthChecker(tt.store, tt.token).Readiness().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+
+			var report health.Report
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			checks := make(map[string]health.Status)
+			for name, r := range report.Checks {
+				checks[name] = r.Status
+			}
+			assert.Equal(t, tt.wantChecks, checks)
+		})
+	}
+}
+
+func TestCheckLyricsProviders(t *testing.T) {
+	assert.Nil(t, checkLyricsProviders([]string{"genius", "songlyrics"}))
+	assert.ErrorIs(t, checkLyricsProviders([]string{"songlyrics"}), errGeniusNotSetUp)
+	assert.ErrorIs(t, checkLyricsProviders(nil), errNoLyricsProviders)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"net/http"
+)
+
+type historyApiService struct {
+	history db.HistoryRepository
+}
+
+func (h historyApiService) HistoryRecentGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	plays, total, err := h.history.RecentPlays(ctx, int(page), int(limit), true)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.PlayedTrack, len(plays))
+	for i := range plays {
+		data[i] = openapi.PlayedTrack{
+			PlayedAt: plays[i].PlayedAt,
+			Track:    toTrackDetail(*plays[i].Track),
+		}
+	}
+
+	return openapi.Response(http.StatusOK, openapi.HistoryRecentGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+func (h historyApiService) HistoryCountsGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	counts, total, err := h.history.PlayCounts(ctx, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.PlayCount, len(counts))
+	for i := range counts {
+		data[i] = openapi.PlayCount{
+			Track:        toTrackInfo(*counts[i].Track),
+			Plays:        int32(counts[i].Count),
+			LastPlayedAt: counts[i].LastPlayedAt,
+		}
+	}
+
+	return openapi.Response(http.StatusOK, openapi.HistoryCountsGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+var _ openapi.HistoryApiServicer = &historyApiService{}
+
+func newHistoryApiService(history db.HistoryRepository) historyApiService {
+	return historyApiService{
+		history: history,
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type historyRepoMock struct {
+	mock.Mock
+}
+
+func (h *historyRepoMock) SavePlays(ctx context.Context, plays []db.Play) (int, error) {
+	args := h.Called(plays)
+	return args.Int(0), args.Error(1)
+}
+func (h *historyRepoMock) LatestPlayedAt(ctx context.Context) (time.Time, error) {
+	args := h.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (h *historyRepoMock) RecentPlays(ctx context.Context, page, limit int, withLyrics bool) ([]db.PlayedTrack, int, error) {
+	args := h.Called(page, limit, withLyrics)
+	return args.Get(0).([]db.PlayedTrack), args.Int(1), args.Error(2)
+}
+func (h *historyRepoMock) PlayCounts(ctx context.Context, page, limit int) ([]db.PlayCount, int, error) {
+	args := h.Called(page, limit)
+	return args.Get(0).([]db.PlayCount), args.Int(1), args.Error(2)
+}
+
+var _ db.HistoryRepository = &historyRepoMock{}
+
+func TestHistoryApiService_HistoryRecentGet(t *testing.T) {
+	playedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	m := new(historyRepoMock)
+	m.On("RecentPlays", 1, 25, true).Return([]db.PlayedTrack{
+		{PlayedAt: playedAt, Track: &db.Track{SpotifyID: "a", Lyrics: "la la la", Loaded: true}},
+	}, 1, nil)
+	service := historyApiService{history: m}
+
+	res, err := service.HistoryRecentGet(context.Background(), 0, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.HistoryRecentGet200Response)
+	assert.Equal(t, int32(1), body.Meta.Total)
+	assert.Equal(t, playedAt, body.Data[0].PlayedAt)
+	assert.Equal(t, "la la la", body.Data[0].Track.Lyrics)
+}
+
+func TestHistoryApiService_HistoryCountsGet(t *testing.T) {
+	m := new(historyRepoMock)
+	m.On("PlayCounts", 2, 10).Return([]db.PlayCount{
+		{Track: &db.Track{SpotifyID: "a"}, Count: 3},
+	}, 11, nil)
+	service := historyApiService{history: m}
+
+	res, err := service.HistoryCountsGet(context.Background(), 2, 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.HistoryCountsGet200Response)
+	assert.Equal(t, int32(11), body.Meta.Total)
+	assert.Equal(t, int32(3), body.Data[0].Plays)
+	assert.Equal(t, "a", body.Data[0].Track.SpotifyId)
+}
@@ -4,31 +4,66 @@ import (
 	"context"
 	"errors"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/logging"
 	"github.com/imba28/spolyr/pkg/lyrics"
 	"github.com/imba28/spolyr/pkg/openapi"
 	"github.com/imba28/spolyr/pkg/spotify"
-	"log"
+	"github.com/imba28/spolyr/pkg/webhooks"
 	"net/http"
+	"time"
 )
 
 var (
 	errLyricsNotFound = errors.New("no lyrics found")
 )
 
-func newImportApiService(repo db.TrackRepository, syncer *lyrics.Syncer, fetcher lyrics.AsyncFetcher, d languageDetector) ImportApiServicer {
+// importModes maps the import modes of the api to whether a full import is requested.
+var importModes = map[string]bool{
+	"":            true,
+	"incremental": false,
+	"full":        true,
+}
+
+// artistIncludes maps the include parameter of the artist import to whether complete discographies are imported.
+var artistIncludes = map[string]bool{
+	"":            false,
+	"top-tracks":  false,
+	"discography": true,
+}
+
+func newImportApiService(repo db.TrackRepository, playlists db.PlaylistRepository, history db.HistoryRepository, syncer *lyrics.Syncer, fetcher lyrics.AsyncFetcher, d languageDetector, detections duplicateScheduler, reconciler orphanReconciler, events eventDispatcher) ImportApiServicer {
 	return ImportApiServicer{
 		repo:             repo,
+		playlists:        playlists,
+		history:          history,
 		syncer:           syncer,
 		fetcher:          fetcher,
 		languageDetector: d,
+		duplicates:       detections,
+		orphans:          reconciler,
+		events:           events,
 	}
 }
 
 type ImportApiServicer struct {
 	repo             db.TrackRepository
+	playlists        db.PlaylistRepository
+	history          db.HistoryRepository
 	syncer           *lyrics.Syncer
 	fetcher          lyrics.Fetcher
 	languageDetector languageDetector
+	duplicates       duplicateScheduler
+	orphans          orphanReconciler
+	events           eventDispatcher
+}
+
+// detectDuplicates groups newly imported tracks with already known releases of the same song in the background.
+func (i ImportApiServicer) detectDuplicates(ctx context.Context) {
+	if i.duplicates == nil {
+		return
+	}
+	i.duplicates.Schedule(ctx)
 }
 
 func (i ImportApiServicer) ImportLyricsTrackIdPost(ctx context.Context, id string) (openapi.ImplResponse, error) {
@@ -36,7 +71,7 @@ func (i ImportApiServicer) ImportLyricsTrackIdPost(ctx context.Context, id strin
 		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
 	}
 
-	t, err := i.repo.FindTrack(id)
+	t, err := i.repo.FindTrack(ctx, id)
 	if err != nil {
 		return openapi.Response(http.StatusNotFound, nil), nil
 	}
@@ -47,23 +82,38 @@ func (i ImportApiServicer) ImportLyricsTrackIdPost(ctx context.Context, id strin
 	}
 
 	err = i.fetcher.Fetch(t)
+	if errors.Is(err, lyrics.ErrLowConfidence) {
+		// keep the lyrics, so they can be reviewed
+		t.AddLyricsImportError(err, time.Now())
+		if err := i.repo.Save(ctx, t); err != nil {
+			return openapi.Response(http.StatusInternalServerError, nil), err
+		}
+		return openapi.Response(http.StatusNotFound, nil), err
+	}
 	if err != nil {
 		return openapi.Response(http.StatusNotFound, nil), errLyricsNotFound
 	}
 
-	languageOfLyrics, err := i.languageDetector.Detect(t.Lyrics)
-	if err != nil {
-		log.Printf("Could not detect language of track %d. Falling back to english.", t.ID)
-		t.Language = "english"
-	} else {
-		t.Language = languageOfLyrics
+	// instrumental tracks have no lyrics whose language could be detected
+	if !t.Instrumental {
+		languageOfLyrics, err := i.languageDetector.Detect(t.Lyrics)
+		if err != nil {
+			logging.FromContext(ctx).Warn("could not detect language of lyrics, falling back to english", "spotify_id", t.SpotifyID, "error", err)
+			t.Language = "english"
+		} else {
+			t.Language = languageOfLyrics
+		}
 	}
 
-	err = i.repo.Save(t)
+	err = i.repo.Save(ctx, t)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), err
 	}
 
+	if _, err := duplicates.ShareLyrics(ctx, i.repo, t); err != nil {
+		logging.FromContext(ctx).Warn("could not share lyrics with duplicates", "spotify_id", t.SpotifyID, "error", err)
+	}
+
 	return openapi.Response(http.StatusOK, toTrackDetail(*t)), nil
 }
 
@@ -88,17 +138,75 @@ func (i ImportApiServicer) ImportLyricsGet(ctx context.Context) (openapi.ImplRes
 	}), nil
 }
 
-func (i ImportApiServicer) ImportLibraryPost(ctx context.Context) (openapi.ImplResponse, error) {
+func (i ImportApiServicer) ImportLibraryPost(ctx context.Context, mode string) (openapi.ImplResponse, error) {
 	if !isAuthenticated(ctx) {
 		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
 	}
 
-	err := spotify.SyncTracks(ctx, spotify.NewSpotifyTrackProvider(oauthClientFromContext(ctx)), i.repo)
+	full, ok := importModes[mode]
+	if !ok {
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
+
+	var since time.Time
+	if !full {
+		var err error
+		since, err = i.repo.LibraryWatermark(ctx)
+		if err != nil {
+			return openapi.Response(http.StatusInternalServerError, nil), err
+		}
+	}
+
+	r, err := spotify.SyncTracks(ctx, spotify.NewSpotifyTrackProvider(oauthClientFromContext(ctx)), i.repo, since)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), err
 	}
+	i.advanceWatermark(ctx, r)
+	if r.New > 0 {
+		i.detectDuplicates(ctx)
+	}
+	if r.Complete {
+		i.reconcileOrphans(ctx, r.TrackIDs)
+	}
+	logImport(ctx, "library", r)
+	dispatch(i.events, webhooks.EventLibraryImported, toImportData("", r))
 
-	return openapi.Response(http.StatusOK, nil), nil
+	return openapi.Response(http.StatusOK, toImportSummary(r)), nil
+}
+
+// advanceWatermark moves the watermark of incremental library imports to the most recently added track. Imports that
+// failed to save a track keep the previous watermark, so the track is imported again by the next incremental import.
+func (i ImportApiServicer) advanceWatermark(ctx context.Context, r spotify.ImportResult) {
+	if r.Failed > 0 {
+		return
+	}
+	latest, err := i.repo.LatestAddedAt(ctx)
+	if err == nil && !latest.IsZero() {
+		err = i.repo.SetLibraryWatermark(ctx, latest)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("could not store library import watermark", "error", err)
+	}
+}
+
+// reconcileOrphans marks tracks that have been removed from the library. It requires the IDs of all tracks of the library.
+func (i ImportApiServicer) reconcileOrphans(ctx context.Context, libraryIDs []string) {
+	if i.orphans == nil {
+		return
+	}
+	if _, err := i.orphans.Reconcile(ctx, libraryIDs); err != nil {
+		logging.FromContext(ctx).Warn("could not reconcile orphaned tracks", "error", err)
+	}
+}
+
+// reconcilePlaylistOrphans marks tracks that have been removed from all imported playlists.
+func (i ImportApiServicer) reconcilePlaylistOrphans(ctx context.Context) {
+	if i.orphans == nil {
+		return
+	}
+	if _, err := i.orphans.ReconcilePlaylists(ctx); err != nil {
+		logging.FromContext(ctx).Warn("could not reconcile orphaned tracks", "error", err)
+	}
 }
 
 func (i ImportApiServicer) ImportLyricsPost(ctx context.Context) (openapi.ImplResponse, error) {
@@ -106,27 +214,121 @@ func (i ImportApiServicer) ImportLyricsPost(ctx context.Context) (openapi.ImplRe
 		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
 	}
 
-	_, err := i.syncer.Sync()
-	if err == lyrics.ErrBusy {
+	_, err := i.syncer.Sync(ctx)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, nil), nil
+	case lyrics.ErrBusy:
 		return openapi.Response(http.StatusTooManyRequests, nil), nil
+	case lyrics.ErrClosed:
+		return openapi.Response(http.StatusServiceUnavailable, nil), nil
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
 	}
-
-	return openapi.Response(http.StatusOK, nil), nil
 }
 
-func (i ImportApiServicer) ImportPlaylistIdPost(ctx context.Context, playlistId string) (openapi.ImplResponse, error) {
+func (i ImportApiServicer) ImportPlaylistIdPost(ctx context.Context, playlistId string, mode string) (openapi.ImplResponse, error) {
 	if !isAuthenticated(ctx) {
 		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
 	}
 
+	full, ok := importModes[mode]
+	if !ok {
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
+
 	c := oauthClientFromContext(ctx)
-	err := spotify.NewPlaylistProvider(c, i.repo).Download(ctx, playlistId)
+	r, err := spotify.NewPlaylistProvider(c, i.repo, i.playlists).Download(ctx, playlistId, full)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), nil
 	}
+	if r.New > 0 {
+		i.detectDuplicates(ctx)
+	}
+	if r.Complete {
+		i.reconcilePlaylistOrphans(ctx)
+	}
+	logImport(ctx, "playlist", r)
+	dispatch(i.events, webhooks.EventPlaylistImported, toImportData(playlistId, r))
 
-	return openapi.Response(http.StatusOK, nil), nil
+	return openapi.Response(http.StatusOK, toImportSummary(r)), nil
+}
 
+func (i ImportApiServicer) ImportAlbumsPost(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	r, err := spotify.NewAlbumProvider(oauthClientFromContext(ctx), i.repo).SavedAlbums(ctx)
+	return i.importResponse(ctx, "albums", r, err)
+}
+
+func (i ImportApiServicer) ImportAlbumIdPost(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	r, err := spotify.NewAlbumProvider(oauthClientFromContext(ctx), i.repo).Album(ctx, id)
+	return i.importResponse(ctx, "album", r, err)
+}
+
+func (i ImportApiServicer) ImportArtistsPost(ctx context.Context, include string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	discography, ok := artistIncludes[include]
+	if !ok {
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
+
+	r, err := spotify.NewAlbumProvider(oauthClientFromContext(ctx), i.repo).FollowedArtists(ctx, discography)
+	return i.importResponse(ctx, "artists", r, err)
+}
+
+func (i ImportApiServicer) ImportHistoryPost(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	r, err := spotify.NewHistoryProvider(oauthClientFromContext(ctx), i.repo, i.history).Import(ctx)
+	return i.importResponse(ctx, "history", r, err)
+}
+
+func (i ImportApiServicer) importResponse(ctx context.Context, source string, r spotify.ImportResult, err error) (openapi.ImplResponse, error) {
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+	logImport(ctx, source, r)
+	if r.New > 0 {
+		i.detectDuplicates(ctx)
+	}
+
+	return openapi.Response(http.StatusOK, toImportSummary(r)), nil
+}
+
+func logImport(ctx context.Context, source string, r spotify.ImportResult) {
+	logging.FromContext(ctx).Info("import finished", "source", source, "new", r.New, "unchanged", r.Unchanged, "failed", r.Failed, "skipped", r.Skipped, "plays", r.Plays)
+}
+
+func toImportSummary(r spotify.ImportResult) openapi.ImportSummary {
+	return openapi.ImportSummary{
+		New:       int32(r.New),
+		Unchanged: int32(r.Unchanged),
+		Skipped:   r.Skipped,
+		Plays:     int32(r.Plays),
+		Failed:    int32(r.Failed),
+	}
+}
+
+func toImportData(playlistID string, r spotify.ImportResult) webhooks.ImportData {
+	return webhooks.ImportData{
+		PlaylistID: playlistID,
+		New:        r.New,
+		Unchanged:  r.Unchanged,
+		Failed:     r.Failed,
+		Skipped:    r.Skipped,
+	}
 }
 
 var _ openapi.ImportApiServicer = &ImportApiServicer{}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
 	"github.com/imba28/spolyr/pkg/lyrics"
 	"github.com/imba28/spolyr/pkg/openapi"
@@ -12,6 +13,7 @@ import (
 	"github.com/zmb3/spotify/v2"
 	"net/http"
 	"testing"
+	"time"
 )
 
 type fetcherMock struct {
@@ -21,7 +23,7 @@ type fetcherMock struct {
 func (f *fetcherMock) Fetch(track *db.Track) error {
 	return f.Called(track).Error(0)
 }
-func (f *fetcherMock) FetchAll(tracks []*db.Track) (<-chan lyrics.Result, error) {
+func (f *fetcherMock) FetchAll(ctx context.Context, tracks []*db.Track) (<-chan lyrics.Result, error) {
 	args := f.Called(tracks)
 	return args.Get(0).(<-chan lyrics.Result), args.Error(1)
 }
@@ -31,7 +33,7 @@ var _ lyrics.Fetcher = &fetcherMock{}
 func TestImportApiServicer_ImportLibraryPost(t *testing.T) {
 	t.Run("denies unauthenticated access", func(t *testing.T) {
 		service := ImportApiServicer{}
-		res, err := service.ImportLibraryPost(context.Background())
+		res, err := service.ImportLibraryPost(context.Background(), "")
 
 		assert.Equal(t, http.StatusUnauthorized, res.Code)
 		assert.Error(t, err)
@@ -64,24 +66,29 @@ func TestImportApiServicer_ImportLibraryPost(t *testing.T) {
 				})
 			})
 
+		watermark := time.Date(2021, 6, 24, 19, 0, 0, 0, time.UTC)
 		repoMock := new(trackRepoMock)
-		repoMock.On("Save", mock.AnythingOfType("*db.Track")).
-			Times(2).
-			Return(nil)
+		repoMock.On("LibraryWatermark").Return(time.Time{}, nil)
+		repoMock.On("SaveMany", mock.AnythingOfType("[]*db.Track")).
+			Once().
+			Return([]db.SaveResult{{Inserted: true}, {}}, nil)
+		repoMock.On("LatestAddedAt").Return(watermark, nil)
+		repoMock.On("SetLibraryWatermark", watermark).Return(nil)
 		service := ImportApiServicer{repo: repoMock}
 
 		c := spotify.New(http.DefaultClient)
 		ctx := context.WithValue(context.Background(), spotifyOauthClientKey, c)
 		ctx = context.WithValue(ctx, jwtAccessKey, "a-valid-token")
 
-		res, err := service.ImportLibraryPost(ctx)
+		res, err := service.ImportLibraryPost(ctx, "incremental")
 
 		repoMock.AssertExpectations(t)
 		assert.Equal(t, http.StatusOK, res.Code)
 		assert.Nil(t, err)
+		assert.Equal(t, openapi.ImportSummary{New: 1, Unchanged: 1}, res.Body)
 	})
 
-	t.Run("imports tracks returned from spotify library API", func(t *testing.T) {
+	t.Run("keeps the watermark if saving tracks fails", func(t *testing.T) {
 		httpmock.Activate()
 		defer httpmock.DeactivateAndReset()
 
@@ -103,22 +110,36 @@ func TestImportApiServicer_ImportLibraryPost(t *testing.T) {
 			})
 
 		repoMock := new(trackRepoMock)
-		repoMock.On("Save", mock.AnythingOfType("*db.Track")).
-			Return(errors.New("database error"))
+		repoMock.On("SaveMany", mock.AnythingOfType("[]*db.Track")).
+			Return([]db.SaveResult(nil), errors.New("database error"))
 		service := ImportApiServicer{repo: repoMock}
 
 		c := spotify.New(http.DefaultClient)
 		ctx := context.WithValue(context.Background(), spotifyOauthClientKey, c)
 		ctx = context.WithValue(ctx, jwtAccessKey, "a-valid-token")
 
-		res, err := service.ImportLibraryPost(ctx)
+		res, err := service.ImportLibraryPost(ctx, "")
 
 		repoMock.AssertExpectations(t)
+		repoMock.AssertNotCalled(t, "LibraryWatermark")
+		repoMock.AssertNotCalled(t, "SetLibraryWatermark", mock.Anything)
 		assert.Equal(t, http.StatusInternalServerError, res.Code)
 		assert.Error(t, err)
 	})
 }
 
+func TestImportApiServicer_ImportLibraryPost__invalid_mode(t *testing.T) {
+	repoMock := new(trackRepoMock)
+	service := ImportApiServicer{repo: repoMock}
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	res, err := service.ImportLibraryPost(ctx, "everything")
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	repoMock.AssertNotCalled(t, "LibraryWatermark")
+}
+
 func TestImportApiServicer_ImportLyricsTrackIdPost(t *testing.T) {
 	t.Run("denies unauthenticated access", func(t *testing.T) {
 		t.Run("denies unauthenticated access", func(t *testing.T) {
@@ -191,4 +212,71 @@ func TestImportApiServicer_ImportLyricsTrackIdPost(t *testing.T) {
 		repoMock.AssertNotCalled(t, "Save")
 		lm.AssertNotCalled(t, "Detect")
 	})
+
+	t.Run("saves lyrics flagged for review", func(t *testing.T) {
+		requestedId := "1234"
+		track := &db.Track{SpotifyID: requestedId, Artist: "Eminem", Name: "Lose Yourself"}
+		repoMock := new(trackRepoMock)
+		repoMock.
+			On("FindTrack", requestedId).
+			Return(track, nil).
+			On("Save", track).
+			Return(nil)
+		lyricsFetcherMock := new(fetcherMock)
+		lyricsFetcherMock.On("Fetch", track).Return(fmt.Errorf("%w: too-short", lyrics.ErrLowConfidence))
+
+		service := ImportApiServicer{repo: repoMock, languageDetector: new(languageDetectorMock), fetcher: lyricsFetcherMock}
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.ImportLyricsTrackIdPost(ctx, requestedId)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.ErrorIs(t, err, lyrics.ErrLowConfidence)
+		repoMock.AssertExpectations(t)
+	})
+}
+
+func TestImportApiServicer_ImportLyricsPost(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	t.Run("rejects syncs while shutting down", func(t *testing.T) {
+		syncer := lyrics.NewSyncer(nil, new(trackRepoMock))
+		assert.Nil(t, syncer.Shutdown(context.Background()))
+		s := ImportApiServicer{syncer: syncer}
+
+		res, err := s.ImportLyricsPost(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	})
+
+	t.Run("reports errors loading the tracks", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("CountGroupsWithoutLyricsError").Return(int64(0), errors.New("connection refused"))
+		s := ImportApiServicer{syncer: lyrics.NewSyncer(nil, repoMock)}
+
+		res, err := s.ImportLyricsPost(ctx)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
+
+func TestImportApiServicer_ImportArtistsPost(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := ImportApiServicer{}
+		res, err := service.ImportArtistsPost(context.Background(), "")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects invalid include", func(t *testing.T) {
+		service := ImportApiServicer{}
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+		res, err := service.ImportArtistsPost(ctx, "everything")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
 }
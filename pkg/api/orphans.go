@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/orphans"
+	"github.com/imba28/spolyr/pkg/spotify"
+	"net/http"
+)
+
+type orphanReconciler interface {
+	Reconcile(ctx context.Context, libraryIDs []string) (orphans.Result, error)
+	ReconcilePlaylists(ctx context.Context) (orphans.Result, error)
+}
+
+type orphansApiService struct {
+	repo       db.TrackRepository
+	reconciler orphanReconciler
+}
+
+func (o orphansApiService) OrphansGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	tracks, total, err := o.repo.Orphans(ctx, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.TrackInfo, len(tracks))
+	for i := range tracks {
+		data[i] = toTrackInfo(*tracks[i])
+	}
+
+	return openapi.Response(http.StatusOK, openapi.OrphansGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+func (o orphansApiService) OrphansIdDelete(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	err := o.repo.DeleteOrphan(ctx, id)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, nil), nil
+	case db.ErrTrackNotFound:
+		return openapi.Response(http.StatusNotFound, nil), err
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+}
+
+func (o orphansApiService) OrphansReconcilePost(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	ids, err := spotify.LibraryTrackIDs(ctx, spotify.NewSpotifyTrackProvider(oauthClientFromContext(ctx)))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	r, err := o.reconciler.Reconcile(ctx, ids)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	return openapi.Response(http.StatusOK, toOrphansReconciliation(r)), nil
+}
+
+func toOrphansReconciliation(r orphans.Result) openapi.OrphansReconciliation {
+	return openapi.OrphansReconciliation{
+		NumberOfOrphanedTracks: int32(r.Orphaned),
+		NumberOfRestoredTracks: int32(r.Restored),
+		NumberOfDeletedTracks:  int32(r.Deleted),
+	}
+}
+
+var _ openapi.OrphansApiServicer = &orphansApiService{}
+
+func newOrphansApiService(repo db.TrackRepository, reconciler orphanReconciler) orphansApiService {
+	return orphansApiService{
+		repo:       repo,
+		reconciler: reconciler,
+	}
+}
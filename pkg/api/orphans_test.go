@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestOrphansApiService_OrphansGet(t *testing.T) {
+	orphanedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	repoMock := new(trackRepoMock)
+	repoMock.On("Orphans", 1, 25).Return([]*db.Track{{SpotifyID: "a", OrphanedAt: orphanedAt}}, 1, nil)
+	service := orphansApiService{repo: repoMock}
+
+	res, err := service.OrphansGet(context.Background(), 0, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.OrphansGet200Response)
+	assert.Equal(t, int32(1), body.Meta.Total)
+	assert.Len(t, body.Data, 1)
+	assert.Equal(t, orphanedAt, body.Data[0].OrphanedAt)
+}
+
+func TestOrphansApiService_OrphansIdDelete(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := orphansApiService{}
+		res, err := service.OrphansIdDelete(context.Background(), "a")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("deletes orphan", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("DeleteOrphan", "a").Return(nil)
+		service := orphansApiService{repo: repoMock}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.OrphansIdDelete(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("returns not found if track is not orphaned", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("DeleteOrphan", "a").Return(db.ErrTrackNotFound)
+		service := orphansApiService{repo: repoMock}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.OrphansIdDelete(ctx, "a")
+
+		assert.ErrorIs(t, err, db.ErrTrackNotFound)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestOrphansApiService_OrphansReconcilePost__denies_unauthenticated_access(t *testing.T) {
+	service := orphansApiService{}
+	res, err := service.OrphansReconcilePost(context.Background())
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Error(t, err)
+}
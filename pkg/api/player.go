@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/imba28/spolyr/pkg/lyrics"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"net/http"
+	"time"
+)
+
+type playerApiService struct {
+	repo    db.TrackRepository
+	fetcher lyrics.Fetcher
+}
+
+func (p playerApiService) PlayerNowPlayingGet(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	playing, err := oauthClientFromContext(ctx).PlayerCurrentlyPlaying(ctx)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+	// nothing is playing, the current item is not a track, e.g. a podcast episode, or it is a local file without an ID
+	if playing.Item == nil || playing.Item.ID == "" {
+		return openapi.Response(http.StatusNoContent, nil), nil
+	}
+
+	imported := false
+	t, err := p.repo.FindTrack(ctx, playing.Item.ID.String())
+	if err != nil {
+		track := db.NewTrack(*playing.Item)
+		track.Sources = []string{db.SourceHistory}
+		if err := p.repo.Save(ctx, &track); err != nil {
+			return openapi.Response(http.StatusInternalServerError, nil), err
+		}
+		t = &track
+		imported = true
+	}
+
+	if !t.Loaded && t.LyricsImportErrorCount == 0 && !t.Instrumental {
+		p.fetchLyrics(ctx, t)
+	}
+
+	return openapi.Response(http.StatusOK, openapi.NowPlaying{
+		IsPlaying:  playing.Playing,
+		ProgressMs: int32(playing.Progress),
+		Imported:   imported,
+		Track:      toTrackDetail(*t),
+	}), nil
+}
+
+// fetchLyrics tries to find the lyrics of the track once. Failures are recorded, so polling the current track does
+// not query the lyrics providers over and over again. Tracks that failed are retried by the next lyrics import.
+func (p playerApiService) fetchLyrics(ctx context.Context, t *db.Track) {
+	if err := p.fetcher.Fetch(t); err != nil {
+		t.AddLyricsImportError(err, time.Now())
+	}
+
+	if err := p.repo.Save(ctx, t); err != nil {
+		logging.FromContext(ctx).Error("could not save track", "spotify_id", t.SpotifyID, "error", err)
+		return
+	}
+
+	if _, err := duplicates.ShareLyrics(ctx, p.repo, t); err != nil {
+		logging.FromContext(ctx).Warn("could not share lyrics with duplicates", "spotify_id", t.SpotifyID, "error", err)
+	}
+}
+
+var _ openapi.PlayerApiServicer = &playerApiService{}
+
+func newPlayerApiService(repo db.TrackRepository, fetcher lyrics.Fetcher) playerApiService {
+	return playerApiService{
+		repo:    repo,
+		fetcher: fetcher,
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zmb3/spotify/v2"
+	"net/http"
+	"testing"
+)
+
+func nowPlayingContext() context.Context {
+	ctx := context.WithValue(context.Background(), spotifyOauthClientKey, spotify.New(http.DefaultClient))
+	return context.WithValue(ctx, jwtAccessKey, "a-valid-token")
+}
+
+func TestPlayerApiService_PlayerNowPlayingGet(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := playerApiService{}
+		res, err := service.PlayerNowPlayingGet(context.Background())
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("imports unknown track and fetches its lyrics", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"progress_ms": 42000,
+				"is_playing":  true,
+				"item":        map[string]interface{}{"id": "1", "name": "Track A"},
+			}))
+
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "1").Return((*db.Track)(nil), db.ErrTrackNotFound)
+		repoMock.On("Save", mock.AnythingOfType("*db.Track")).Times(2).Return(nil)
+		fetcher := new(fetcherMock)
+		fetcher.On("Fetch", mock.AnythingOfType("*db.Track")).Run(func(args mock.Arguments) {
+			track := args.Get(0).(*db.Track)
+			track.Lyrics = "la la la"
+			track.Loaded = true
+		}).Return(nil)
+		service := playerApiService{repo: repoMock, fetcher: fetcher}
+
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		body := res.Body.(openapi.NowPlaying)
+		assert.True(t, body.IsPlaying)
+		assert.True(t, body.Imported)
+		assert.Equal(t, int32(42000), body.ProgressMs)
+		assert.Equal(t, "la la la", body.Track.Lyrics)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("does not fetch lyrics of tracks that failed before", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"item": map[string]interface{}{"id": "1"},
+			}))
+
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "1").Return(&db.Track{SpotifyID: "1", LyricsImportErrorCount: 1}, nil)
+		fetcher := new(fetcherMock)
+		service := playerApiService{repo: repoMock, fetcher: fetcher}
+
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.False(t, res.Body.(openapi.NowPlaying).Imported)
+		fetcher.AssertNotCalled(t, "Fetch", mock.Anything)
+		repoMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("does not fetch lyrics of instrumental tracks", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"item": map[string]interface{}{"id": "1"},
+			}))
+
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "1").Return(&db.Track{SpotifyID: "1", Instrumental: true}, nil)
+		fetcher := new(fetcherMock)
+		service := playerApiService{repo: repoMock, fetcher: fetcher}
+
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		fetcher.AssertNotCalled(t, "Fetch", mock.Anything)
+		repoMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("records failed lookups", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"item": map[string]interface{}{"id": "1"},
+			}))
+
+		track := &db.Track{SpotifyID: "1"}
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "1").Return(track, nil)
+		repoMock.On("Save", track).Return(nil)
+		fetcher := new(fetcherMock)
+		fetcher.On("Fetch", track).Return(errors.New("not found"))
+		service := playerApiService{repo: repoMock, fetcher: fetcher}
+
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, 1, track.LyricsImportErrorCount)
+	})
+
+	t.Run("returns no content if nothing is playing", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing", httpmock.NewStringResponder(http.StatusNoContent, ""))
+
+		service := playerApiService{}
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNoContent, res.Code)
+	})
+	t.Run("returns no content for local files", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+		httpmock.RegisterResponder("GET", "=~/me/player/currently-playing",
+			httpmock.NewJsonResponderOrPanic(http.StatusOK, map[string]interface{}{
+				"is_playing": true,
+				"item":       map[string]interface{}{"name": "A local file", "is_local": true},
+			}))
+
+		repoMock := new(trackRepoMock)
+		service := playerApiService{repo: repoMock}
+		res, err := service.PlayerNowPlayingGet(nowPlayingContext())
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		repoMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
@@ -2,12 +2,15 @@ package api
 
 import (
 	"context"
+	"github.com/imba28/spolyr/pkg/db"
 	"github.com/imba28/spolyr/pkg/openapi"
 	spotify2 "github.com/zmb3/spotify/v2"
 	"net/http"
 )
 
 type playlistApiService struct {
+	playlists db.PlaylistRepository
+	tracks    db.TrackRepository
 }
 
 func (p playlistApiService) PlaylistsGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
@@ -45,8 +48,89 @@ func (p playlistApiService) PlaylistsGet(ctx context.Context, page int32, limit
 	return openapi.Response(http.StatusOK, res), nil
 }
 
+func (p playlistApiService) PlaylistsImportedGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	pp, total, err := p.playlists.Playlists(ctx, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	playlists := make([]openapi.PlaylistInfo, len(pp))
+	for i, playlist := range pp {
+		playlists[i] = toPlaylistInfo(*playlist)
+	}
+
+	return openapi.Response(http.StatusOK, openapi.PlaylistsImportedGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Limit: limit,
+			Page:  page,
+			Total: int32(total),
+		},
+		Data: playlists,
+	}), nil
+}
+
+func (p playlistApiService) PlaylistsImportedIdTracksGet(ctx context.Context, id string, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	_, err := p.playlists.FindPlaylist(ctx, id)
+	if err == db.ErrPlaylistNotFound {
+		return openapi.Response(http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	tracks, total, err := p.tracks.Search(ctx, "", db.TrackFilter{PlaylistID: id}, int(page), int(limit), "")
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.TrackInfo, len(tracks))
+	for i, track := range tracks {
+		data[i] = toTrackInfo(*track)
+	}
+
+	return openapi.Response(http.StatusOK, openapi.PlaylistsImportedIdTracksGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Limit: limit,
+			Page:  page,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+func toPlaylistInfo(p db.Playlist) openapi.PlaylistInfo {
+	return openapi.PlaylistInfo{
+		SpotifyId:       p.SpotifyID,
+		Name:            p.Name,
+		CoverImage:      p.ImageURL,
+		TrackCount:      int32(p.TrackCount),
+		Owner:           p.Owner,
+		IsPublic:        p.IsPublic,
+		IsCollaborative: p.IsCollaborative,
+		SnapshotId:      p.SnapshotID,
+		ImportedAt:      p.ImportedAt,
+	}
+}
+
 var _ openapi.PlaylistsApiServicer = &playlistApiService{}
 
-func newPlaylistApiService() playlistApiService {
-	return playlistApiService{}
+func newPlaylistApiService(playlists db.PlaylistRepository, tracks db.TrackRepository) playlistApiService {
+	return playlistApiService{
+		playlists: playlists,
+		tracks:    tracks,
+	}
 }
@@ -2,14 +2,35 @@ package api
 
 import (
 	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
 	"github.com/imba28/spolyr/pkg/openapi"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/zmb3/spotify/v2"
 	"net/http"
 	"testing"
 )
 
+type playlistRepoMock struct {
+	mock.Mock
+}
+
+func (p *playlistRepoMock) FindPlaylist(ctx context.Context, spotifyID string) (*db.Playlist, error) {
+	args := p.Called(spotifyID)
+	return args.Get(0).(*db.Playlist), args.Error(1)
+}
+func (p *playlistRepoMock) Playlists(ctx context.Context, page, limit int) ([]*db.Playlist, int, error) {
+	args := p.Called(page, limit)
+	return args.Get(0).([]*db.Playlist), args.Int(1), args.Error(2)
+}
+func (p *playlistRepoMock) SavePlaylist(ctx context.Context, playlist *db.Playlist) error {
+	return p.Called(playlist).Error(0)
+}
+
+var _ db.PlaylistRepository = &playlistRepoMock{}
+
 func TestPlaylistApiService_PlaylistsGet(t *testing.T) {
 	t.Run("deny unauthenticated access", func(t *testing.T) {
 		service := playlistApiService{}
@@ -90,3 +111,80 @@ func TestPlaylistApiService_PlaylistsGet(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestPlaylistApiService_PlaylistsImportedGet(t *testing.T) {
+	t.Run("lists imported playlists", func(t *testing.T) {
+		pm := new(playlistRepoMock)
+		pm.On("Playlists", 2, 5).Return([]*db.Playlist{
+			{SpotifyID: "1", Name: "Playlist A", SnapshotID: "abc", TrackCount: 3},
+		}, 6, nil)
+		service := playlistApiService{playlists: pm}
+
+		res, err := service.PlaylistsImportedGet(context.Background(), 2, 5)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		body, _ := res.Body.(openapi.PlaylistsImportedGet200Response)
+		assert.Equal(t, int32(6), body.Meta.Total)
+		assert.Len(t, body.Data, 1)
+		assert.Equal(t, "Playlist A", body.Data[0].Name)
+		assert.Equal(t, "abc", body.Data[0].SnapshotId)
+		assert.Equal(t, int32(3), body.Data[0].TrackCount)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		databaseErr := errors.New("database error")
+		pm := new(playlistRepoMock)
+		pm.On("Playlists", 1, 5).Return([]*db.Playlist(nil), 0, databaseErr)
+		service := playlistApiService{playlists: pm}
+
+		res, err := service.PlaylistsImportedGet(context.Background(), 1, 5)
+
+		assert.Equal(t, databaseErr, err)
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+
+	t.Run("defaults invalid page and limit", func(t *testing.T) {
+		pm := new(playlistRepoMock)
+		pm.On("Playlists", 1, 25).Return([]*db.Playlist{}, 0, nil)
+		service := playlistApiService{playlists: pm}
+
+		res, err := service.PlaylistsImportedGet(context.Background(), 0, -1)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		pm.AssertExpectations(t)
+	})
+}
+
+func TestPlaylistApiService_PlaylistsImportedIdTracksGet(t *testing.T) {
+	t.Run("lists tracks of the playlist", func(t *testing.T) {
+		pm := new(playlistRepoMock)
+		pm.On("FindPlaylist", "1").Return(&db.Playlist{SpotifyID: "1"}, nil)
+		tm := new(trackRepoMock)
+		tm.On("Search", "", db.TrackFilter{PlaylistID: "1"}, 1, 10, "").Return([]*db.Track{{SpotifyID: "a"}, {SpotifyID: "b"}}, 2, nil)
+		service := playlistApiService{playlists: pm, tracks: tm}
+
+		res, err := service.PlaylistsImportedIdTracksGet(context.Background(), "1", 1, 10)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		body, _ := res.Body.(openapi.PlaylistsImportedIdTracksGet200Response)
+		assert.Equal(t, int32(2), body.Meta.Total)
+		assert.Equal(t, "a", body.Data[0].SpotifyId)
+		assert.Equal(t, "b", body.Data[1].SpotifyId)
+	})
+
+	t.Run("playlist has not been imported", func(t *testing.T) {
+		pm := new(playlistRepoMock)
+		pm.On("FindPlaylist", "1").Return((*db.Playlist)(nil), db.ErrPlaylistNotFound)
+		tm := new(trackRepoMock)
+		service := playlistApiService{playlists: pm, tracks: tm}
+
+		res, err := service.PlaylistsImportedIdTracksGet(context.Background(), "1", 1, 10)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		tm.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/imba28/spolyr/pkg/lyrics"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/webhooks"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type lyricsRetrier interface {
+	FetchWith(*db.Track, lyrics.FetchOptions) error
+}
+
+type reviewApiService struct {
+	repo      db.TrackRepository
+	fetcher   lyricsRetrier
+	providers []string
+	events    eventDispatcher
+}
+
+func (s reviewApiService) ReviewGet(ctx context.Context, page int32, limit int32) (openapi.ImplResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	tracks, total, err := s.repo.ReviewTracks(ctx, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.ReviewItem, len(tracks))
+	for i := range tracks {
+		data[i] = toReviewItem(*tracks[i])
+	}
+
+	return openapi.Response(http.StatusOK, openapi.ReviewGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data:      data,
+		Providers: s.providers,
+	}), nil
+}
+
+func (s reviewApiService) ReviewIdRetryPost(ctx context.Context, id string, r openapi.LyricsRetryRequest) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	t, err := s.repo.FindTrack(ctx, id)
+	if err != nil {
+		return openapi.Response(http.StatusNotFound, nil), nil
+	}
+
+	err = s.fetcher.FetchWith(t, lyrics.FetchOptions{
+		Provider: r.Provider,
+		Artist:   strings.TrimSpace(r.Artist),
+		Title:    strings.TrimSpace(r.Title),
+	})
+	if errors.Is(err, lyrics.ErrUnknownProvider) {
+		return openapi.Response(http.StatusBadRequest, nil), err
+	}
+	if err != nil {
+		t.AddLyricsImportError(err, time.Now())
+		if err := s.repo.Save(ctx, t); err != nil {
+			return openapi.Response(http.StatusInternalServerError, nil), err
+		}
+		return openapi.Response(http.StatusUnprocessableEntity, toReviewItem(*t)), nil
+	}
+
+	t.LyricsImportErrorCount = 0
+	if err := s.repo.Save(ctx, t); err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+	s.shareLyrics(ctx, t)
+
+	return openapi.Response(http.StatusOK, toTrackDetail(*t)), nil
+}
+
+func (s reviewApiService) ReviewIdAcceptPost(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	t, err := s.repo.FindTrack(ctx, id)
+	if err != nil {
+		return openapi.Response(http.StatusNotFound, nil), nil
+	}
+	if t.LyricsReview == nil {
+		return openapi.Response(http.StatusConflict, nil), nil
+	}
+
+	t.Lyrics = t.LyricsReview.Lyrics
+	t.Language = t.LyricsReview.Language
+	t.Loaded = true
+	t.LyricsImportErrorCount = 0
+	t.LyricsReview = nil
+	if err := s.repo.Save(ctx, t); err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+	s.shareLyrics(ctx, t)
+
+	dispatch(s.events, webhooks.EventLyricsEdited, webhooks.LyricsEditedData{
+		SpotifyID: t.SpotifyID,
+		Title:     t.Name,
+		Artists:   t.ArtistNames(),
+		Language:  t.Language,
+	})
+
+	return openapi.Response(http.StatusOK, toTrackDetail(*t)), nil
+}
+
+func (s reviewApiService) ReviewIdInstrumentalPost(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	return s.setInstrumental(ctx, id, true)
+}
+
+func (s reviewApiService) ReviewIdInstrumentalDelete(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	return s.setInstrumental(ctx, id, false)
+}
+
+func (s reviewApiService) setInstrumental(ctx context.Context, id string, instrumental bool) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	err := s.repo.SetInstrumental(ctx, id, instrumental)
+	if errors.Is(err, db.ErrTrackNotFound) {
+		return openapi.Response(http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	t, err := s.repo.FindTrack(ctx, id)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+	return openapi.Response(http.StatusOK, toTrackDetail(*t)), nil
+}
+
+func (s reviewApiService) shareLyrics(ctx context.Context, t *db.Track) {
+	if _, err := duplicates.ShareLyrics(ctx, s.repo, t); err != nil {
+		logging.FromContext(ctx).Warn("could not share lyrics with duplicates", "spotify_id", t.SpotifyID, "error", err)
+	}
+}
+
+func toReviewItem(t db.Track) openapi.ReviewItem {
+	item := openapi.ReviewItem{
+		Track:                  toTrackInfo(t),
+		Reason:                 "failed",
+		LyricsImportErrorCount: int32(t.LyricsImportErrorCount),
+		Errors:                 make([]openapi.LyricsImportError, len(t.LyricsImportErrors)),
+	}
+	for i, e := range t.LyricsImportErrors {
+		item.Errors[i] = openapi.LyricsImportError{Message: e.Message, At: e.At}
+	}
+	if t.LyricsReview != nil {
+		item.Reason = "flagged"
+		item.Review = openapi.LyricsReview{
+			Lyrics:    t.LyricsReview.Lyrics,
+			Language:  t.LyricsReview.Language,
+			Score:     t.LyricsReview.Score,
+			Issues:    t.LyricsReview.Issues,
+			FlaggedAt: t.LyricsReview.FlaggedAt,
+		}
+	}
+	return item
+}
+
+var _ openapi.ReviewApiServicer = &reviewApiService{}
+
+func newReviewApiService(repo db.TrackRepository, fetcher lyricsRetrier, providers []string, events eventDispatcher) reviewApiService {
+	return reviewApiService{
+		repo:      repo,
+		fetcher:   fetcher,
+		providers: providers,
+		events:    events,
+	}
+}
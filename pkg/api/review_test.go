@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/lyrics"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type lyricsRetrierMock struct {
+	mock.Mock
+}
+
+func (l *lyricsRetrierMock) FetchWith(t *db.Track, opts lyrics.FetchOptions) error {
+	return l.Called(t, opts).Error(0)
+}
+
+func TestReviewApiService_ReviewGet(t *testing.T) {
+	flaggedAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	failed := &db.Track{SpotifyID: "a", LyricsImportErrorCount: 3}
+	failed.LyricsImportErrors = []db.LyricsImportError{{Message: "lyrics not found", At: flaggedAt}}
+	flagged := &db.Track{SpotifyID: "b", LyricsImportErrorCount: 1, LyricsReview: &db.LyricsReview{
+		Lyrics: "la la la", Score: 0.25, Issues: []string{lyrics.IssueTooShort}, FlaggedAt: flaggedAt,
+	}}
+	repoMock := new(trackRepoMock)
+	repoMock.On("ReviewTracks", 1, 25).Return([]*db.Track{flagged, failed}, 2, nil)
+	service := newReviewApiService(repoMock, nil, []string{"genius", "songlyrics"}, nil)
+
+	res, err := service.ReviewGet(context.Background(), 0, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.ReviewGet200Response)
+	assert.Equal(t, int32(2), body.Meta.Total)
+	assert.Equal(t, []string{"genius", "songlyrics"}, body.Providers)
+	if assert.Len(t, body.Data, 2) {
+		assert.Equal(t, "flagged", body.Data[0].Reason)
+		assert.Equal(t, []string{lyrics.IssueTooShort}, body.Data[0].Review.Issues)
+		assert.Equal(t, "failed", body.Data[1].Reason)
+		assert.Equal(t, int32(3), body.Data[1].LyricsImportErrorCount)
+		assert.Equal(t, []openapi.LyricsImportError{{Message: "lyrics not found", At: flaggedAt}}, body.Data[1].Errors)
+	}
+}
+
+func TestReviewApiService_ReviewIdRetryPost(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+	request := openapi.LyricsRetryRequest{Provider: "songlyrics", Artist: " artist ", Title: "title"}
+	options := lyrics.FetchOptions{Provider: "songlyrics", Artist: "artist", Title: "title"}
+
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		res, err := reviewApiService{}.ReviewIdRetryPost(context.Background(), "a", request)
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("imports lyrics", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "a", LyricsImportErrorCount: 3}
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return(track, nil)
+		repoMock.On("Save", track).Return(nil)
+		fetcher := new(lyricsRetrierMock)
+		fetcher.On("FetchWith", track, options).Run(func(args mock.Arguments) {
+			track.Lyrics = "la la la"
+			track.Loaded = true
+		}).Return(nil)
+		service := newReviewApiService(repoMock, fetcher, nil, nil)
+
+		res, err := service.ReviewIdRetryPost(ctx, "a", request)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.True(t, res.Body.(openapi.TrackDetail).HasLyrics)
+		assert.Equal(t, 0, track.LyricsImportErrorCount)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "a", LyricsImportErrorCount: 3}
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return(track, nil)
+		repoMock.On("Save", track).Return(nil)
+		fetcher := new(lyricsRetrierMock)
+		fetcher.On("FetchWith", track, options).Return(fmt.Errorf("%w: too-short", lyrics.ErrLowConfidence))
+		service := newReviewApiService(repoMock, fetcher, nil, nil)
+
+		res, err := service.ReviewIdRetryPost(ctx, "a", request)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		item := res.Body.(openapi.ReviewItem)
+		assert.Equal(t, int32(4), item.LyricsImportErrorCount)
+		if assert.Len(t, item.Errors, 1) {
+			assert.Equal(t, "lyrics have a low confidence: too-short", item.Errors[0].Message)
+		}
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown providers", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "a"}
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return(track, nil)
+		fetcher := new(lyricsRetrierMock)
+		fetcher.On("FetchWith", track, options).Return(lyrics.ErrUnknownProvider)
+		service := newReviewApiService(repoMock, fetcher, nil, nil)
+
+		res, err := service.ReviewIdRetryPost(ctx, "a", request)
+
+		assert.ErrorIs(t, err, lyrics.ErrUnknownProvider)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		repoMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("returns not found for unknown tracks", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return((*db.Track)(nil), db.ErrTrackNotFound)
+		service := newReviewApiService(repoMock, nil, nil, nil)
+
+		res, _ := service.ReviewIdRetryPost(ctx, "a", request)
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestReviewApiService_ReviewIdAcceptPost(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		res, err := reviewApiService{}.ReviewIdAcceptPost(context.Background(), "a")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("loads flagged lyrics", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "a", LyricsImportErrorCount: 1, LyricsReview: &db.LyricsReview{Lyrics: "la la la", Language: "german"}}
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return(track, nil)
+		repoMock.On("Save", track).Return(nil)
+		events := new(eventDispatcherMock)
+		events.On("Dispatch", "lyrics.edited", mock.Anything).Return()
+		service := newReviewApiService(repoMock, nil, nil, events)
+
+		res, err := service.ReviewIdAcceptPost(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.True(t, track.Loaded)
+		assert.Equal(t, "la la la", track.Lyrics)
+		assert.Equal(t, "german", track.Language)
+		assert.Equal(t, 0, track.LyricsImportErrorCount)
+		assert.Nil(t, track.LyricsReview)
+		repoMock.AssertExpectations(t)
+		events.AssertExpectations(t)
+	})
+
+	t.Run("rejects tracks without flagged lyrics", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("FindTrack", "a").Return(&db.Track{SpotifyID: "a"}, nil)
+		service := newReviewApiService(repoMock, nil, nil, nil)
+
+		res, err := service.ReviewIdAcceptPost(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusConflict, res.Code)
+		repoMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestReviewApiService_ReviewIdInstrumentalPost(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		res, err := reviewApiService{}.ReviewIdInstrumentalPost(context.Background(), "a")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("marks tracks as instrumental", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("SetInstrumental", "a", true).Return(nil)
+		repoMock.On("FindTrack", "a").Return(&db.Track{SpotifyID: "a", Instrumental: true}, nil)
+		service := newReviewApiService(repoMock, nil, nil, nil)
+
+		res, err := service.ReviewIdInstrumentalPost(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("returns not found for unknown tracks", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("SetInstrumental", "a", true).Return(db.ErrTrackNotFound)
+		service := newReviewApiService(repoMock, nil, nil, nil)
+
+		res, err := service.ReviewIdInstrumentalPost(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestReviewApiService_ReviewIdInstrumentalDelete(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		res, err := reviewApiService{}.ReviewIdInstrumentalDelete(context.Background(), "a")
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("removes the instrumental mark", func(t *testing.T) {
+		repoMock := new(trackRepoMock)
+		repoMock.On("SetInstrumental", "a", false).Return(nil)
+		repoMock.On("FindTrack", "a").Return(&db.Track{SpotifyID: "a"}, nil)
+		service := newReviewApiService(repoMock, nil, nil, nil)
+
+		res, err := service.ReviewIdInstrumentalDelete(ctx, "a")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.False(t, res.Body.(openapi.TrackDetail).Instrumental)
+		repoMock.AssertExpectations(t)
+	})
+}
@@ -2,28 +2,39 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/logging"
 	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/webhooks"
 	"go.mongodb.org/mongo-driver/mongo"
-	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 var _ openapi.TracksApiServicer = &TracksApiService{}
 
+const dateLayout = "2006-01-02"
+
 type TracksApiService struct {
 	repo             db.TrackRepository
 	languageDetector languageDetector
+	hideOrphans      bool
+	events           eventDispatcher
 }
 
 func (s *TracksApiService) TracksStatsGet(ctx context.Context) (openapi.ImplResponse, error) {
-	numberOfTracks, _ := s.repo.Count()
-	NumberOfTracksWithLyrics, _ := s.repo.CountWithLyrics()
+	numberOfTracks, _ := s.repo.Count(ctx)
+	NumberOfTracksWithLyrics, _ := s.repo.CountWithLyrics(ctx)
+	numberOfInstrumentalTracks, _ := s.repo.CountInstrumental(ctx)
 
 	return openapi.Response(http.StatusOK, openapi.TracksStats{
-		NumberOfTracks:           int32(numberOfTracks),
-		NumberOfTracksWithLyrics: int32(NumberOfTracksWithLyrics),
+		NumberOfTracks:             int32(numberOfTracks),
+		NumberOfTracksWithLyrics:   int32(NumberOfTracksWithLyrics),
+		NumberOfInstrumentalTracks: int32(numberOfInstrumentalTracks),
 	}), nil
 }
 
@@ -32,27 +43,46 @@ func (s *TracksApiService) TracksIdPatch(ctx context.Context, id string, lyrics
 		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
 	}
 
-	t, err := s.repo.FindTrack(id)
+	t, err := s.repo.FindTrack(ctx, id)
 	if err != nil {
 		return openapi.Response(http.StatusNotFound, nil), nil
 	}
 
+	// lyrics entered manually prove that the track is not an instrumental
+	if t.Instrumental {
+		if err := s.repo.SetInstrumental(ctx, t.SpotifyID, false); err != nil {
+			return openapi.Response(http.StatusInternalServerError, nil), err
+		}
+		t.Instrumental = false
+	}
+
 	t.Loaded = true
 	t.Lyrics = lyrics.Lyrics
 
 	ll, err := s.languageDetector.Detect(t.Lyrics)
 	if err != nil {
-		log.Printf("Could not detect language of track(%d). Using english as default", t.ID)
+		logging.FromContext(ctx).Warn("could not detect language of lyrics, falling back to english", "spotify_id", t.SpotifyID, "error", err)
 		t.Language = "english"
 	} else {
 		t.Language = ll
 	}
 
-	err = s.repo.Save(t)
+	err = s.repo.Save(ctx, t)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), err
 	}
 
+	if _, err := duplicates.ShareLyrics(ctx, s.repo, t); err != nil {
+		logging.FromContext(ctx).Warn("could not share lyrics with duplicates", "spotify_id", t.SpotifyID, "error", err)
+	}
+
+	dispatch(s.events, webhooks.EventLyricsEdited, webhooks.LyricsEditedData{
+		SpotifyID: t.SpotifyID,
+		Title:     t.Name,
+		Artists:   t.ArtistNames(),
+		Language:  t.Language,
+	})
+
 	return openapi.Response(http.StatusOK, toTrackDetail(*t)), nil
 }
 
@@ -63,26 +93,99 @@ func toTrackDetail(t db.Track) openapi.TrackDetail {
 		Album:                  t.AlbumName,
 		CoverImage:             t.ImageURL,
 		PreviewURL:             t.PreviewURL,
-		Artists:                strings.Split(t.Artist, ","),
+		Artists:                t.ArtistNames(),
 		HasLyrics:              t.Loaded,
 		Lyrics:                 t.Lyrics,
 		LyricsImportErrorCount: int32(t.LyricsImportErrorCount),
 		Language:               t.Language,
+		OrphanedAt:             t.OrphanedAt,
+		ArtistIds:              t.ArtistIDs,
+		Isrc:                   t.ISRC,
+		DurationMs:             int32(t.DurationMs),
+		Explicit:               t.Explicit,
+		ReleaseDate:            t.ReleaseDate,
+		Popularity:             int32(t.Popularity),
+		AddedAt:                t.AddedAt,
+		Instrumental:           t.Instrumental,
+	}
+}
+
+func toTrackInfo(t db.Track) openapi.TrackInfo {
+	return openapi.TrackInfo{
+		SpotifyId:  t.SpotifyID,
+		Title:      t.Name,
+		Album:      t.AlbumName,
+		CoverImage: t.ImageURL,
+		PreviewURL: t.PreviewURL,
+		Artists:    t.ArtistNames(),
+		HasLyrics:  t.Loaded,
+		Language:   t.Language,
+		OrphanedAt: t.OrphanedAt,
+	}
+}
+
+func toTrackFilter(explicit, artistId string, releasedFrom, releasedTo int32, addedAfter, addedBefore string, minPopularity int32) (db.TrackFilter, error) {
+	f := db.TrackFilter{
+		ArtistID:      artistId,
+		ReleasedFrom:  int(releasedFrom),
+		ReleasedTo:    int(releasedTo),
+		MinPopularity: int(minPopularity),
+	}
+
+	switch explicit {
+	case "", "include":
+		f.Explicit = db.ExplicitInclude
+	case "exclude":
+		f.Explicit = db.ExplicitExclude
+	case "only":
+		f.Explicit = db.ExplicitOnly
+	default:
+		return f, fmt.Errorf("invalid value %q for explicit", explicit)
+	}
+
+	var err error
+	if addedAfter != "" {
+		if f.AddedAfter, err = time.Parse(dateLayout, addedAfter); err != nil {
+			return f, err
+		}
+	}
+	if addedBefore != "" {
+		if f.AddedBefore, err = time.Parse(dateLayout, addedBefore); err != nil {
+			return f, err
+		}
 	}
+	return f, nil
 }
 
 // newTracksApiService creates a default api service
-func newTracksApiService(repo db.TrackRepository, languageDetector languageDetector) *TracksApiService {
+func newTracksApiService(repo db.TrackRepository, languageDetector languageDetector, hideOrphans bool, events eventDispatcher) *TracksApiService {
 	return &TracksApiService{
 		repo:             repo,
 		languageDetector: languageDetector,
+		hideOrphans:      hideOrphans,
+		events:           events,
 	}
 }
 
-func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int32, query string) (openapi.ImplResponse, error) {
+func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int32, query string, explicit string, artistId string, releasedFrom int32, releasedTo int32, addedAfter string, addedBefore string, minPopularity int32, playlistId string, cursor string) (openapi.ImplResponse, error) {
 	var tracks []*db.Track
-	var err error
 	var total int
+	var next string
+	if limit < 1 {
+		limit = 25
+	}
+
+	filter, err := toTrackFilter(explicit, artistId, releasedFrom, releasedTo, addedAfter, addedBefore, minPopularity)
+	filter.PlaylistID = playlistId
+	filter.HideOrphans = s.hideOrphans
+	if err != nil {
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
+
+	if query != "" && cursor != "" {
+		// search results are sorted by relevance, which does not allow keyset pagination
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
 
 	if query != "" {
 		var queryLanguage string
@@ -99,10 +202,14 @@ func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int3
 			query = strings.Join(qs, " ")
 		}
 
-		tracks, total, err = s.repo.Search(query, int(page), int(limit), queryLanguage)
+		tracks, total, err = s.repo.Search(ctx, query, filter, int(page), int(limit), queryLanguage)
+	} else if cursor != "" || page <= 1 {
+		tracks, next, total, err = s.repo.TracksAfter(ctx, filter, cursor, int(limit))
+		if errors.Is(err, db.ErrInvalidCursor) {
+			return openapi.Response(http.StatusBadRequest, nil), nil
+		}
 	} else {
-		total = 10
-		tracks, err = s.repo.LatestTracks(int64(limit))
+		tracks, total, err = s.repo.Search(ctx, "", filter, int(page), int(limit), "")
 	}
 
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -111,16 +218,7 @@ func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int3
 
 	data := make([]openapi.TrackInfo, len(tracks))
 	for i, track := range tracks {
-		data[i] = openapi.TrackInfo{
-			SpotifyId:  track.SpotifyID,
-			Title:      track.Name,
-			Album:      track.AlbumName,
-			CoverImage: track.ImageURL,
-			PreviewURL: track.PreviewURL,
-			Artists:    strings.Split(track.Artist, ", "),
-			HasLyrics:  track.Loaded,
-			Language:   track.Language,
-		}
+		data[i] = toTrackInfo(*track)
 	}
 
 	res := openapi.TracksGet200Response{
@@ -129,6 +227,7 @@ func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int3
 			Page:  page,
 			Limit: limit,
 			Total: int32(total),
+			Next:  next,
 		},
 	}
 
@@ -137,21 +236,10 @@ func (s *TracksApiService) TracksGet(ctx context.Context, page int32, limit int3
 
 // TracksIdGet - Returns a track
 func (s *TracksApiService) TracksIdGet(ctx context.Context, id string) (openapi.ImplResponse, error) {
-	t, err := s.repo.FindTrack(id)
+	t, err := s.repo.FindTrack(ctx, id)
 	if err != nil {
 		return openapi.Response(404, nil), nil
 	}
 
-	return openapi.Response(200, openapi.TrackDetail{
-		SpotifyId:              t.SpotifyID,
-		Title:                  t.Name,
-		Album:                  t.AlbumName,
-		CoverImage:             t.ImageURL,
-		PreviewURL:             t.PreviewURL,
-		Artists:                strings.Split(t.Artist, ","),
-		HasLyrics:              t.Loaded,
-		Lyrics:                 t.Lyrics,
-		LyricsImportErrorCount: int32(t.LyricsImportErrorCount),
-		Language:               t.Language,
-	}), nil
+	return openapi.Response(200, toTrackDetail(*t)), nil
 }
@@ -6,50 +6,130 @@ import (
 	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
 	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/webhooks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 	"testing"
+	"time"
 )
 
 type trackRepoMock struct {
 	mock.Mock
 }
 
-func (t *trackRepoMock) Count() (int64, error) {
+func (t *trackRepoMock) Count(ctx context.Context) (int64, error) {
 	args := t.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (t *trackRepoMock) CountWithLyrics() (int64, error) {
+func (t *trackRepoMock) CountWithLyrics(ctx context.Context) (int64, error) {
+	args := t.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+func (t *trackRepoMock) CountWithLyricsError(ctx context.Context) (int64, error) {
+	args := t.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+func (t *trackRepoMock) CountInstrumental(ctx context.Context) (int64, error) {
 	args := t.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (t *trackRepoMock) AllTracks(page, limit int) ([]*db.Track, int, error) {
+func (t *trackRepoMock) AllTracks(ctx context.Context, page, limit int) ([]*db.Track, int, error) {
 	args := t.Called(page, limit)
 	return args.Get(0).([]*db.Track), args.Int(1), args.Error(2)
 }
-func (t *trackRepoMock) FindTrack(s string) (*db.Track, error) {
+func (t *trackRepoMock) FindTrack(ctx context.Context, s string) (*db.Track, error) {
 	args := t.Called(s)
 	return args.Get(0).(*db.Track), args.Error(1)
 }
-func (t *trackRepoMock) LatestTracks(limit int64) ([]*db.Track, error) {
+func (t *trackRepoMock) LatestTracks(ctx context.Context, limit int64) ([]*db.Track, error) {
 	args := t.Called(limit)
 	return args.Get(0).([]*db.Track), args.Error(1)
 }
-func (t *trackRepoMock) TracksWithoutLyricsError() ([]*db.Track, error) {
+func (t *trackRepoMock) TracksWithoutLyricsError() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := t.MethodCalled("TracksWithoutLyricsError", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
+}
+func (t *trackRepoMock) CountGroupsWithoutLyricsError(ctx context.Context) (int64, error) {
 	args := t.Called()
-	return args.Get(0).([]*db.Track), args.Error(1)
+	return args.Get(0).(int64), args.Error(1)
+}
+func (t *trackRepoMock) IterateTracks() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := t.MethodCalled("IterateTracks", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
 }
-func (t *trackRepoMock) Search(query string, page, limit int, language string) ([]*db.Track, int, error) {
-	args := t.Called(query, page, limit, language)
+func (t *trackRepoMock) TracksAfter(ctx context.Context, filter db.TrackFilter, cursor string, limit int) ([]*db.Track, string, int, error) {
+	args := t.Called(filter, cursor, limit)
+	return args.Get(0).([]*db.Track), args.String(1), args.Int(2), args.Error(3)
+}
+func (t *trackRepoMock) Search(ctx context.Context, query string, filter db.TrackFilter, page, limit int, language string) ([]*db.Track, int, error) {
+	args := t.Called(query, filter, page, limit, language)
 	return args.Get(0).([]*db.Track), args.Int(1), args.Error(2)
 }
-func (t *trackRepoMock) Save(track *db.Track) error {
+func (t *trackRepoMock) Save(ctx context.Context, track *db.Track) error {
+	return t.Called(track).Error(0)
+}
+func (t *trackRepoMock) SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error) {
+	args := t.Called(tracks)
+	return args.Get(0).([]db.SaveResult), args.Error(1)
+}
+func (t *trackRepoMock) ResetLyrics(ctx context.Context, track *db.Track) error {
 	return t.Called(track).Error(0)
 }
+func (t *trackRepoMock) FindGroup(ctx context.Context, groupID string) ([]*db.Track, error) {
+	args := t.Called(groupID)
+	return args.Get(0).([]*db.Track), args.Error(1)
+}
+func (t *trackRepoMock) Groups(ctx context.Context, page, limit int) ([]db.TrackGroup, int, error) {
+	args := t.Called(page, limit)
+	return args.Get(0).([]db.TrackGroup), args.Int(1), args.Error(2)
+}
+func (t *trackRepoMock) SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error {
+	return t.Called(spotifyID, groupID, locked).Error(0)
+}
+
+func (t *trackRepoMock) LatestAddedAt(ctx context.Context) (time.Time, error) {
+	args := t.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (t *trackRepoMock) LibraryWatermark(ctx context.Context) (time.Time, error) {
+	args := t.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (t *trackRepoMock) SetLibraryWatermark(ctx context.Context, watermark time.Time) error {
+	return t.Called(watermark).Error(0)
+}
+func (t *trackRepoMock) SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error {
+	return t.Called(playlistID, spotifyIDs).Error(0)
+}
+func (t *trackRepoMock) Orphans(ctx context.Context, page, limit int) ([]*db.Track, int, error) {
+	args := t.Called(page, limit)
+	return args.Get(0).([]*db.Track), args.Int(1), args.Error(2)
+}
+func (t *trackRepoMock) SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error {
+	return t.Called(spotifyID, orphanedAt).Error(0)
+}
+func (t *trackRepoMock) DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error) {
+	args := t.Called(orphanedBefore)
+	return args.Int(0), args.Error(1)
+}
+func (t *trackRepoMock) DeleteOrphan(ctx context.Context, spotifyID string) error {
+	return t.Called(spotifyID).Error(0)
+}
+func (t *trackRepoMock) ReviewTracks(ctx context.Context, page, limit int) ([]*db.Track, int, error) {
+	args := t.Called(page, limit)
+	return args.Get(0).([]*db.Track), args.Int(1), args.Error(2)
+}
+func (t *trackRepoMock) SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error {
+	return t.Called(spotifyID, instrumental).Error(0)
+}
 
 var _ db.TrackRepository = &trackRepoMock{}
 
@@ -64,10 +144,31 @@ func (l *languageDetectorMock) Detect(s string) (string, error) {
 
 var _ languageDetector = &languageDetectorMock{}
 
+func TestTracksApiService_TracksStatsGet(t *testing.T) {
+	m := new(trackRepoMock)
+	m.On("Count").Return(int64(10), nil)
+	m.On("CountWithLyrics").Return(int64(7), nil)
+	m.On("CountInstrumental").Return(int64(2), nil)
+	trackApi := TracksApiService{repo: m}
+
+	res, err := trackApi.TracksStatsGet(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, openapi.TracksStats{
+		NumberOfTracks:             10,
+		NumberOfTracksWithLyrics:   7,
+		NumberOfInstrumentalTracks: 2,
+	}, res.Body)
+	m.AssertExpectations(t)
+}
+
 func TestTracksApiService_TracksIdGet(t *testing.T) {
 	spotifyId := "1234"
 	track := db.Track{
 		SpotifyID: spotifyId,
+		Artist:    "Tyler, The Creator, Kali Uchis",
+		Artists:   []string{"Tyler, The Creator", "Kali Uchis"},
+		ArtistIDs: []string{"4V8LLVI7PbaPR0K2TGSxFF", "1U1el3k54VvEUzo3ybLPlM"},
 	}
 	m := new(trackRepoMock)
 	m.On("FindTrack", spotifyId).Return(&track, nil)
@@ -80,6 +181,8 @@ func TestTracksApiService_TracksIdGet(t *testing.T) {
 
 	td, _ := res.Body.(openapi.TrackDetail)
 	assert.Equal(t, track.SpotifyID, td.SpotifyId)
+	assert.Equal(t, track.Artists, td.Artists)
+	assert.Equal(t, track.ArtistIDs, td.ArtistIds)
 }
 
 func TestTracksApiService_TracksGet(t *testing.T) {
@@ -89,12 +192,12 @@ func TestTracksApiService_TracksGet(t *testing.T) {
 		totalResults := 10
 
 		m := new(trackRepoMock)
-		m.On("Search", query, int(page), int(limit), mock.Anything).Return(tracks, totalResults, nil)
+		m.On("Search", query, db.TrackFilter{}, int(page), int(limit), mock.Anything).Return(tracks, totalResults, nil)
 		lm := new(languageDetectorMock)
 		lm.On("Detect", query).Return("english", nil)
 		trackApi := TracksApiService{repo: m, languageDetector: lm}
 
-		res, err := trackApi.TracksGet(context.Background(), page, limit, query)
+		res, err := trackApi.TracksGet(context.Background(), page, limit, query, "", "", 0, 0, "", "", 0, "", "")
 
 		assert.Nil(t, err)
 		assert.Equal(t, http.StatusOK, res.Code)
@@ -142,12 +245,12 @@ func TestTracksApiService_TracksGet(t *testing.T) {
 				var tracks []*db.Track
 
 				m := new(trackRepoMock)
-				m.On("Search", testCase.expectedQuery, 1, 10, mock.Anything).Return(tracks, 1, nil)
+				m.On("Search", testCase.expectedQuery, db.TrackFilter{}, 1, 10, mock.Anything).Return(tracks, 1, nil)
 				lm := new(languageDetectorMock)
 				lm.On("Detect", testCase.query).Return("english", nil)
 				trackApi := TracksApiService{repo: m, languageDetector: lm}
 
-				_, _ = trackApi.TracksGet(context.Background(), 1, 10, testCase.query)
+				_, _ = trackApi.TracksGet(context.Background(), 1, 10, testCase.query, "", "", 0, 0, "", "", 0, "", "")
 
 				m.AssertExpectations(t)
 			})
@@ -160,12 +263,12 @@ func TestTracksApiService_TracksGet(t *testing.T) {
 		var tracks []*db.Track
 
 		m := new(trackRepoMock)
-		m.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tracks, 0, mongo.ErrNoDocuments)
+		m.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tracks, 0, mongo.ErrNoDocuments)
 		lm := new(languageDetectorMock)
 		lm.On("Detect", mock.Anything).Return("english", nil)
 		trackApi := TracksApiService{repo: m, languageDetector: lm}
 
-		res, err := trackApi.TracksGet(context.Background(), 1, 10, "query")
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "query", "", "", 0, 0, "", "", 0, "", "")
 
 		assert.Nil(t, err)
 		assert.Equal(t, http.StatusOK, res.Code)
@@ -176,17 +279,119 @@ func TestTracksApiService_TracksGet(t *testing.T) {
 		assert.Len(t, tr.Data, 0)
 	})
 
+	t.Run("filters without query", func(t *testing.T) {
+		tracks := []*db.Track{{SpotifyID: "1"}}
+		expectedFilter := db.TrackFilter{
+			Explicit:      db.ExplicitExclude,
+			ArtistID:      "artist",
+			ReleasedFrom:  1990,
+			ReleasedTo:    1999,
+			AddedAfter:    time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+			MinPopularity: 50,
+		}
+
+		m := new(trackRepoMock)
+		m.On("TracksAfter", expectedFilter, "", 10).Return(tracks, "next", 11, nil)
+		m.On("Search", "", expectedFilter, 2, 10, "").Return(tracks, 11, nil)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "", "exclude", "artist", 1990, 1999, "2021-06-01", "", 50, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		tr, _ := res.Body.(openapi.TracksGet200Response)
+		assert.Equal(t, int32(11), tr.Meta.Total)
+		assert.Equal(t, "next", tr.Meta.Next)
+
+		res, err = trackApi.TracksGet(context.Background(), 2, 10, "", "exclude", "artist", 1990, 1999, "2021-06-01", "", 50, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code, "pages without cursor should still be supported")
+
+		m.AssertExpectations(t)
+		m.AssertNotCalled(t, "LatestTracks", mock.Anything)
+	})
+
+	t.Run("lists the latest tracks without filter", func(t *testing.T) {
+		tracks := []*db.Track{{SpotifyID: "1"}}
+		m := new(trackRepoMock)
+		m.On("TracksAfter", db.TrackFilter{}, "", 25).Return(tracks, "next", 30, nil)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 0, 0, "", "", "", 0, 0, "", "", 0, "", "")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		tr, _ := res.Body.(openapi.TracksGet200Response)
+		assert.Len(t, tr.Data, 1)
+		assert.Equal(t, int32(30), tr.Meta.Total)
+		assert.Equal(t, "next", tr.Meta.Next)
+		m.AssertExpectations(t)
+		m.AssertNotCalled(t, "LatestTracks", mock.Anything)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		tracks := []*db.Track{{SpotifyID: "1"}}
+		m := new(trackRepoMock)
+		m.On("TracksAfter", db.TrackFilter{}, "abc", 25).Return(tracks, "", 26, nil)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 0, 0, "", "", "", 0, 0, "", "", 0, "", "abc")
+
+		m.AssertExpectations(t)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		tr, _ := res.Body.(openapi.TracksGet200Response)
+		assert.Len(t, tr.Data, 1)
+		assert.Equal(t, "", tr.Meta.Next, "last page should not have a next cursor")
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		m := new(trackRepoMock)
+		m.On("TracksAfter", db.TrackFilter{}, "abc", 10).Return([]*db.Track(nil), "", 0, db.ErrInvalidCursor)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "", "", "", 0, 0, "", "", 0, "", "abc")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("cursor cannot be combined with a query", func(t *testing.T) {
+		m := new(trackRepoMock)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "query", "", "", 0, 0, "", "", 0, "", "abc")
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		m.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		m := new(trackRepoMock)
+		trackApi := TracksApiService{repo: m}
+
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "", "sometimes", "", 0, 0, "", "", 0, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		res, err = trackApi.TracksGet(context.Background(), 1, 10, "", "", "", 0, 0, "yesterday", "", 0, "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		m.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("database error", func(t *testing.T) {
 		var tracks []*db.Track
 		databaseErr := errors.New("database error")
 
 		m := new(trackRepoMock)
-		m.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tracks, 0, databaseErr)
+		m.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tracks, 0, databaseErr)
 		lm := new(languageDetectorMock)
 		lm.On("Detect", mock.Anything).Return("english", nil)
 		trackApi := TracksApiService{repo: m, languageDetector: lm}
 
-		res, err := trackApi.TracksGet(context.Background(), 1, 10, "query")
+		res, err := trackApi.TracksGet(context.Background(), 1, 10, "query", "", "", 0, 0, "", "", 0, "", "")
 
 		assert.Equal(t, databaseErr, err)
 		assert.Equal(t, res.Code, http.StatusInternalServerError)
@@ -232,6 +437,43 @@ func TestTracksApiService_TracksIdPatch(t *testing.T) {
 		assert.Equal(t, td.Language, "german")
 	})
 
+	t.Run("removes the instrumental mark", func(t *testing.T) {
+		track := db.Track{SpotifyID: "id", Instrumental: true}
+		m := new(trackRepoMock)
+		lm := new(languageDetectorMock)
+		trackApi := TracksApiService{repo: m, languageDetector: lm}
+		m.On("FindTrack", "id").Return(&track, nil)
+		m.On("SetInstrumental", "id", false).Return(nil)
+		m.On("Save", &track).Return(nil)
+		lm.On("Detect", mock.Anything).Return("german", nil)
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "valid-token")
+		res, err := trackApi.TracksIdPatch(ctx, "id", openapi.Lyrics{Lyrics: "new lyrics"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.False(t, res.Body.(openapi.TrackDetail).Instrumental)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("notifies webhooks about edited lyrics", func(t *testing.T) {
+		track := db.Track{SpotifyID: "id", Name: "a track"}
+		m := new(trackRepoMock)
+		lm := new(languageDetectorMock)
+		events := new(eventDispatcherMock)
+		trackApi := TracksApiService{repo: m, languageDetector: lm, events: events}
+		m.On("FindTrack", "id").Return(&track, nil)
+		m.On("Save", &track).Return(nil)
+		lm.On("Detect", mock.Anything).Return("german", nil)
+		events.On("Dispatch", webhooks.EventLyricsEdited, webhooks.LyricsEditedData{SpotifyID: "id", Title: "a track", Language: "german"}).Return()
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "valid-token")
+		_, err := trackApi.TracksIdPatch(ctx, "id", openapi.Lyrics{Lyrics: "new lyrics"})
+
+		assert.Nil(t, err)
+		events.AssertExpectations(t)
+	})
+
 	t.Run("database error", func(t *testing.T) {
 		track := db.Track{SpotifyID: "id"}
 		databaseErr := errors.New("database error")
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/webhooks"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type eventDispatcher interface {
+	Dispatch(event string, data interface{})
+}
+
+// dispatch notifies the webhooks about an event. It does nothing if no dispatcher is configured.
+func dispatch(events eventDispatcher, event string, data interface{}) {
+	if events == nil {
+		return
+	}
+	events.Dispatch(event, data)
+}
+
+type webhooksApiService struct {
+	repo db.WebhookRepository
+}
+
+func (s webhooksApiService) WebhooksGet(ctx context.Context) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	hooks, err := s.repo.Webhooks(ctx)
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.Webhook, len(hooks))
+	for i := range hooks {
+		data[i] = toWebhook(*hooks[i])
+	}
+	return openapi.Response(http.StatusOK, data), nil
+}
+
+func (s webhooksApiService) WebhooksPost(ctx context.Context, request openapi.WebhookRequest) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	u, err := url.Parse(request.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return openapi.Response(http.StatusBadRequest, nil), nil
+	}
+	for _, e := range request.Events {
+		if !webhooks.IsEvent(e) {
+			return openapi.Response(http.StatusBadRequest, nil), nil
+		}
+	}
+
+	hook := db.Webhook{
+		URL:       request.Url,
+		Secret:    request.Secret,
+		Events:    request.Events,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.SaveWebhook(ctx, &hook); err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	return openapi.Response(http.StatusCreated, toWebhook(hook)), nil
+}
+
+func (s webhooksApiService) WebhooksIdDelete(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+
+	err := s.repo.DeleteWebhook(ctx, id)
+	switch err {
+	case nil:
+		return openapi.Response(http.StatusOK, nil), nil
+	case db.ErrWebhookNotFound:
+		return openapi.Response(http.StatusNotFound, nil), err
+	default:
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+}
+
+func (s webhooksApiService) WebhooksIdDeliveriesGet(ctx context.Context, id string, page int32, limit int32) (openapi.ImplResponse, error) {
+	if !isAuthenticated(ctx) {
+		return openapi.Response(http.StatusUnauthorized, nil), ErrNotAuthenticated
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 25
+	}
+
+	if _, err := s.repo.FindWebhook(ctx, id); err != nil {
+		return openapi.Response(http.StatusNotFound, nil), err
+	}
+
+	deliveries, total, err := s.repo.Deliveries(ctx, id, int(page), int(limit))
+	if err != nil {
+		return openapi.Response(http.StatusInternalServerError, nil), err
+	}
+
+	data := make([]openapi.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		data[i] = toWebhookDelivery(*deliveries[i])
+	}
+
+	return openapi.Response(http.StatusOK, openapi.WebhooksIdDeliveriesGet200Response{
+		Meta: openapi.PaginationMetadata{
+			Page:  page,
+			Limit: limit,
+			Total: int32(total),
+		},
+		Data: data,
+	}), nil
+}
+
+// toWebhook converts a webhook to its api representation. The secret is never returned.
+func toWebhook(w db.Webhook) openapi.Webhook {
+	return openapi.Webhook{
+		Id:        w.ID.Hex(),
+		Url:       w.URL,
+		Events:    w.Events,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func toWebhookDelivery(d db.Delivery) openapi.WebhookDelivery {
+	return openapi.WebhookDelivery{
+		Id:          d.ID.Hex(),
+		Event:       d.Event,
+		Payload:     d.Payload,
+		Attempts:    int32(d.Attempts),
+		StatusCode:  int32(d.StatusCode),
+		Error:       d.Error,
+		Success:     d.Success,
+		Pending:     d.Pending,
+		CreatedAt:   d.CreatedAt,
+		DeliveredAt: d.DeliveredAt,
+	}
+}
+
+var _ openapi.WebhooksApiServicer = &webhooksApiService{}
+
+func newWebhooksApiService(repo db.WebhookRepository) webhooksApiService {
+	return webhooksApiService{
+		repo: repo,
+	}
+}
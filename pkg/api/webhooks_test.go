@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/openapi"
+	"github.com/imba28/spolyr/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type webhookRepoMock struct {
+	mock.Mock
+}
+
+func (w *webhookRepoMock) Webhooks(ctx context.Context) ([]*db.Webhook, error) {
+	args := w.Called()
+	return args.Get(0).([]*db.Webhook), args.Error(1)
+}
+func (w *webhookRepoMock) FindWebhook(ctx context.Context, id string) (*db.Webhook, error) {
+	args := w.Called(id)
+	return args.Get(0).(*db.Webhook), args.Error(1)
+}
+func (w *webhookRepoMock) SaveWebhook(ctx context.Context, webhook *db.Webhook) error {
+	return w.Called(webhook).Error(0)
+}
+func (w *webhookRepoMock) DeleteWebhook(ctx context.Context, id string) error {
+	return w.Called(id).Error(0)
+}
+func (w *webhookRepoMock) SaveDelivery(ctx context.Context, delivery *db.Delivery) error {
+	return w.Called(delivery).Error(0)
+}
+func (w *webhookRepoMock) Deliveries(ctx context.Context, webhookID string, page, limit int) ([]*db.Delivery, int, error) {
+	args := w.Called(webhookID, page, limit)
+	return args.Get(0).([]*db.Delivery), args.Int(1), args.Error(2)
+}
+
+var _ db.WebhookRepository = &webhookRepoMock{}
+
+type eventDispatcherMock struct {
+	mock.Mock
+}
+
+func (e *eventDispatcherMock) Dispatch(event string, data interface{}) {
+	e.Called(event, data)
+}
+
+var _ eventDispatcher = &eventDispatcherMock{}
+
+func TestWebhooksApiService_WebhooksGet(t *testing.T) {
+	t.Run("denies unauthenticated access", func(t *testing.T) {
+		service := webhooksApiService{}
+		res, err := service.WebhooksGet(context.Background())
+
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+		assert.Error(t, err)
+	})
+
+	t.Run("does not return secrets", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		repoMock := new(webhookRepoMock)
+		repoMock.On("Webhooks").Return([]*db.Webhook{{ID: id, URL: "https://example.com", Secret: "secret", Events: webhooks.Events}}, nil)
+		service := webhooksApiService{repo: repoMock}
+
+		ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+		res, err := service.WebhooksGet(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, []openapi.Webhook{{Id: id.Hex(), Url: "https://example.com", Events: webhooks.Events}}, res.Body)
+	})
+}
+
+func TestWebhooksApiService_WebhooksPost(t *testing.T) {
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+
+	t.Run("creates webhook", func(t *testing.T) {
+		repoMock := new(webhookRepoMock)
+		repoMock.On("SaveWebhook", mock.MatchedBy(func(w *db.Webhook) bool {
+			return w.URL == "https://example.com/hook" && w.Secret == "secret" && !w.CreatedAt.IsZero()
+		})).Return(nil)
+		service := webhooksApiService{repo: repoMock}
+
+		res, err := service.WebhooksPost(ctx, openapi.WebhookRequest{
+			Url:    "https://example.com/hook",
+			Secret: "secret",
+			Events: []string{webhooks.EventLyricsEdited},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusCreated, res.Code)
+		repoMock.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		tests := map[string]openapi.WebhookRequest{
+			"invalid url":   {Url: "example.com", Events: []string{webhooks.EventLyricsEdited}},
+			"invalid event": {Url: "https://example.com", Events: []string{"tracks.deleted"}},
+		}
+		for name, request := range tests {
+			t.Run(name, func(t *testing.T) {
+				repoMock := new(webhookRepoMock)
+				service := webhooksApiService{repo: repoMock}
+
+				res, err := service.WebhooksPost(ctx, request)
+
+				assert.Nil(t, err)
+				assert.Equal(t, http.StatusBadRequest, res.Code)
+				repoMock.AssertNotCalled(t, "SaveWebhook", mock.Anything)
+			})
+		}
+	})
+}
+
+func TestWebhooksApiService_WebhooksIdDelete(t *testing.T) {
+	repoMock := new(webhookRepoMock)
+	repoMock.On("DeleteWebhook", "unknown").Return(db.ErrWebhookNotFound)
+	service := webhooksApiService{repo: repoMock}
+
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+	res, err := service.WebhooksIdDelete(ctx, "unknown")
+
+	assert.ErrorIs(t, err, db.ErrWebhookNotFound)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestWebhooksApiService_WebhooksIdDeliveriesGet(t *testing.T) {
+	createdAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	repoMock := new(webhookRepoMock)
+	repoMock.On("FindWebhook", "a").Return(&db.Webhook{}, nil)
+	repoMock.On("Deliveries", "a", 1, 25).Return([]*db.Delivery{{Event: webhooks.EventLyricsEdited, Attempts: 2, StatusCode: 500, CreatedAt: createdAt}}, 1, nil)
+	service := webhooksApiService{repo: repoMock}
+
+	ctx := context.WithValue(context.Background(), jwtAccessKey, "a-valid-token")
+	res, err := service.WebhooksIdDeliveriesGet(ctx, "a", 0, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.Code)
+	body := res.Body.(openapi.WebhooksIdDeliveriesGet200Response)
+	assert.Equal(t, int32(1), body.Meta.Total)
+	assert.Equal(t, int32(2), body.Data[0].Attempts)
+	assert.Equal(t, int32(500), body.Data[0].StatusCode)
+	assert.Equal(t, createdAt, body.Data[0].CreatedAt)
+}
@@ -0,0 +1,168 @@
+// Package cache keeps the results of repository reads and language detection, so popular searches and tracks are not
+// loaded from the database on every request.
+package cache
+
+import (
+	"container/list"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/imba28/spolyr/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// Backend stores cached values. Implementations must be safe for concurrent use. Values may be evicted at any time,
+// so a Backend shared by multiple instances, e.g. backed by Redis, can be plugged in as well.
+type Backend interface {
+	Get(key string) ([]byte, bool)
+	// Set stores the value for at most ttl. A ttl of zero keeps the value until it is evicted.
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU is an in-process Backend that evicts the least recently used entries once it holds more than size entries.
+type LRU struct {
+	size    int
+	entries *list.List
+	keys    map[string]*list.Element
+	now     func() time.Time
+
+	sync.Mutex
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		entries: list.New(),
+		keys:    make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+func (c *LRU) Get(key string) ([]byte, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.keys[key]
+	if !ok {
+		return nil, false
+	}
+	en := e.Value.(*entry)
+	if !en.expiresAt.IsZero() && !c.now().Before(en.expiresAt) {
+		c.remove(e)
+		return nil, false
+	}
+	c.entries.MoveToFront(e)
+	return en.value, true
+}
+
+func (c *LRU) Set(key string, value []byte, ttl time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if e, ok := c.keys[key]; ok {
+		en := e.Value.(*entry)
+		en.value = value
+		en.expiresAt = expiresAt
+		c.entries.MoveToFront(e)
+		return
+	}
+
+	c.keys[key] = c.entries.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.entries.Len() > c.size {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *LRU) Delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.keys[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Len returns the number of cached entries, including expired entries that have not been evicted yet.
+func (c *LRU) Len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.entries.Len()
+}
+
+func (c *LRU) remove(e *list.Element) {
+	c.entries.Remove(e)
+	delete(c.keys, e.Value.(*entry).key)
+}
+
+// generationKey stores the current generation of a cache. Changing it invalidates all entries of the cache at once.
+const generationKey = "generation"
+
+// cache stores JSON encoded values of a single kind in a Backend. Keys are prefixed with the name of the cache and
+// its generation, so invalidated entries are never read again and are evicted eventually.
+type cache struct {
+	name    string
+	backend Backend
+	ttl     time.Duration
+}
+
+func (c cache) generation() string {
+	key := c.name + ":" + generationKey
+	if g, ok := c.backend.Get(key); ok {
+		return string(g)
+	}
+	// an evicted generation is replaced, which invalidates all entries that might have been written before
+	g := newGeneration()
+	c.backend.Set(key, []byte(g), 0)
+	return g
+}
+
+// invalidate removes all entries of the cache.
+func (c cache) invalidate() {
+	c.backend.Set(c.name+":"+generationKey, []byte(newGeneration()), 0)
+}
+
+func newGeneration() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// key returns the key of the value identified by parts. Parts are hashed, so large values like lyrics can be used.
+func (c cache) key(generation string, parts ...interface{}) string {
+	b, _ := json.Marshal(parts)
+	sum := sha256.Sum256(b)
+	return c.name + ":" + generation + ":" + hex.EncodeToString(sum[:])
+}
+
+// get decodes the cached value into v and reports whether it has been found.
+func (c cache) get(key string, v interface{}) bool {
+	b, ok := c.backend.Get(key)
+	hit := ok && json.Unmarshal(b, v) == nil
+	metrics.ObserveCache(c.name, hit)
+	return hit
+}
+
+func (c cache) set(key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	c.backend.Set(key, b, c.ttl)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("evicts least recently used entries", func(t *testing.T) {
+		c := NewLRU(2)
+		c.Set("a", []byte("1"), 0)
+		c.Set("b", []byte("2"), 0)
+		c.Get("a")
+		c.Set("c", []byte("3"), 0)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok, "b should have been evicted")
+		v, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), v)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("replaces values", func(t *testing.T) {
+		c := NewLRU(2)
+		c.Set("a", []byte("1"), 0)
+		c.Set("a", []byte("2"), 0)
+
+		v, _ := c.Get("a")
+		assert.Equal(t, []byte("2"), v)
+		assert.Equal(t, 1, c.Len())
+	})
+
+	t.Run("expires entries", func(t *testing.T) {
+		now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		c := NewLRU(2)
+		c.now = func() time.Time { return now }
+		c.Set("a", []byte("1"), time.Minute)
+
+		_, ok := c.Get("a")
+		assert.True(t, ok)
+
+		now = now.Add(time.Minute)
+		_, ok = c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("deletes entries", func(t *testing.T) {
+		c := NewLRU(2)
+		c.Set("a", []byte("1"), 0)
+		c.Delete("a")
+		c.Delete("b")
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+	})
+}
+
+func TestCache_invalidate(t *testing.T) {
+	c := cache{name: "test", backend: NewLRU(10)}
+	key := c.key(c.generation(), "a")
+	c.set(key, "value")
+	assert.Equal(t, key, c.key(c.generation(), "a"), "keys should be stable until the cache is invalidated")
+
+	c.invalidate()
+
+	assert.NotEqual(t, key, c.key(c.generation(), "a"))
+}
+
+func TestCache_generation__evicted(t *testing.T) {
+	backend := NewLRU(10)
+	c := cache{name: "test", backend: backend}
+	g := c.generation()
+
+	backend.Delete("test:" + generationKey)
+
+	assert.NotEqual(t, g, c.generation(), "entries written before the generation has been evicted must not be read")
+}
@@ -0,0 +1,37 @@
+package cache
+
+import "time"
+
+type languageDetector interface {
+	Detect(string) (string, error)
+}
+
+// LanguageDetector caches the languages detected by another detector. Failed detections are not cached.
+type LanguageDetector struct {
+	detector languageDetector
+	cache    cache
+}
+
+func (d LanguageDetector) Detect(s string) (string, error) {
+	// detected languages never change, so the entries do not need a generation
+	key := d.cache.key("", s)
+	var language string
+	if d.cache.get(key, &language) {
+		return language, nil
+	}
+
+	language, err := d.detector.Detect(s)
+	if err != nil {
+		return "", err
+	}
+	d.cache.set(key, language)
+	return language, nil
+}
+
+// NewLanguageDetector caches the languages detected by detector in backend for at most ttl.
+func NewLanguageDetector(detector languageDetector, backend Backend, ttl time.Duration) LanguageDetector {
+	return LanguageDetector{
+		detector: detector,
+		cache:    cache{name: "languages", backend: backend, ttl: ttl},
+	}
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type languageDetectorMock struct {
+	mock.Mock
+}
+
+func (l *languageDetectorMock) Detect(s string) (string, error) {
+	args := l.Called(s)
+	return args.String(0), args.Error(1)
+}
+
+func TestLanguageDetector_Detect(t *testing.T) {
+	t.Run("detects languages once", func(t *testing.T) {
+		m := new(languageDetectorMock)
+		m.On("Detect", "hallo welt").Once().Return("german", nil)
+		d := NewLanguageDetector(m, NewLRU(10), 0)
+
+		for i := 0; i < 2; i++ {
+			language, err := d.Detect("hallo welt")
+			assert.Nil(t, err)
+			assert.Equal(t, "german", language)
+		}
+		m.AssertExpectations(t)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		m := new(languageDetectorMock)
+		m.On("Detect", "?").Times(2).Return("", errors.New("unknown language"))
+		d := NewLanguageDetector(m, NewLRU(10), 0)
+
+		_, err := d.Detect("?")
+		assert.Error(t, err)
+		_, err = d.Detect("?")
+		assert.Error(t, err)
+		m.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"time"
+)
+
+// TrackRepository caches track lookups and searches of a db.TrackRepository. Every write through the repository
+// invalidates all cached results, because a changed track can be part of any search result. Writes that bypass the
+// repository, e.g. by another process, are visible once the cached results have expired.
+type TrackRepository struct {
+	db.TrackRepository
+	cache cache
+}
+
+var _ db.TrackRepository = &TrackRepository{}
+
+// trackPage is a cached page of tracks.
+type trackPage struct {
+	Tracks []*db.Track
+	Total  int
+	Next   string
+}
+
+func (r *TrackRepository) FindTrack(ctx context.Context, spotifyID string) (*db.Track, error) {
+	key := r.cache.key(r.cache.generation(), "FindTrack", spotifyID)
+	var track db.Track
+	if r.cache.get(key, &track) {
+		return &track, nil
+	}
+
+	t, err := r.TrackRepository.FindTrack(ctx, spotifyID)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.set(key, t)
+	return t, nil
+}
+
+func (r *TrackRepository) LatestTracks(ctx context.Context, limit int64) ([]*db.Track, error) {
+	key := r.cache.key(r.cache.generation(), "LatestTracks", limit)
+	var page trackPage
+	if r.cache.get(key, &page) {
+		return page.Tracks, nil
+	}
+
+	tracks, err := r.TrackRepository.LatestTracks(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.set(key, trackPage{Tracks: tracks})
+	return tracks, nil
+}
+
+func (r *TrackRepository) TracksAfter(ctx context.Context, filter db.TrackFilter, cursor string, limit int) ([]*db.Track, string, int, error) {
+	key := r.cache.key(r.cache.generation(), "TracksAfter", filter, cursor, limit)
+	var page trackPage
+	if r.cache.get(key, &page) {
+		return page.Tracks, page.Next, page.Total, nil
+	}
+
+	tracks, next, total, err := r.TrackRepository.TracksAfter(ctx, filter, cursor, limit)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	r.cache.set(key, trackPage{Tracks: tracks, Total: total, Next: next})
+	return tracks, next, total, nil
+}
+
+func (r *TrackRepository) Search(ctx context.Context, query string, filter db.TrackFilter, page, limit int, language string) ([]*db.Track, int, error) {
+	key := r.cache.key(r.cache.generation(), "Search", query, filter, page, limit, language)
+	var p trackPage
+	if r.cache.get(key, &p) {
+		return p.Tracks, p.Total, nil
+	}
+
+	tracks, total, err := r.TrackRepository.Search(ctx, query, filter, page, limit, language)
+	if err != nil {
+		return nil, 0, err
+	}
+	r.cache.set(key, trackPage{Tracks: tracks, Total: total})
+	return tracks, total, nil
+}
+
+func (r *TrackRepository) Save(ctx context.Context, track *db.Track) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.Save(ctx, track)
+}
+
+func (r *TrackRepository) SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error) {
+	defer r.cache.invalidate()
+	return r.TrackRepository.SaveMany(ctx, tracks)
+}
+
+func (r *TrackRepository) ResetLyrics(ctx context.Context, track *db.Track) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.ResetLyrics(ctx, track)
+}
+
+func (r *TrackRepository) SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.SetGroup(ctx, spotifyID, groupID, locked)
+}
+
+func (r *TrackRepository) SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.SetPlaylistTracks(ctx, playlistID, spotifyIDs)
+}
+
+func (r *TrackRepository) SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.SetOrphaned(ctx, spotifyID, orphanedAt)
+}
+
+func (r *TrackRepository) DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error) {
+	defer r.cache.invalidate()
+	return r.TrackRepository.DeleteOrphans(ctx, orphanedBefore)
+}
+
+func (r *TrackRepository) DeleteOrphan(ctx context.Context, spotifyID string) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.DeleteOrphan(ctx, spotifyID)
+}
+
+func (r *TrackRepository) SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error {
+	defer r.cache.invalidate()
+	return r.TrackRepository.SetInstrumental(ctx, spotifyID, instrumental)
+}
+
+// NewTrackRepository caches the reads of repo in backend for at most ttl.
+func NewTrackRepository(repo db.TrackRepository, backend Backend, ttl time.Duration) *TrackRepository {
+	return &TrackRepository{
+		TrackRepository: repo,
+		cache:           cache{name: "tracks", backend: backend, ttl: ttl},
+	}
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// trackRepoMock only implements the methods used by the tests, all other methods panic.
+type trackRepoMock struct {
+	db.TrackRepository
+	mock.Mock
+}
+
+func (t *trackRepoMock) FindTrack(ctx context.Context, spotifyID string) (*db.Track, error) {
+	args := t.Called(spotifyID)
+	return args.Get(0).(*db.Track), args.Error(1)
+}
+func (t *trackRepoMock) Search(ctx context.Context, query string, filter db.TrackFilter, page, limit int, language string) ([]*db.Track, int, error) {
+	args := t.Called(query, filter, page, limit, language)
+	return args.Get(0).([]*db.Track), args.Int(1), args.Error(2)
+}
+func (t *trackRepoMock) TracksAfter(ctx context.Context, filter db.TrackFilter, cursor string, limit int) ([]*db.Track, string, int, error) {
+	args := t.Called(filter, cursor, limit)
+	return args.Get(0).([]*db.Track), args.String(1), args.Int(2), args.Error(3)
+}
+func (t *trackRepoMock) Save(ctx context.Context, track *db.Track) error {
+	return t.Called(track).Error(0)
+}
+
+func TestTrackRepository_FindTrack(t *testing.T) {
+	t.Run("loads tracks once", func(t *testing.T) {
+		m := new(trackRepoMock)
+		m.On("FindTrack", "1").Once().Return(&db.Track{SpotifyID: "1", Name: "track A"}, nil)
+		r := NewTrackRepository(m, NewLRU(10), 0)
+
+		first, err := r.FindTrack(context.Background(), "1")
+		assert.Nil(t, err)
+		first.Name = "changed by the caller"
+		second, err := r.FindTrack(context.Background(), "1")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "track A", second.Name, "cached tracks should not be shared with callers")
+		m.AssertExpectations(t)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		m := new(trackRepoMock)
+		m.On("FindTrack", "1").Times(2).Return((*db.Track)(nil), db.ErrTrackNotFound)
+		r := NewTrackRepository(m, NewLRU(10), 0)
+
+		_, err := r.FindTrack(context.Background(), "1")
+		assert.ErrorIs(t, err, db.ErrTrackNotFound)
+		_, err = r.FindTrack(context.Background(), "1")
+		assert.ErrorIs(t, err, db.ErrTrackNotFound)
+
+		m.AssertExpectations(t)
+	})
+}
+
+func TestTrackRepository_Search(t *testing.T) {
+	filter := db.TrackFilter{Explicit: db.ExplicitExclude}
+	m := new(trackRepoMock)
+	m.On("Search", "love", filter, 1, 10, "english").Once().Return([]*db.Track{{SpotifyID: "1"}}, 12, nil)
+	m.On("Search", "love", filter, 2, 10, "english").Once().Return([]*db.Track{{SpotifyID: "2"}}, 12, nil)
+	r := NewTrackRepository(m, NewLRU(10), 0)
+
+	for i := 0; i < 2; i++ {
+		tracks, total, err := r.Search(context.Background(), "love", filter, 1, 10, "english")
+		assert.Nil(t, err)
+		assert.Equal(t, 12, total)
+		assert.Equal(t, "1", tracks[0].SpotifyID)
+	}
+	tracks, _, _ := r.Search(context.Background(), "love", filter, 2, 10, "english")
+
+	assert.Equal(t, "2", tracks[0].SpotifyID, "pages should be cached separately")
+	m.AssertExpectations(t)
+}
+
+func TestTrackRepository_TracksAfter(t *testing.T) {
+	m := new(trackRepoMock)
+	m.On("TracksAfter", db.TrackFilter{}, "abc", 10).Once().Return([]*db.Track{{SpotifyID: "1"}}, "def", 30, nil)
+	r := NewTrackRepository(m, NewLRU(10), 0)
+
+	for i := 0; i < 2; i++ {
+		tracks, next, total, err := r.TracksAfter(context.Background(), db.TrackFilter{}, "abc", 10)
+		assert.Nil(t, err)
+		assert.Len(t, tracks, 1)
+		assert.Equal(t, "def", next)
+		assert.Equal(t, 30, total)
+	}
+	m.AssertExpectations(t)
+}
+
+func TestTrackRepository_Save(t *testing.T) {
+	track := &db.Track{SpotifyID: "1", Name: "track A"}
+	m := new(trackRepoMock)
+	m.On("FindTrack", "1").Times(2).Return(track, nil)
+	m.On("Save", track).Return(nil)
+	r := NewTrackRepository(m, NewLRU(10), 0)
+
+	_, _ = r.FindTrack(context.Background(), "1")
+	assert.Nil(t, r.Save(context.Background(), track))
+	_, _ = r.FindTrack(context.Background(), "1")
+
+	m.AssertExpectations(t)
+}
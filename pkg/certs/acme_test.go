@@ -0,0 +1,245 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// acmeServer is a minimal stand-in for an ACME certificate authority like Pebble. It accepts every challenge without
+// validating it and does not verify the signatures of requests.
+type acmeServer struct {
+	*httptest.Server
+	t *testing.T
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	sync.Mutex
+	domain     string
+	authzValid bool
+	cert       []byte
+	orders     int
+}
+
+func newACMEServer(t *testing.T) *acmeServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "acme test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	assert.Nil(t, err)
+	caCert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	s := &acmeServer{t: t, caKey: caKey, caCert: caCert}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// caFile writes the certificate of the https server of the ACME directory to a file.
+func (s *acmeServer) caFile() string {
+	file := filepath.Join(s.t.TempDir(), "acme-ca.pem")
+	assert.Nil(s.t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}), 0600))
+	return file
+}
+
+func (s *acmeServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	w.Header().Set("Replay-Nonce", base64.RawURLEncoding.EncodeToString([]byte(time.Now().String())))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if r.Method == http.MethodPost {
+		_ = json.NewDecoder(r.Body).Decode(&jws)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	switch r.URL.Path {
+	case "/directory":
+		s.json(w, http.StatusOK, map[string]interface{}{
+			"newNonce":   s.URL + "/nonce",
+			"newAccount": s.URL + "/account",
+			"newOrder":   s.URL + "/order",
+			"revokeCert": s.URL + "/revoke",
+			"keyChange":  s.URL + "/key-change",
+		})
+	case "/account":
+		w.Header().Set("Location", s.URL+"/account/1")
+		s.json(w, http.StatusCreated, map[string]interface{}{"status": "valid"})
+	case "/order":
+		var req struct {
+			Identifiers []struct{ Value string }
+		}
+		_ = json.Unmarshal(payload, &req)
+		s.domain = req.Identifiers[0].Value
+		s.authzValid = false
+		s.cert = nil
+		s.orders++
+
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.json(w, http.StatusCreated, s.order())
+	case "/order/1":
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.json(w, http.StatusOK, s.order())
+	case "/authz/1":
+		s.json(w, http.StatusOK, s.authz())
+	case "/challenge/1":
+		s.authzValid = true
+		s.json(w, http.StatusOK, s.challenge())
+	case "/finalize/1":
+		var req struct {
+			CSR string
+		}
+		_ = json.Unmarshal(payload, &req)
+		s.cert = s.sign(req.CSR)
+
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.json(w, http.StatusOK, s.order())
+	case "/cert/1":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(s.cert)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *acmeServer) json(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *acmeServer) order() map[string]interface{} {
+	status := "pending"
+	if s.cert != nil {
+		status = "valid"
+	} else if s.authzValid {
+		status = "ready"
+	}
+	return map[string]interface{}{
+		"status":         status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": s.domain}},
+		"authorizations": []string{s.URL + "/authz/1"},
+		"finalize":       s.URL + "/finalize/1",
+		"certificate":    s.URL + "/cert/1",
+	}
+}
+
+func (s *acmeServer) authz() map[string]interface{} {
+	status := "pending"
+	if s.authzValid {
+		status = "valid"
+	}
+	return map[string]interface{}{
+		"status":     status,
+		"identifier": map[string]string{"type": "dns", "value": s.domain},
+		"challenges": []interface{}{s.challenge()},
+	}
+}
+
+func (s *acmeServer) challenge() map[string]interface{} {
+	status := "pending"
+	if s.authzValid {
+		status = "valid"
+	}
+	return map[string]interface{}{
+		"type":   "tls-alpn-01",
+		"url":    s.URL + "/challenge/1",
+		"token":  "token",
+		"status": status,
+	}
+}
+
+// sign issues a certificate for the CSR and returns the PEM encoded chain.
+func (s *acmeServer) sign(encodedCSR string) []byte {
+	der, err := base64.RawURLEncoding.DecodeString(encodedCSR)
+	assert.Nil(s.t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	assert.Nil(s.t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: csr.DNSNames[0]},
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, s.caCert, csr.PublicKey, s.caKey)
+	assert.Nil(s.t, err)
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf})
+	return append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.caCert.Raw})...)
+}
+
+func TestManager__acme(t *testing.T) {
+	ca := newACMEServer(t)
+	config := Config{
+		ACMEDomains:   []string{"spolyr.test"},
+		ACMEEmail:     "admin@spolyr.test",
+		ACMEDirectory: ca.URL + "/directory",
+		ACMECacheDir:  t.TempDir(),
+		ACMECAFile:    ca.caFile(),
+	}
+
+	m, err := New(config)
+	assert.Nil(t, err)
+
+	t.Run("obtains certificate", func(t *testing.T) {
+		cert, err := m.TLSConfig().GetCertificate(&tls.ClientHelloInfo{ServerName: "spolyr.test"})
+		assert.Nil(t, err)
+		if assert.NotNil(t, cert) {
+			assert.Equal(t, []string{"spolyr.test"}, cert.Leaf.DNSNames)
+			assert.Equal(t, "acme test ca", cert.Leaf.Issuer.CommonName)
+		}
+	})
+
+	t.Run("rejects unknown domains", func(t *testing.T) {
+		_, err := m.TLSConfig().GetCertificate(&tls.ClientHelloInfo{ServerName: "other.test"})
+		assert.Error(t, err)
+	})
+
+	t.Run("reuses cached certificates after a restart", func(t *testing.T) {
+		restarted, err := New(config)
+		assert.Nil(t, err)
+
+		cert, err := restarted.TLSConfig().GetCertificate(&tls.ClientHelloInfo{ServerName: "spolyr.test"})
+		assert.Nil(t, err)
+		assert.NotNil(t, cert)
+
+		ca.Lock()
+		defer ca.Unlock()
+		assert.Equal(t, 1, ca.orders)
+	})
+}
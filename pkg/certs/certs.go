@@ -0,0 +1,164 @@
+// Package certs provides the TLS configuration of the web server. Certificates are either loaded from files or obtained
+// automatically from an ACME certificate authority like Let's Encrypt.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrIncompleteKeyPair = errors.New("both a certificate and a key file are required")
+	ErrConflictingSource = errors.New("certificate files and ACME cannot be used at the same time")
+	ErrNoCertificates    = errors.New("tls is not configured")
+)
+
+// Config describes where certificates come from. Either CertFile and KeyFile or ACMEDomains must be set.
+type Config struct {
+	CertFile string
+	KeyFile  string
+
+	// ACMEDomains are the domains certificates are requested for.
+	ACMEDomains []string
+	ACMEEmail   string
+	// ACMEDirectory is the directory url of the certificate authority (default: Let's Encrypt).
+	ACMEDirectory string
+	// ACMECacheDir stores the account key and issued certificates across restarts.
+	ACMECacheDir string
+	// ACMECAFile contains additional PEM encoded root certificates trusted when connecting to the ACME directory,
+	// e.g. the root of a local test authority like Pebble.
+	ACMECAFile string
+}
+
+// Enabled reports whether any source of certificates has been configured.
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.ACMEDomains) > 0
+}
+
+func (c Config) validate() error {
+	if !c.Enabled() {
+		return ErrNoCertificates
+	}
+	if (c.CertFile != "" || c.KeyFile != "") && len(c.ACMEDomains) > 0 {
+		return ErrConflictingSource
+	}
+	if len(c.ACMEDomains) == 0 && (c.CertFile == "" || c.KeyFile == "") {
+		return ErrIncompleteKeyPair
+	}
+	return nil
+}
+
+// Manager provides certificates to the https listener and, using ACME, answers challenges on the http listener.
+type Manager struct {
+	tlsConfig *tls.Config
+	acme      *autocert.Manager
+}
+
+func New(c Config) (*Manager, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	if len(c.ACMEDomains) == 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load certificate: %w", err)
+		}
+		return &Manager{
+			tlsConfig: &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				NextProtos:   []string{"h2", "http/1.1"},
+				MinVersion:   tls.VersionTLS12,
+			},
+		}, nil
+	}
+
+	client, err := acmeClient(c)
+	if err != nil {
+		return nil, err
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(c.ACMEDomains...),
+		Email:      c.ACMEEmail,
+		Client:     client,
+	}
+	if c.ACMECacheDir != "" {
+		m.Cache = autocert.DirCache(c.ACMECacheDir)
+	}
+
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return &Manager{
+		tlsConfig: tlsConfig,
+		acme:      m,
+	}, nil
+}
+
+func acmeClient(c Config) (*acme.Client, error) {
+	client := &acme.Client{
+		DirectoryURL: c.ACMEDirectory,
+	}
+	if c.ACMECAFile == "" {
+		return client, nil
+	}
+
+	pem, err := os.ReadFile(c.ACMECAFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read ACME ca file: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ACME ca file %s does not contain any certificate", c.ACMECAFile)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	client.HTTPClient = &http.Client{Transport: transport}
+	return client, nil
+}
+
+// TLSConfig returns the configuration of the https listener.
+func (m *Manager) TLSConfig() *tls.Config {
+	return m.tlsConfig
+}
+
+// HTTPHandler returns the handler of the plain http listener. If certificates are obtained using ACME, it answers
+// http-01 challenges. All other requests are passed to fallback, e.g. Redirect.
+func (m *Manager) HTTPHandler(fallback http.Handler) http.Handler {
+	if m.acme == nil {
+		return fallback
+	}
+	return m.acme.HTTPHandler(fallback)
+}
+
+// Redirect returns a handler redirecting requests to the same url using https on the given port.
+func Redirect(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
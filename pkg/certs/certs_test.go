@@ -0,0 +1,122 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert creates a certificate for the domain and writes the certificate and key as PEM files.
+func writeSelfSignedCert(t *testing.T, domain string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func TestNew__invalid_config(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   error
+	}{
+		{"nothing configured", Config{}, ErrNoCertificates},
+		{"key file is missing", Config{CertFile: "cert.pem"}, ErrIncompleteKeyPair},
+		{"certificate file is missing", Config{KeyFile: "key.pem"}, ErrIncompleteKeyPair},
+		{"files and acme", Config{CertFile: "cert.pem", KeyFile: "key.pem", ACMEDomains: []string{"example.com"}}, ErrConflictingSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestNew__certificate_files(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, "spolyr.test")
+
+	m, err := New(Config{CertFile: certFile, KeyFile: keyFile})
+
+	assert.Nil(t, err)
+	assert.Len(t, m.TLSConfig().Certificates, 1)
+	assert.Equal(t, uint16(tls.VersionTLS12), m.TLSConfig().MinVersion)
+}
+
+func TestNew__missing_certificate_files(t *testing.T) {
+	_, err := New(Config{CertFile: "missing.pem", KeyFile: "missing.pem"})
+
+	assert.Error(t, err)
+}
+
+func TestNew__invalid_acme_ca_file(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	assert.Nil(t, os.WriteFile(file, []byte("no certificate"), 0600))
+
+	_, err := New(Config{ACMEDomains: []string{"spolyr.test"}, ACMECAFile: file})
+
+	assert.Error(t, err)
+}
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		httpsPort int
+		want      string
+	}{
+		{"default port", "http://spolyr.test/tracks?page=2", 443, "https://spolyr.test/tracks?page=2"},
+		{"custom port", "http://spolyr.test:8080/", 8443, "https://spolyr.test:8443/"},
+		{"ipv6", "http://[::1]:8080/", 443, "https://[::1]/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Redirect(tt.httpsPort).ServeHTTP(rec, httptest.NewRequest("GET", tt.url, nil))
+
+			assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestManager_HTTPHandler(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, "spolyr.test")
+	m, err := New(Config{CertFile: certFile, KeyFile: keyFile})
+	assert.Nil(t, err)
+
+	rec := httptest.NewRecorder()
+	m.HTTPHandler(Redirect(8443)).ServeHTTP(rec, httptest.NewRequest("GET", "http://spolyr.test/", nil))
+
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "https://spolyr.test:8443/", rec.Header().Get("Location"))
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+	"os"
+	"time"
+)
+
+const (
+	DefaultDatabaseName              = "spolyr"
+	DefaultMaxLyricsImportErrorCount = 3
+)
+
+// Config describes how to connect to MongoDB.
+type Config struct {
+	// URI is a MongoDB connection string, e.g. mongodb+srv://cluster.example.com/?authSource=admin or
+	// mongodb://a:27017,b:27017/?replicaSet=rs0.
+	URI string
+	// Username and Password are used if the URI does not contain credentials.
+	Username string
+	Password string
+	// Database is the name of the database. If empty, the database of the URI or DefaultDatabaseName is used.
+	Database string
+	// TLSCAFile contains PEM encoded root certificates used to verify the server. It enables TLS.
+	TLSCAFile string
+
+	// MinPoolSize and MaxPoolSize limit the number of connections per server. Zero uses the defaults of the driver.
+	MinPoolSize uint64
+	MaxPoolSize uint64
+	// ConnectTimeout and ServerSelectionTimeout are applied if they are greater than zero.
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// OperationTimeout limits the duration of every repository operation, e.g. a search or saving a track.
+	OperationTimeout time.Duration
+
+	// MaxLyricsImportErrorCount is the number of failed lyrics imports after which a track is no longer synced.
+	MaxLyricsImportErrorCount int
+}
+
+// HostURI returns the connection string of a single MongoDB server listening on the default port.
+func HostURI(host string) string {
+	return fmt.Sprintf("mongodb://%s:27017", host)
+}
+
+func (c Config) databaseName() (string, error) {
+	if c.Database != "" {
+		return c.Database, nil
+	}
+	cs, err := connstring.ParseAndValidate(c.URI)
+	if err != nil {
+		return "", err
+	}
+	if cs.Database != "" {
+		return cs.Database, nil
+	}
+	return DefaultDatabaseName, nil
+}
+
+func (c Config) maxLyricsImportErrorCount() int {
+	if c.MaxLyricsImportErrorCount > 0 {
+		return c.MaxLyricsImportErrorCount
+	}
+	return DefaultMaxLyricsImportErrorCount
+}
+
+func (c Config) clientOptions() (*options.ClientOptions, error) {
+	o := options.Client().ApplyURI(c.URI)
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.Username != "" && (o.Auth == nil || o.Auth.Username == "") {
+		// the driver ignores options like authSource if the uri does not contain a user
+		cs, err := connstring.ParseAndValidate(c.URI)
+		if err != nil {
+			return nil, err
+		}
+		auth := options.Credential{AuthSource: cs.AuthSource}
+		if o.Auth != nil {
+			auth = *o.Auth
+		}
+		auth.Username = c.Username
+		auth.Password = c.Password
+		o.SetAuth(auth)
+	}
+
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read database ca file: %w", err)
+		}
+		tlsConfig := o.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("database ca file %s does not contain any certificate", c.TLSCAFile)
+		}
+		o.SetTLSConfig(tlsConfig)
+	}
+
+	if c.MinPoolSize > 0 {
+		o.SetMinPoolSize(c.MinPoolSize)
+	}
+	if c.MaxPoolSize > 0 {
+		o.SetMaxPoolSize(c.MaxPoolSize)
+	}
+	if c.ConnectTimeout > 0 {
+		o.SetConnectTimeout(c.ConnectTimeout)
+	}
+	if c.ServerSelectionTimeout > 0 {
+		o.SetServerSelectionTimeout(c.ServerSelectionTimeout)
+	}
+	return o, nil
+}
@@ -2,23 +2,94 @@ package db
 
 import (
 	"context"
-	"fmt"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"time"
 )
 
 type Repositories struct {
-	Tracks TrackRepository
-	client *mongo.Client
+	Tracks    TrackRepository
+	Playlists PlaylistRepository
+	History   HistoryRepository
+	Webhooks  WebhookRepository
+	client    *mongo.Client
+	database  *mongo.Database
+	// latestMigration is the version of the last embedded migration, see CheckMigrations.
+	latestMigration uint
 }
 
-func New(username, password, databaseName, host string, maxLyricsImportErrorCount int) (*Repositories, error) {
+type settings struct {
+	migrationMode MigrationMode
+	monitors      []*event.CommandMonitor
+}
+
+type Option func(s *settings)
+
+// WithMigrationMode sets how pending migrations are handled while connecting to the database.
+func WithMigrationMode(mode MigrationMode) Option {
+	return func(s *settings) {
+		s.migrationMode = mode
+	}
+}
+
+// WithCommandMonitor registers a monitor that is notified about every command sent to the database. It can be passed
+// multiple times.
+func WithCommandMonitor(monitor *event.CommandMonitor) Option {
+	return func(s *settings) {
+		s.monitors = append(s.monitors, monitor)
+	}
+}
+
+// combineMonitors returns a monitor notifying all given monitors.
+func combineMonitors(monitors []*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
+
+// New connects to the database and applies pending migrations depending on the migration mode.
+func New(c Config, opts ...Option) (*Repositories, error) {
 	ctx := context.Background()
 
-	clientOptions := options.Client().ApplyURI(fmt.Sprintf("mongodb://%s:27017", host)).SetAuth(options.Credential{
-		Username: username,
-		Password: password,
-	})
+	s := settings{
+		migrationMode: MigrationsAuto,
+	}
+	for i := range opts {
+		opts[i](&s)
+	}
+
+	clientOptions, err := c.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	databaseName, err := c.databaseName()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.monitors) > 0 {
+		clientOptions.SetMonitor(combineMonitors(s.monitors))
+	}
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
@@ -29,12 +100,40 @@ func New(username, password, databaseName, host string, maxLyricsImportErrorCoun
 		return nil, err
 	}
 
-	trackRepo, err := NewMongoTrackRepository(client.Database(databaseName), maxLyricsImportErrorCount)
+	database := client.Database(databaseName)
+	if err := migrateDatabase(database, s.migrationMode); err != nil {
+		return nil, err
+	}
+	latest, err := latestMigration()
 	if err != nil {
 		return nil, err
 	}
+
 	return &Repositories{
-		trackRepo,
-		client,
+		Tracks:          NewMongoTrackRepository(database, c.maxLyricsImportErrorCount(), c.OperationTimeout),
+		Playlists:       NewMongoPlaylistRepository(database, c.OperationTimeout),
+		History:         NewMongoHistoryRepository(database, c.OperationTimeout),
+		Webhooks:        NewMongoWebhookRepository(database, c.OperationTimeout),
+		client:          client,
+		database:        database,
+		latestMigration: latest,
 	}, nil
 }
+
+// withTimeout limits the duration of a single repository operation. Without a timeout, only the deadline of ctx applies.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Ping checks whether the database server is reachable.
+func (r *Repositories) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx, readpref.Primary())
+}
+
+// Close disconnects from the database. Operations still in progress are aborted once ctx is done.
+func (r *Repositories) Close(ctx context.Context) error {
+	return r.client.Disconnect(ctx)
+}
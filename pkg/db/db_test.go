@@ -1,9 +1,11 @@
 package db
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"os"
 	"testing"
+	"time"
 )
 
 const (
@@ -14,14 +16,51 @@ const (
 	testDatabaseName = "tests"
 )
 
+func testConfig() Config {
+	return Config{
+		URI:      HostURI(os.Getenv(EnvHost)),
+		Username: os.Getenv(EnvUsername),
+		Password: os.Getenv(EnvPassword),
+		Database: testDatabaseName,
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("sets deadline", func(t *testing.T) {
+		ctx, cancel := withTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("keeps deadline of parent without timeout", func(t *testing.T) {
+		ctx, cancel := withTimeout(context.Background(), 0)
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("cancelled with parent", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := withTimeout(parent, time.Minute)
+		defer cancel()
+
+		cancelParent()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+}
+
 func TestNew__correct_credentials(t *testing.T) {
-	r, err := New(os.Getenv(EnvUsername), os.Getenv(EnvPassword), testDatabaseName, os.Getenv(EnvHost), 3)
+	r, err := New(testConfig())
 	defer tearDown(r)
 	assert.Nil(t, err)
 }
 
 func TestNew__wrong_credentials(t *testing.T) {
-	r, err := New("", "", testDatabaseName, "localhost", 3)
+	r, err := New(Config{URI: HostURI("localhost"), Database: testDatabaseName})
 	defer tearDown(r)
 
 	assert.Error(t, err, "should return error if database credentials are wrong")
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"strconv"
+	"time"
+)
+
+const (
+	ExplicitInclude = ""
+	ExplicitExclude = "exclude"
+	ExplicitOnly    = "only"
+)
+
+// TrackFilter narrows down the tracks returned by a search. Zero values do not restrict the result.
+type TrackFilter struct {
+	// Explicit is one of ExplicitInclude, ExplicitExclude or ExplicitOnly.
+	Explicit string
+	ArtistID string
+	// ReleasedFrom and ReleasedTo are inclusive years.
+	ReleasedFrom  int
+	ReleasedTo    int
+	AddedAfter    time.Time
+	AddedBefore   time.Time
+	MinPopularity int
+	PlaylistID    string
+	// HideOrphans excludes tracks that have been removed from the library and all imported playlists.
+	HideOrphans bool
+}
+
+// IsZero reports whether the filter does not restrict the result at all.
+func (f TrackFilter) IsZero() bool {
+	return f == TrackFilter{}
+}
+
+func (f TrackFilter) query() bson.M {
+	q := bson.M{}
+
+	switch f.Explicit {
+	case ExplicitExclude:
+		q["explicit"] = bson.M{"$ne": true}
+	case ExplicitOnly:
+		q["explicit"] = true
+	}
+
+	if f.ArtistID != "" {
+		q["artist_ids"] = f.ArtistID
+	}
+
+	// release dates are stored as "YYYY", "YYYY-MM" or "YYYY-MM-DD", so they can be compared as strings
+	releaseDate := bson.M{}
+	if f.ReleasedFrom > 0 {
+		releaseDate["$gte"] = strconv.Itoa(f.ReleasedFrom)
+	}
+	if f.ReleasedTo > 0 {
+		releaseDate["$lt"] = strconv.Itoa(f.ReleasedTo + 1)
+	}
+	if len(releaseDate) > 0 {
+		q["release_date"] = releaseDate
+	}
+
+	addedAt := bson.M{}
+	if !f.AddedAfter.IsZero() {
+		addedAt["$gte"] = f.AddedAfter
+	}
+	if !f.AddedBefore.IsZero() {
+		addedAt["$lt"] = f.AddedBefore
+	}
+	if len(addedAt) > 0 {
+		q["added_at"] = addedAt
+	}
+
+	if f.MinPopularity > 0 {
+		q["popularity"] = bson.M{"$gte": f.MinPopularity}
+	}
+
+	if f.PlaylistID != "" {
+		q["playlist_ids"] = f.PlaylistID
+	}
+
+	if f.HideOrphans {
+		q["orphaned_at"] = nil
+	}
+
+	return q
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestTrackFilter_query(t *testing.T) {
+	addedAfter := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter TrackFilter
+		want   bson.M
+	}{
+		{"empty", TrackFilter{}, bson.M{}},
+		{"exclude explicit", TrackFilter{Explicit: ExplicitExclude}, bson.M{"explicit": bson.M{"$ne": true}}},
+		{"only explicit", TrackFilter{Explicit: ExplicitOnly}, bson.M{"explicit": true}},
+		{"artist", TrackFilter{ArtistID: "abc"}, bson.M{"artist_ids": "abc"}},
+		{
+			"release years",
+			TrackFilter{ReleasedFrom: 1990, ReleasedTo: 1999},
+			bson.M{"release_date": bson.M{"$gte": "1990", "$lt": "2000"}},
+		},
+		{"added after", TrackFilter{AddedAfter: addedAfter}, bson.M{"added_at": bson.M{"$gte": addedAfter}}},
+		{"playlist", TrackFilter{PlaylistID: "abc"}, bson.M{"playlist_ids": "abc"}},
+		{"hide orphans", TrackFilter{HideOrphans: true}, bson.M{"orphaned_at": nil}},
+		{"popularity", TrackFilter{MinPopularity: 50}, bson.M{"popularity": bson.M{"$gte": 50}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.query())
+			assert.Equal(t, tt.name == "empty", tt.filter.IsZero())
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Index is an index declared by the embedded migration files.
+type Index struct {
+	Collection string
+	Name       string
+	Definition bson.D
+}
+
+// declaredIndexes replays the up migrations and returns all indexes that should exist after applying them.
+func declaredIndexes() ([]Index, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.up.json")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var indexes []Index
+	for _, file := range files {
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var cmds []bson.D
+		if err := bson.UnmarshalExtJSON(content, true, &cmds); err != nil {
+			return nil, err
+		}
+
+		for _, cmd := range cmds {
+			m := cmd.Map()
+			if collection, ok := m["createIndexes"].(string); ok {
+				definitions, _ := m["indexes"].(bson.A)
+				for _, definition := range definitions {
+					d, ok := definition.(bson.D)
+					if !ok {
+						continue
+					}
+					name, _ := d.Map()["name"].(string)
+					indexes = append(indexes, Index{Collection: collection, Name: name, Definition: d})
+				}
+			}
+			if collection, ok := m["dropIndexes"].(string); ok {
+				name, _ := m["index"].(string)
+				indexes = removeIndex(indexes, collection, name)
+			}
+		}
+	}
+
+	return indexes, nil
+}
+
+func removeIndex(indexes []Index, collection, name string) []Index {
+	var remaining []Index
+	for _, index := range indexes {
+		if index.Collection != collection || (name != "*" && index.Name != name) {
+			remaining = append(remaining, index)
+		}
+	}
+	return remaining
+}
+
+// MissingIndexes compares the indexes of the database with the indexes declared by the migration files and returns
+// those that do not exist.
+func (r *Repositories) MissingIndexes(ctx context.Context) ([]Index, error) {
+	declared, err := declaredIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[string]bool)
+	existing := make(map[string]struct{})
+	for _, index := range declared {
+		if listed[index.Collection] {
+			continue
+		}
+		listed[index.Collection] = true
+
+		specs, err := r.database.Collection(index.Collection).Indexes().ListSpecifications(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			existing[Index{Collection: index.Collection, Name: spec.Name}.String()] = struct{}{}
+		}
+	}
+
+	var missing []Index
+	for _, index := range declared {
+		if _, ok := existing[index.String()]; !ok {
+			missing = append(missing, index)
+		}
+	}
+	return missing, nil
+}
+
+// CreateIndexes creates the given indexes using their definitions from the migration files.
+func (r *Repositories) CreateIndexes(ctx context.Context, indexes []Index) error {
+	for _, index := range indexes {
+		cmd := bson.D{
+			{Key: "createIndexes", Value: index.Collection},
+			{Key: "indexes", Value: bson.A{index.Definition}},
+		}
+		if err := r.database.RunCommand(ctx, cmd).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i Index) String() string {
+	return strings.Join([]string{i.Collection, i.Name}, ".")
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDeclaredIndexes(t *testing.T) {
+	indexes, err := declaredIndexes()
+
+	assert.Nil(t, err)
+	assert.Len(t, indexes, 10)
+
+	names := make([]string, len(indexes))
+	for i := range indexes {
+		names[i] = indexes[i].String()
+	}
+	assert.ElementsMatch(t, []string{
+		"tracks.fulltext_index",
+		"tracks.spotify_id_index",
+		"tracks.group_id_index",
+		"tracks.artist_ids_index",
+		"tracks.playlist_ids_index",
+		"tracks.orphaned_at_index",
+		"playlists.playlist_spotify_id_index",
+		"history.history_play_index",
+		"history.history_played_at_index",
+		"webhook_deliveries.webhook_deliveries_index",
+	}, names)
+}
+
+func TestRemoveIndex(t *testing.T) {
+	indexes := []Index{
+		{Collection: "tracks", Name: "a"},
+		{Collection: "tracks", Name: "b"},
+		{Collection: "users", Name: "a"},
+	}
+
+	assert.Equal(t, []Index{{Collection: "tracks", Name: "b"}, {Collection: "users", Name: "a"}}, removeIndex(indexes, "tracks", "a"))
+	assert.Equal(t, []Index{{Collection: "users", Name: "a"}}, removeIndex(indexes, "tracks", "*"))
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns an opaque cursor pointing to the track with the given ID.
+func encodeCursor(id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString(id[:])
+}
+
+func decodeCursor(cursor string) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(b) != len(id) {
+		return id, ErrInvalidCursor
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// TrackPage returns the page of tracks following the cursor and the cursor of the next page. The first page is
+// requested using an empty cursor. The cursor of the last page is empty.
+type TrackPage func(ctx context.Context, cursor string) ([]*Track, string, error)
+
+// TrackIterator streams tracks page by page, so only a single page is held in memory. Pages are loaded using keyset
+// pagination, so no cursor is kept open on the server while the tracks are processed.
+type TrackIterator struct {
+	page   TrackPage
+	cursor string
+	last   bool
+
+	tracks  []*Track
+	current *Track
+	err     error
+}
+
+func NewTrackIterator(page TrackPage) *TrackIterator {
+	return &TrackIterator{
+		page: page,
+	}
+}
+
+// Next advances to the next track, loading the next page if required. It returns false once all tracks have been
+// visited or loading a page failed.
+func (it *TrackIterator) Next(ctx context.Context) bool {
+	for len(it.tracks) == 0 {
+		if it.last || it.err != nil {
+			it.current = nil
+			return false
+		}
+		it.tracks, it.cursor, it.err = it.page(ctx, it.cursor)
+		it.last = it.cursor == ""
+		if it.err != nil {
+			it.tracks = nil
+		}
+	}
+
+	it.current, it.tracks = it.tracks[0], it.tracks[1:]
+	return true
+}
+
+// Track returns the current track.
+func (it *TrackIterator) Track() *Track {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TrackIterator) Err() error {
+	return it.err
+}
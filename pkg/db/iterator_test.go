@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		id := primitive.NewObjectID()
+
+		decoded, err := decodeCursor(encodeCursor(id))
+
+		assert.Nil(t, err)
+		assert.Equal(t, id, decoded)
+	})
+
+	t.Run("invalid cursors", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", "YWJj"} {
+			_, err := decodeCursor(cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+		}
+	})
+}
+
+func TestTrackIterator(t *testing.T) {
+	t.Run("visits all pages", func(t *testing.T) {
+		a, b, c := &Track{SpotifyID: "a"}, &Track{SpotifyID: "b"}, &Track{SpotifyID: "c"}
+		var cursors []string
+		it := NewTrackIterator(func(ctx context.Context, cursor string) ([]*Track, string, error) {
+			cursors = append(cursors, cursor)
+			switch cursor {
+			case "":
+				return []*Track{a, b}, "2", nil
+			case "2":
+				return []*Track{}, "3", nil
+			default:
+				return []*Track{c}, "", nil
+			}
+		})
+
+		var visited []*Track
+		for it.Next(context.Background()) {
+			visited = append(visited, it.Track())
+		}
+
+		assert.Nil(t, it.Err())
+		assert.Equal(t, []*Track{a, b, c}, visited)
+		assert.Equal(t, []string{"", "2", "3"}, cursors)
+		assert.False(t, it.Next(context.Background()), "should not load pages after the last one")
+		assert.Len(t, cursors, 3)
+	})
+
+	t.Run("stops on errors", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		it := NewTrackIterator(func(ctx context.Context, cursor string) ([]*Track, string, error) {
+			if cursor == "" {
+				return []*Track{{}}, "1", nil
+			}
+			return nil, "", expectedErr
+		})
+
+		assert.True(t, it.Next(context.Background()))
+		assert.False(t, it.Next(context.Background()))
+		assert.Nil(t, it.Track())
+		assert.ErrorIs(t, it.Err(), expectedErr)
+	})
+}
@@ -1,40 +1,216 @@
 package db
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/mongodb"
 	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
+	"os"
 )
 
-const TrackCollection = "tracks"
+const (
+	TrackCollection = "tracks"
+	// ImportCollection stores the state of imports, e.g. how far the library has been imported.
+	ImportCollection = "imports"
+)
 
 //go:embed migrations
 var migrationFiles embed.FS
 
-func migrateDatabase(db *mongo.Database) error {
+var (
+	ErrPendingMigrations = errors.New("database schema is not up to date, run `spolyr migrate up` first")
+	ErrDirtyMigration    = errors.New("last migration failed and left the database in a dirty state, fix it and run `spolyr migrate force VERSION`")
+)
+
+// MigrationStatus describes the schema version of a database with regard to the embedded migration files.
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	// Applied is false if no migration has been applied at all.
+	Applied   bool
+	Available []uint
+	Pending   []uint
+}
+
+// Migrator manages the schema version of a database using the embedded migration files.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+func newMigrator(db *mongo.Database) (*Migrator, error) {
 	driver, err := mongodb.WithInstance(db.Client(), &mongodb.Config{
 		DatabaseName: db.Name(),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	source, err := httpfs.New(http.FS(migrationFiles), "migrations")
+	src, err := migrationSource()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	m, err := migrate.NewWithInstance("httpfs", source, db.Name(), driver)
+	m, err := migrate.NewWithInstance("httpfs", src, db.Name(), driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{m: m, source: src}, nil
+}
+
+func migrationSource() (source.Driver, error) {
+	return httpfs.New(http.FS(migrationFiles), "migrations")
+}
+
+// latestMigration returns the version of the last embedded migration.
+func latestMigration() (uint, error) {
+	src, err := migrationSource()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	var latest uint
+	v, err := src.First()
+	for err == nil {
+		latest = v
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return latest, nil
+}
+
+// Status returns the current schema version and all migrations that have not been applied yet.
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var s MigrationStatus
+
+	version, dirty, err := m.m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return s, err
+	}
+	s.Version, s.Dirty, s.Applied = version, dirty, err == nil
+
+	v, err := m.source.First()
+	for err == nil {
+		s.Available = append(s.Available, v)
+		if !s.Applied || v > s.Version {
+			s.Pending = append(s.Pending, v)
+		}
+		v, err = m.source.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Check returns ErrDirtyMigration or ErrPendingMigrations if the schema is not up to date.
+func (m *Migrator) Check() error {
+	s, err := m.Status()
 	if err != nil {
 		return err
 	}
-	err = m.Up()
-	if err != nil && err != migrate.ErrNoChange {
+	if s.Dirty {
+		return ErrDirtyMigration
+	}
+	if len(s.Pending) > 0 {
+		return ErrPendingMigrations
+	}
+	return nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back the last n migrations.
+func (m *Migrator) Down(n int) error {
+	return ignoreNoChange(m.m.Steps(-n))
+}
+
+// Goto migrates up or down to the given version.
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force sets the schema version without running any migration and clears the dirty flag.
+// A version of -1 marks the database as not migrated at all.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func ignoreNoChange(err error) error {
+	if err == migrate.ErrNoChange {
+		return nil
+	}
+	return err
+}
+
+// Migrator returns a Migrator for the database of the repositories.
+func (r *Repositories) Migrator() (*Migrator, error) {
+	return newMigrator(r.database)
+}
+
+// CheckMigrations returns ErrDirtyMigration or ErrPendingMigrations if the schema of the database is not up to date.
+// Unlike Migrator.Check, it reads the schema version directly, so it is cheap enough to be called by every readiness
+// probe and stops once ctx is done.
+func (r *Repositories) CheckMigrations(ctx context.Context) error {
+	var v struct {
+		Version int  `bson:"version"`
+		Dirty   bool `bson:"dirty"`
+	}
+	err := r.database.Collection(mongodb.DefaultMigrationsCollection).FindOne(ctx, bson.M{}).Decode(&v)
+	if err == mongo.ErrNoDocuments {
+		v.Version = -1
+	} else if err != nil {
 		return err
 	}
+
+	if v.Dirty {
+		return ErrDirtyMigration
+	}
+	if v.Version < 0 || uint(v.Version) < r.latestMigration {
+		return ErrPendingMigrations
+	}
 	return nil
 }
+
+// MigrationMode controls how New deals with pending migrations.
+type MigrationMode int
+
+const (
+	// MigrationsAuto applies pending migrations on startup.
+	MigrationsAuto MigrationMode = iota
+	// MigrationsRequired refuses to start if there are pending migrations.
+	MigrationsRequired
+	// MigrationsManual does not check the schema version at all.
+	MigrationsManual
+)
+
+func migrateDatabase(db *mongo.Database, mode MigrationMode) error {
+	if mode == MigrationsManual {
+		return nil
+	}
+
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if mode == MigrationsAuto {
+		return m.Up()
+	}
+
+	return m.Check()
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const HistoryCollection = "history"
+
+type HistoryRepository interface {
+	SavePlays(ctx context.Context, plays []Play) (int, error)
+	LatestPlayedAt(ctx context.Context) (time.Time, error)
+	RecentPlays(ctx context.Context, page, limit int, withLyrics bool) ([]PlayedTrack, int, error)
+	PlayCounts(ctx context.Context, page, limit int) ([]PlayCount, int, error)
+}
+
+type MongoHistoryRepository struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+// SavePlays stores the given play events and returns the number of events that have not been stored before.
+func (r MongoHistoryRepository) SavePlays(ctx context.Context, plays []Play) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	opts := options.Update().SetUpsert(true)
+
+	n := 0
+	for _, p := range plays {
+		filter := bson.M{"spotify_id": p.SpotifyID, "played_at": p.PlayedAt}
+		update := bson.M{"$setOnInsert": bson.M{"spotify_id": p.SpotifyID, "played_at": p.PlayedAt}}
+		res, err := r.db.Collection(HistoryCollection).UpdateOne(ctx, filter, update, opts)
+		if err != nil {
+			return n, err
+		}
+		n += int(res.UpsertedCount)
+	}
+	return n, nil
+}
+
+// LatestPlayedAt returns the time of the most recent play event. It is zero if the history has not been imported yet.
+func (r MongoHistoryRepository) LatestPlayedAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	var p Play
+	opts := options.FindOne().SetSort(bson.M{"played_at": -1})
+	err := r.db.Collection(HistoryCollection).FindOne(ctx, bson.M{}, opts).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	return p.PlayedAt, err
+}
+
+// RecentPlays returns the play events joined with their tracks, most recent first.
+// If withLyrics is set, only plays of tracks with lyrics are returned.
+func (r MongoHistoryRepository) RecentPlays(ctx context.Context, page, limit int, withLyrics bool) ([]PlayedTrack, int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	pipeline := bson.A{
+		bson.M{"$lookup": bson.M{"from": TrackCollection, "localField": "spotify_id", "foreignField": "spotify_id", "as": "track"}},
+		bson.M{"$unwind": "$track"},
+	}
+	if withLyrics {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"track.loaded": true}})
+	}
+
+	var plays []PlayedTrack
+	total, err := r.aggregatePage(ctx, pipeline, bson.M{"played_at": -1}, page, limit, &plays)
+	return plays, total, err
+}
+
+// PlayCounts returns the number of plays per track, most played first.
+func (r MongoHistoryRepository) PlayCounts(ctx context.Context, page, limit int) ([]PlayCount, int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": "$spotify_id", "count": bson.M{"$sum": 1}, "last_played_at": bson.M{"$max": "$played_at"}}},
+		bson.M{"$lookup": bson.M{"from": TrackCollection, "localField": "_id", "foreignField": "spotify_id", "as": "track"}},
+		bson.M{"$unwind": "$track"},
+	}
+
+	var counts []PlayCount
+	total, err := r.aggregatePage(ctx, pipeline, bson.D{{Key: "count", Value: -1}, {Key: "last_played_at", Value: -1}}, page, limit, &counts)
+	return counts, total, err
+}
+
+func (r MongoHistoryRepository) aggregatePage(ctx context.Context, pipeline bson.A, sort interface{}, page, limit int, results interface{}) (int, error) {
+
+	c, err := r.db.Collection(HistoryCollection).Aggregate(ctx, append(pipeline, bson.M{"$count": "total"}))
+	if err != nil {
+		return 0, err
+	}
+	var count []struct {
+		Total int `bson:"total"`
+	}
+	if err := c.All(ctx, &count); err != nil {
+		return 0, err
+	}
+	if len(count) == 0 {
+		return 0, nil
+	}
+
+	c, err = r.db.Collection(HistoryCollection).Aggregate(ctx, append(pipeline,
+		bson.M{"$sort": sort},
+		bson.M{"$skip": (page - 1) * limit},
+		bson.M{"$limit": limit},
+	))
+	if err != nil {
+		return 0, err
+	}
+	return count[0].Total, c.All(ctx, results)
+}
+
+func NewMongoHistoryRepository(db *mongo.Database, timeout time.Duration) MongoHistoryRepository {
+	return MongoHistoryRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
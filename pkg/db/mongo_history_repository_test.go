@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestHistoryRepository_SavePlays(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	playedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+
+	latest, err := repos.History.LatestPlayedAt(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, latest.IsZero())
+
+	n, err := repos.History.SavePlays(context.Background(), []Play{{SpotifyID: "1", PlayedAt: playedAt}, {SpotifyID: "2", PlayedAt: playedAt.Add(-time.Hour)}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = repos.History.SavePlays(context.Background(), []Play{{SpotifyID: "1", PlayedAt: playedAt}, {SpotifyID: "1", PlayedAt: playedAt.Add(-2 * time.Hour)}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n, "should not record the same play twice")
+
+	latest, err = repos.History.LatestPlayedAt(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, playedAt.Equal(latest))
+}
+
+func TestHistoryRepository_RecentPlays(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	playedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Lyrics: "la la la", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.History.SavePlays(context.Background(), []Play{
+		{SpotifyID: "1", PlayedAt: playedAt},
+		{SpotifyID: "2", PlayedAt: playedAt.Add(time.Hour)},
+		{SpotifyID: "1", PlayedAt: playedAt.Add(-time.Hour)},
+	})
+
+	plays, total, err := repos.History.RecentPlays(context.Background(), 1, 10, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, "2", plays[0].Track.SpotifyID, "should return the most recent play first")
+
+	plays, total, err = repos.History.RecentPlays(context.Background(), 1, 10, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "la la la", plays[0].Track.Lyrics)
+
+	counts, total, err := repos.History.PlayCounts(context.Background(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "1", counts[0].Track.SpotifyID, "should return the most played track first")
+	assert.Equal(t, 2, counts[0].Count)
+	assert.True(t, playedAt.Equal(counts[0].LastPlayedAt))
+}
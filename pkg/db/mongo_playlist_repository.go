@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const PlaylistCollection = "playlists"
+
+var ErrPlaylistNotFound = errors.New("playlist not found")
+
+type PlaylistRepository interface {
+	FindPlaylist(ctx context.Context, spotifyID string) (*Playlist, error)
+	Playlists(ctx context.Context, page, limit int) ([]*Playlist, int, error)
+	SavePlaylist(ctx context.Context, playlist *Playlist) error
+}
+
+type MongoPlaylistRepository struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+func (r MongoPlaylistRepository) FindPlaylist(ctx context.Context, spotifyID string) (*Playlist, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	var p Playlist
+	err := r.db.Collection(PlaylistCollection).FindOne(ctx, bson.M{"spotify_id": spotifyID}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPlaylistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Playlists returns the imported playlists ordered by name.
+func (r MongoPlaylistRepository) Playlists(ctx context.Context, page, limit int) ([]*Playlist, int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	total, err := r.db.Collection(PlaylistCollection).CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+	c, err := r.db.Collection(PlaylistCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var playlists []*Playlist
+	err = c.All(ctx, &playlists)
+	return playlists, int(total), err
+}
+
+func (r MongoPlaylistRepository) SavePlaylist(ctx context.Context, playlist *Playlist) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	filter := bson.M{"spotify_id": playlist.SpotifyID}
+	update := bson.M{"$set": bson.M{
+		"spotify_id":       playlist.SpotifyID,
+		"name":             playlist.Name,
+		"owner":            playlist.Owner,
+		"snapshot_id":      playlist.SnapshotID,
+		"image_url":        playlist.ImageURL,
+		"track_count":      playlist.TrackCount,
+		"is_public":        playlist.IsPublic,
+		"is_collaborative": playlist.IsCollaborative,
+		"imported_at":      playlist.ImportedAt,
+	}}
+	opts := options.Update().SetUpsert(true)
+	_, err := r.db.Collection(PlaylistCollection).UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+func NewMongoPlaylistRepository(db *mongo.Database, timeout time.Duration) MongoPlaylistRepository {
+	return MongoPlaylistRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
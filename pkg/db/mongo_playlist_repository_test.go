@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlaylistRepository_SavePlaylist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	assert.Nil(t, repos.Playlists.SavePlaylist(context.Background(), &Playlist{SpotifyID: "1", Name: "B", SnapshotID: "a"}))
+	assert.Nil(t, repos.Playlists.SavePlaylist(context.Background(), &Playlist{SpotifyID: "2", Name: "A"}))
+	assert.Nil(t, repos.Playlists.SavePlaylist(context.Background(), &Playlist{SpotifyID: "1", Name: "B", SnapshotID: "b"}))
+
+	p, err := repos.Playlists.FindPlaylist(context.Background(), "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", p.SnapshotID)
+
+	playlists, total, err := repos.Playlists.Playlists(context.Background(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "2", playlists[0].SpotifyID, "should order playlists by name")
+
+	_, err = repos.Playlists.FindPlaylist(context.Background(), "3")
+	assert.Equal(t, ErrPlaylistNotFound, err)
+}
+
+func TestTrackRepository_SetPlaylistTracks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
+
+	assert.Nil(t, repos.Tracks.SetPlaylistTracks(context.Background(), "a", []string{"1", "2"}))
+	assert.Nil(t, repos.Tracks.SetPlaylistTracks(context.Background(), "b", []string{"2"}))
+	assert.Nil(t, repos.Tracks.SetPlaylistTracks(context.Background(), "a", []string{"2", "3"}))
+
+	tracks, n, err := repos.Tracks.Search(context.Background(), "", TrackFilter{PlaylistID: "a"}, 1, 10, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "3", tracks[0].SpotifyID)
+	assert.Equal(t, "2", tracks[1].SpotifyID)
+	assert.ElementsMatch(t, []string{"a", "b"}, tracks[1].PlaylistIDs)
+
+	track, _ := repos.Tracks.FindTrack(context.Background(), "1")
+	assert.Empty(t, track.PlaylistIDs)
+}
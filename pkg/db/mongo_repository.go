@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 var (
@@ -15,25 +16,65 @@ var (
 )
 
 type TrackRepository interface {
-	FindTrack(string) (*Track, error)
-	LatestTracks(limit int64) ([]*Track, error)
-	TracksWithoutLyricsError() ([]*Track, error)
-	AllTracks(page, limit int) ([]*Track, int, error)
-	Search(query string, page, limit int, language string) ([]*Track, int, error)
-	Save(track *Track) error
+	FindTrack(ctx context.Context, spotifyID string) (*Track, error)
+	LatestTracks(ctx context.Context, limit int64) ([]*Track, error)
+	TracksWithoutLyricsError() *TrackIterator
+	AllTracks(ctx context.Context, page, limit int) ([]*Track, int, error)
+	IterateTracks() *TrackIterator
+	TracksAfter(ctx context.Context, filter TrackFilter, cursor string, limit int) ([]*Track, string, int, error)
+	Search(ctx context.Context, query string, filter TrackFilter, page, limit int, language string) ([]*Track, int, error)
+	Save(ctx context.Context, track *Track) error
+	SaveMany(ctx context.Context, tracks []*Track) ([]SaveResult, error)
+	ResetLyrics(ctx context.Context, track *Track) error
+	LatestAddedAt(ctx context.Context) (time.Time, error)
+	LibraryWatermark(ctx context.Context) (time.Time, error)
+	SetLibraryWatermark(ctx context.Context, watermark time.Time) error
+
+	FindGroup(ctx context.Context, groupID string) ([]*Track, error)
+	Groups(ctx context.Context, page, limit int) ([]TrackGroup, int, error)
+	SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error
+
+	SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error
+
+	Orphans(ctx context.Context, page, limit int) ([]*Track, int, error)
+	SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error
+	DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error)
+	DeleteOrphan(ctx context.Context, spotifyID string) error
+
+	ReviewTracks(ctx context.Context, page, limit int) ([]*Track, int, error)
+	SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error
+
+	Count(ctx context.Context) (int64, error)
+	CountWithLyrics(ctx context.Context) (int64, error)
+	CountWithLyricsError(ctx context.Context) (int64, error)
+	CountInstrumental(ctx context.Context) (int64, error)
+	CountGroupsWithoutLyricsError(ctx context.Context) (int64, error)
+}
+
+// iteratorPageSize is the number of tracks loaded at once by a TrackIterator.
+const iteratorPageSize = 500
+
+// SaveResult is the outcome of saving a single track using SaveMany.
+type SaveResult struct {
+	// Inserted is set if the track has not been stored before.
+	Inserted bool
+	Err      error
+}
 
-	Count() (int64, error)
-	CountWithLyrics() (int64, error)
+// TrackGroup is a set of tracks that are considered to be the same song.
+type TrackGroup struct {
+	ID     string   `bson:"_id"`
+	Tracks []*Track `bson:"tracks"`
 }
 
 type MongoTrackRepository struct {
 	maxLyricsImportError int
 	db                   *mongo.Database
+	timeout              time.Duration
 }
 
-func decodeTracks(cur *mongo.Cursor) ([]*Track, error) {
+func decodeTracks(ctx context.Context, cur *mongo.Cursor) ([]*Track, error) {
 	var tracks []*Track
-	ctx := context.Background()
 	for cur.Next(ctx) {
 		var t Track
 		err := cur.Decode(&t)
@@ -48,109 +89,295 @@ func decodeTracks(cur *mongo.Cursor) ([]*Track, error) {
 		return tracks, err
 	}
 
-	err := cur.Close(context.Background())
+	err := cur.Close(ctx)
 	return tracks, err
 }
 
-func (r MongoTrackRepository) findOneByQuery(filter interface{}, o ...*options.FindOneOptions) (*Track, error) {
+func (r MongoTrackRepository) findOneByQuery(ctx context.Context, filter interface{}, o ...*options.FindOneOptions) (*Track, error) {
 	var t Track
-	err := r.db.Collection(TrackCollection).FindOne(context.Background(), filter, o...).Decode(&t)
+	err := r.db.Collection(TrackCollection).FindOne(ctx, filter, o...).Decode(&t)
 	if err != nil {
 		return nil, ErrTrackNotFound
 	}
 	return &t, nil
 }
-func (r MongoTrackRepository) findByQuery(filter interface{}, o ...*options.FindOptions) ([]*Track, error) {
-	c, err := r.db.Collection(TrackCollection).Find(context.Background(), filter, o...)
+func (r MongoTrackRepository) findByQuery(ctx context.Context, filter interface{}, o ...*options.FindOptions) ([]*Track, error) {
+	c, err := r.db.Collection(TrackCollection).Find(ctx, filter, o...)
 	if err != nil {
 		return nil, ErrTracksNotFound
 	}
-	return decodeTracks(c)
+	return decodeTracks(ctx, c)
+}
+
+func (r MongoTrackRepository) count(ctx context.Context, filter interface{}) (int64, error) {
+	return r.db.Collection(TrackCollection).CountDocuments(ctx, filter)
+}
+
+// keysetPage returns up to limit tracks matching the filter ordered by their ID, starting after the track the cursor
+// points to. Unlike skipping documents, this is fast on every page. The returned cursor is empty on the last page.
+func (r MongoTrackRepository) keysetPage(ctx context.Context, filter bson.M, cursor string, limit int, newestFirst bool) ([]*Track, string, error) {
+	op, direction := "$gt", 1
+	if newestFirst {
+		op, direction = "$lt", -1
+	}
+
+	q := bson.M{}
+	for k, v := range filter {
+		q[k] = v
+	}
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		q["_id"] = bson.M{op: after}
+	}
+
+	// one additional track tells whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: direction}}).
+		SetLimit(int64(limit + 1))
+	tracks, err := r.findByQuery(ctx, q, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tracks) <= limit {
+		return tracks, "", nil
+	}
+	tracks = tracks[:limit]
+	return tracks, encodeCursor(tracks[limit-1].ID), nil
 }
 
-func (r MongoTrackRepository) count(filter interface{}) (int64, error) {
-	return r.db.Collection(TrackCollection).CountDocuments(context.Background(), filter)
+// iterate streams the tracks matching the filter in the order they have been added.
+func (r MongoTrackRepository) iterate(filter bson.M) *TrackIterator {
+	return NewTrackIterator(func(ctx context.Context, cursor string) ([]*Track, string, error) {
+		ctx, cancel := withTimeout(ctx, r.timeout)
+		defer cancel()
+		return r.keysetPage(ctx, filter, cursor, iteratorPageSize, false)
+	})
 }
 
-func (t MongoTrackRepository) FindTrack(spotifyID string) (*Track, error) {
+func (t MongoTrackRepository) FindTrack(ctx context.Context, spotifyID string) (*Track, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	filter := bson.D{primitive.E{Key: "spotify_id", Value: spotifyID}}
 
-	return t.findOneByQuery(filter)
+	return t.findOneByQuery(ctx, filter)
 }
 
-func (t MongoTrackRepository) TracksWithoutLyrics() ([]*Track, error) {
+func (t MongoTrackRepository) TracksWithoutLyrics(ctx context.Context) ([]*Track, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	filter := bson.M{"loaded": bson.M{"$ne": true}}
-	return t.findByQuery(filter)
+	return t.findByQuery(ctx, filter)
 }
 
-func (t MongoTrackRepository) TracksWithoutLyricsError() ([]*Track, error) {
-	filter := bson.M{"loaded": bson.M{"$ne": true}, "lyrics_import_error_count": bson.M{"$lt": t.maxLyricsImportError}}
-	return t.findByQuery(filter)
+// TracksWithoutLyricsError streams the tracks whose lyrics have not been fetched yet and are still retried. Tracks
+// whose lyrics wait for a review and instrumental tracks are skipped.
+func (t MongoTrackRepository) TracksWithoutLyricsError() *TrackIterator {
+	return t.iterate(t.withoutLyricsErrorFilter())
 }
 
-func (t MongoTrackRepository) TracksWithLyricsError() ([]*Track, error) {
-	filter := bson.M{"lyrics_import_error_count": bson.M{"$gte": t.maxLyricsImportError}}
-	return t.findByQuery(filter)
+func (t MongoTrackRepository) withoutLyricsErrorFilter() bson.M {
+	return bson.M{
+		"loaded":                    bson.M{"$ne": true},
+		"lyrics_import_error_count": bson.M{"$lt": t.maxLyricsImportError},
+		"lyrics_review":             nil,
+		"instrumental":              bson.M{"$ne": true},
+	}
 }
 
-func (t MongoTrackRepository) CountWithoutLyrics() (int64, error) {
-	filter := bson.M{"loaded": bson.M{"$ne": true}}
-	return t.count(filter)
+// CountGroupsWithoutLyricsError counts the tracks returned by TracksWithoutLyricsError. Tracks of the same group are
+// counted once, because their lyrics are only fetched once.
+func (t MongoTrackRepository) CountGroupsWithoutLyricsError(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	c, err := t.db.Collection(TrackCollection).Aggregate(ctx, bson.A{
+		bson.M{"$match": t.withoutLyricsErrorFilter()},
+		bson.M{"$group": bson.M{"_id": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$group_id", ""}}, "$group_id", "$_id"}}}},
+		bson.M{"$count": "total"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var count []struct {
+		Total int64 `bson:"total"`
+	}
+	if err := c.All(ctx, &count); err != nil || len(count) == 0 {
+		return 0, err
+	}
+	return count[0].Total, nil
+}
+
+func (t MongoTrackRepository) TracksWithLyricsError(ctx context.Context) ([]*Track, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{
+		"lyrics_import_error_count": bson.M{"$gte": t.maxLyricsImportError},
+		"instrumental":              bson.M{"$ne": true},
+	}
+	return t.findByQuery(ctx, filter)
+}
+
+// CountWithoutLyrics counts the tracks whose lyrics have not been fetched yet. Instrumental tracks are not counted.
+func (t MongoTrackRepository) CountWithoutLyrics(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"loaded": bson.M{"$ne": true}, "instrumental": bson.M{"$ne": true}}
+	return t.count(ctx, filter)
 }
 
-func (t MongoTrackRepository) CountWithLyrics() (int64, error) {
+func (t MongoTrackRepository) CountWithLyrics(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	filter := bson.M{"loaded": bson.M{"$eq": true}}
-	return t.count(filter)
+	return t.count(ctx, filter)
 }
 
-func (t MongoTrackRepository) Count() (int64, error) {
-	return t.count(bson.M{})
+// CountWithLyricsError counts the tracks whose lyrics are no longer fetched because importing them failed too often.
+// Instrumental tracks are counted by CountInstrumental instead.
+func (t MongoTrackRepository) CountWithLyricsError(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{
+		"lyrics_import_error_count": bson.M{"$gte": t.maxLyricsImportError},
+		"instrumental":              bson.M{"$ne": true},
+	}
+	return t.count(ctx, filter)
+}
+
+// CountInstrumental counts the tracks that have been marked as instrumental.
+func (t MongoTrackRepository) CountInstrumental(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.count(ctx, bson.M{"instrumental": true})
+}
+
+func (t MongoTrackRepository) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.count(ctx, bson.M{})
 }
 
-func (t MongoTrackRepository) LatestTracks(limit int64) ([]*Track, error) {
+func (t MongoTrackRepository) LatestTracks(ctx context.Context, limit int64) ([]*Track, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	opts := options.Find().SetLimit(limit).
 		SetSort(bson.D{{"_id", -1}})
-	return t.findByQuery(bson.D{{}}, opts)
+	return t.findByQuery(ctx, bson.D{{}}, opts)
 }
 
-func (t MongoTrackRepository) AllTracks(page, limit int) ([]*Track, int, error) {
+func (t MongoTrackRepository) AllTracks(ctx context.Context, page, limit int) ([]*Track, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	opts := options.Find().
 		SetLimit(int64(limit)).
 		SetSkip(int64((page - 1) * limit))
 
 	filter := bson.D{}
-	total, err := t.count(filter)
+	total, err := t.count(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	tracks, err := t.findByQuery(filter, opts)
+	tracks, err := t.findByQuery(ctx, filter, opts)
 
 	return tracks, int(total), err
 }
 
-func (t MongoTrackRepository) Search(query string, page, limit int, language string) ([]*Track, int, error) {
+// IterateTracks streams all tracks in the order they have been added.
+func (t MongoTrackRepository) IterateTracks() *TrackIterator {
+	return t.iterate(bson.M{})
+}
+
+// TracksAfter returns a page of the tracks matching the filter, the most recently added tracks first. The first page
+// is requested using an empty cursor, the following pages using the returned cursor, which is empty on the last page.
+func (t MongoTrackRepository) TracksAfter(ctx context.Context, filter TrackFilter, cursor string, limit int) ([]*Track, string, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	q := filter.query()
+	total, err := t.count(ctx, q)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	tracks, next, err := t.keysetPage(ctx, q, cursor, limit, true)
+	return tracks, next, int(total), err
+}
+
+// Search returns the tracks matching the query and the filter. If the query is empty, only the filter is applied
+// and the most recently added tracks are returned first.
+func (t MongoTrackRepository) Search(ctx context.Context, query string, filter TrackFilter, page, limit int, language string) ([]*Track, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
 	opts := options.Find().
 		SetLimit(int64(limit)).
 		SetSkip(int64((page - 1) * limit))
-	filter := bson.M{
-		"$text": bson.M{
+	q := filter.query()
+	if query != "" {
+		q["$text"] = bson.M{
 			"$search":   query,
 			"$language": language,
-		},
+		}
+	} else {
+		opts.SetSort(bson.M{"_id": -1})
 	}
 
-	total, err := t.count(filter)
+	total, err := t.count(ctx, q)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	tracks, err := t.findByQuery(filter, opts)
+	tracks, err := t.findByQuery(ctx, q, opts)
 
 	return tracks, int(total), err
 }
 
-func (t MongoTrackRepository) Save(track *Track) error {
+func (t MongoTrackRepository) Save(ctx context.Context, track *Track) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter, update := trackUpsert(track)
+	return t.save(ctx, filter, update)
+}
+
+// SaveMany saves the tracks using a single bulk write. Unlike a failing Save, a track that cannot be saved does not
+// prevent the other tracks from being saved. The results are in the order of the tracks. The error is only set if
+// the bulk write failed as a whole.
+func (t MongoTrackRepository) SaveMany(ctx context.Context, tracks []*Track) ([]SaveResult, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	results := make([]SaveResult, len(tracks))
+	if len(tracks) == 0 {
+		return results, nil
+	}
+
+	models := make([]mongo.WriteModel, len(tracks))
+	for i, track := range tracks {
+		filter, update := trackUpsert(track)
+		models[i] = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true)
+	}
+
+	// unordered writes continue after a failed track
+	res, err := t.db.Collection(TrackCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			results[writeErr.Index].Err = writeErr
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if res != nil {
+		for i := range res.UpsertedIDs {
+			results[i].Inserted = true
+		}
+	}
+	return results, nil
+}
+
+// trackUpsert returns the filter and the update that store a track, inserting it if it does not exist yet.
+func trackUpsert(track *Track) (bson.D, bson.D) {
 	filter := bson.D{{"spotify_id", track.SpotifyID}}
 	fieldsToUpdate := bson.D{
 		{"spotify_id", track.SpotifyID},
@@ -160,31 +387,333 @@ func (t MongoTrackRepository) Save(track *Track) error {
 		{"preview_url", track.PreviewURL},
 		{"image_url", track.ImageURL},
 		{"lyrics_import_error_count", track.LyricsImportErrorCount},
+		{"artists", track.ArtistNames()},
+		{"artist_ids", track.ArtistIDs},
+		{"duration_ms", track.DurationMs},
+		{"explicit", track.Explicit},
+		{"release_date", track.ReleaseDate},
+		{"popularity", track.Popularity},
 	}
 
+	// loaded lyrics replace lyrics that have been flagged for review
+	unset := bson.M{}
 	if track.Loaded {
 		fieldsToUpdate = append(fieldsToUpdate, bson.E{"lyrics", track.Lyrics}, bson.E{"loaded", track.Loaded})
+		unset["lyrics_review"] = ""
+	} else if track.LyricsReview != nil {
+		fieldsToUpdate = append(fieldsToUpdate, bson.E{Key: "lyrics_review", Value: track.LyricsReview})
 	}
 
 	if track.Language != "" {
 		fieldsToUpdate = append(fieldsToUpdate, bson.E{"language", track.Language})
 	}
 
-	return t.save(filter, bson.D{
-		{"$set", fieldsToUpdate},
+	// instrumental tracks are detected during imports and lyrics syncs, which must not reset a mark set manually
+	if track.Instrumental {
+		fieldsToUpdate = append(fieldsToUpdate, bson.E{Key: "instrumental", Value: true})
+	}
+
+	if len(track.LyricsImportErrors) > 0 {
+		fieldsToUpdate = append(fieldsToUpdate, bson.E{Key: "lyrics_import_errors", Value: track.LyricsImportErrors})
+	}
+
+	if track.ISRC != "" {
+		fieldsToUpdate = append(fieldsToUpdate, bson.E{"isrc", track.ISRC})
+	}
+
+	if !track.AddedAt.IsZero() {
+		fieldsToUpdate = append(fieldsToUpdate, bson.E{"added_at", track.AddedAt})
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: fieldsToUpdate},
+	}
+	if len(track.Sources) > 0 {
+		update = append(update, bson.E{Key: "$addToSet", Value: bson.M{"sources": bson.M{"$each": track.Sources}}})
+	}
+
+	// a track saved in the library or imported explicitly is not orphaned anymore
+	if !track.AddedAt.IsZero() || len(track.Sources) > 0 {
+		unset["orphaned_at"] = ""
+	}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+
+	return filter, update
+}
+
+// LatestAddedAt returns the time the most recently saved track of the library has been added.
+// It is zero if no track of the library has been imported yet.
+func (t MongoTrackRepository) LatestAddedAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	opts := options.FindOne().SetSort(bson.M{"added_at": -1})
+	track, err := t.findOneByQuery(ctx, bson.M{"added_at": bson.M{"$type": "date"}}, opts)
+	if err == ErrTrackNotFound {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return track.AddedAt, nil
+}
+
+// libraryImport is the state of the library import stored in ImportCollection.
+type libraryImport struct {
+	Watermark time.Time `bson:"watermark"`
+}
+
+const libraryImportID = "library"
+
+// LibraryWatermark returns the time up to which the library has been imported completely. Incremental imports stop at
+// tracks added before the watermark. It is zero if no library import has completed yet.
+func (t MongoTrackRepository) LibraryWatermark(ctx context.Context) (time.Time, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	var state libraryImport
+	err := t.db.Collection(ImportCollection).FindOne(ctx, bson.M{"_id": libraryImportID}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return state.Watermark, nil
+}
+
+// SetLibraryWatermark stores the time up to which the library has been imported. It must only be set once an import
+// has saved every track added after the previous watermark.
+func (t MongoTrackRepository) SetLibraryWatermark(ctx context.Context, watermark time.Time) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	opts := options.Update().SetUpsert(true)
+	_, err := t.db.Collection(ImportCollection).UpdateOne(ctx, bson.M{"_id": libraryImportID}, bson.M{"$set": bson.M{"watermark": watermark}}, opts)
+	return err
+}
+
+// ResetLyrics removes the lyrics of a track, so they are imported again during the next sync.
+func (t MongoTrackRepository) ResetLyrics(ctx context.Context, track *Track) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	track.Lyrics = ""
+	track.Loaded = false
+	track.Language = ""
+	track.LyricsReview = nil
+
+	filter := bson.M{"spotify_id": track.SpotifyID}
+	update := bson.M{
+		"$set":   bson.M{"lyrics": "", "loaded": false},
+		"$unset": bson.M{"language": "", "lyrics_review": ""},
+	}
+	_, err := t.db.Collection(TrackCollection).UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (t MongoTrackRepository) FindGroup(ctx context.Context, groupID string) ([]*Track, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"group_id": groupID}
+	opts := options.Find().SetSort(bson.M{"spotify_id": 1})
+	return t.findByQuery(ctx, filter, opts)
+}
+
+func (t MongoTrackRepository) Groups(ctx context.Context, page, limit int) ([]TrackGroup, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	match := bson.M{"$match": bson.M{"group_id": bson.M{"$nin": bson.A{"", nil}}}}
+	group := bson.M{"$group": bson.M{"_id": "$group_id", "tracks": bson.M{"$push": "$$ROOT"}}}
+
+	c, err := t.db.Collection(TrackCollection).Aggregate(ctx, bson.A{match, group, bson.M{"$count": "total"}})
+	if err != nil {
+		return nil, 0, err
+	}
+	var count []struct {
+		Total int `bson:"total"`
+	}
+	if err := c.All(ctx, &count); err != nil {
+		return nil, 0, err
+	}
+	if len(count) == 0 {
+		return nil, 0, nil
+	}
+
+	c, err = t.db.Collection(TrackCollection).Aggregate(ctx, bson.A{
+		match,
+		bson.M{"$sort": bson.M{"spotify_id": 1}},
+		group,
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$skip": (page - 1) * limit},
+		bson.M{"$limit": limit},
 	})
+	if err != nil {
+		return nil, 0, err
+	}
+	var groups []TrackGroup
+	err = c.All(ctx, &groups)
+	return groups, count[0].Total, err
+}
+
+func (t MongoTrackRepository) SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"spotify_id": spotifyID}
+	update := bson.M{"$set": bson.M{"group_id": groupID, "group_locked": locked}}
+	res, err := t.db.Collection(TrackCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTrackNotFound
+	}
+	return nil
+}
+
+// SetPlaylistTracks makes the given tracks the only members of a playlist.
+func (t MongoTrackRepository) SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	if spotifyIDs == nil {
+		spotifyIDs = []string{}
+	}
+
+	_, err := t.db.Collection(TrackCollection).UpdateMany(ctx,
+		bson.M{"spotify_id": bson.M{"$in": spotifyIDs}},
+		bson.M{"$addToSet": bson.M{"playlist_ids": playlistID}, "$unset": bson.M{"orphaned_at": ""}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.db.Collection(TrackCollection).UpdateMany(ctx,
+		bson.M{"playlist_ids": playlistID, "spotify_id": bson.M{"$nin": spotifyIDs}},
+		bson.M{"$pull": bson.M{"playlist_ids": playlistID}},
+	)
+	return err
+}
+
+// Orphans returns the orphaned tracks, the most recently orphaned tracks first.
+func (t MongoTrackRepository) Orphans(ctx context.Context, page, limit int) ([]*Track, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"orphaned_at": bson.M{"$type": "date"}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "orphaned_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+
+	total, err := t.count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracks, err := t.findByQuery(ctx, filter, opts)
+	return tracks, int(total), err
+}
+
+// SetOrphaned marks a track as orphaned. A zero time removes the mark.
+func (t MongoTrackRepository) SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"orphaned_at": orphanedAt}}
+	if orphanedAt.IsZero() {
+		update = bson.M{"$unset": bson.M{"orphaned_at": ""}}
+	}
+
+	res, err := t.db.Collection(TrackCollection).UpdateOne(ctx, bson.M{"spotify_id": spotifyID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTrackNotFound
+	}
+	return nil
+}
+
+// DeleteOrphans deletes all tracks that have been orphaned before the given time and returns their number.
+func (t MongoTrackRepository) DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"orphaned_at": bson.M{"$lt": orphanedBefore}}
+	res, err := t.db.Collection(TrackCollection).DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+// DeleteOrphan deletes a single orphaned track. Tracks that are not orphaned are not deleted.
+func (t MongoTrackRepository) DeleteOrphan(ctx context.Context, spotifyID string) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"spotify_id": spotifyID, "orphaned_at": bson.M{"$type": "date"}}
+	res, err := t.db.Collection(TrackCollection).DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrTrackNotFound
+	}
+	return nil
 }
 
-func (r MongoTrackRepository) save(filter, update interface{}) error {
+// ReviewTracks returns the tracks whose lyrics need to be reviewed, either because importing them failed too often or
+// because the fetched lyrics have been flagged. The most recently added tracks are returned first.
+func (t MongoTrackRepository) ReviewTracks(ctx context.Context, page, limit int) ([]*Track, int, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{
+		"loaded":       bson.M{"$ne": true},
+		"instrumental": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"lyrics_import_error_count": bson.M{"$gte": t.maxLyricsImportError}},
+			bson.M{"lyrics_review": bson.M{"$ne": nil}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+
+	total, err := t.count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracks, err := t.findByQuery(ctx, filter, opts)
+	return tracks, int(total), err
+}
+
+// SetInstrumental marks a track as instrumental, so its lyrics are never fetched, or removes the mark. Lyrics waiting
+// for a review are discarded.
+func (t MongoTrackRepository) SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	filter := bson.M{"spotify_id": spotifyID}
+	update := bson.M{"$set": bson.M{"instrumental": instrumental}}
+	if instrumental {
+		update["$unset"] = bson.M{"lyrics_review": ""}
+	}
+	res, err := t.db.Collection(TrackCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTrackNotFound
+	}
+	return nil
+}
+
+func (r MongoTrackRepository) save(ctx context.Context, filter, update interface{}) error {
 	opts := options.Update().SetUpsert(true)
-	_, err := r.db.Collection(TrackCollection).UpdateOne(context.Background(), filter, update, opts)
+	_, err := r.db.Collection(TrackCollection).UpdateOne(ctx, filter, update, opts)
 	return err
 }
 
-func NewMongoTrackRepository(db *mongo.Database, maxLyricsImportError int) (MongoTrackRepository, error) {
-	err := migrateDatabase(db)
+func NewMongoTrackRepository(db *mongo.Database, maxLyricsImportError int, timeout time.Duration) MongoTrackRepository {
 	return MongoTrackRepository{
 		db:                   db,
 		maxLyricsImportError: maxLyricsImportError,
-	}, err
+		timeout:              timeout,
+	}
 }
@@ -2,14 +2,16 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
-	"os"
+	"go.mongodb.org/mongo-driver/bson"
 	"testing"
+	"time"
 )
 
 func setUp() *Repositories {
-	repos, err := New(os.Getenv(EnvUsername), os.Getenv(EnvPassword), testDatabaseName, os.Getenv(EnvHost), 3)
+	repos, err := New(testConfig())
 	if err != nil {
 		panic(err)
 	}
@@ -47,10 +49,10 @@ func TestMongoTrackStore_Save__inserts_new_document_into_database(t *testing.T)
 	repos := setUp()
 	defer tearDown(repos)
 
-	err := repos.Tracks.Save(&track)
+	err := repos.Tracks.Save(context.Background(), &track)
 	assert.Nil(t, err)
 
-	trackFromDatabase, err := repos.Tracks.FindTrack(track.SpotifyID)
+	trackFromDatabase, err := repos.Tracks.FindTrack(context.Background(), track.SpotifyID)
 	assert.Nil(t, err)
 
 	testCases := []struct {
@@ -73,6 +75,41 @@ func TestMongoTrackStore_Save__inserts_new_document_into_database(t *testing.T)
 	}
 }
 
+func TestTrackRepository_SaveMany(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "known", Name: "old name"}))
+	// sources of this track cannot be extended, because they are not an array
+	_, err := repos.database.Collection(TrackCollection).InsertOne(ctx, bson.M{"spotify_id": "broken", "sources": SourceAlbum})
+	assert.Nil(t, err)
+
+	results, err := repos.Tracks.SaveMany(ctx, []*Track{
+		{SpotifyID: "known", Name: "new name"},
+		{SpotifyID: "broken", Sources: []string{SourceAlbum}},
+		{SpotifyID: "new", Name: "a new track"},
+	})
+
+	assert.Nil(t, err)
+	if assert.Len(t, results, 3) {
+		assert.Equal(t, SaveResult{}, results[0])
+		assert.False(t, results[1].Inserted)
+		assert.Error(t, results[1].Err)
+		assert.Equal(t, SaveResult{Inserted: true}, results[2])
+	}
+
+	known, err := repos.Tracks.FindTrack(ctx, "known")
+	assert.Nil(t, err)
+	assert.Equal(t, "new name", known.Name)
+	_, err = repos.Tracks.FindTrack(ctx, "new")
+	assert.Nil(t, err, "tracks following a failed one should be saved")
+}
+
 func TestTrackRepository_FindTrack(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -81,14 +118,29 @@ func TestTrackRepository_FindTrack(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Loaded: true, Lyrics: "foobar"})
-	repos.Tracks.Save(&Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Loaded: true, Lyrics: "foobar"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
 
-	track, err := repos.Tracks.FindTrack("1")
+	track, err := repos.Tracks.FindTrack(context.Background(), "1")
 	assert.Nil(t, err)
 	assert.Equal(t, track.SpotifyID, "1", "it should load the track spotifyID = '1' from database")
 }
 
+func TestTrackRepository_FindTrack__cancelled_context(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repos.Tracks.FindTrack(ctx, "1")
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestTrackRepository_FindTrack__track_not_found__empty_database(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -97,7 +149,7 @@ func TestTrackRepository_FindTrack__track_not_found__empty_database(t *testing.T
 	repos := setUp()
 	defer tearDown(repos)
 
-	track, err := repos.Tracks.FindTrack("1")
+	track, err := repos.Tracks.FindTrack(context.Background(), "1")
 	assert.Error(t, err)
 	assert.Nil(t, track, "if the track does not exist in the database the primary key should be zero")
 }
@@ -110,10 +162,10 @@ func TestTrackRepository_FindTrack__track_not_found(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "2"})
-	repos.Tracks.Save(&Track{SpotifyID: "3"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
 
-	track, err := repos.Tracks.FindTrack("1")
+	track, err := repos.Tracks.FindTrack(context.Background(), "1")
 	assert.Error(t, err)
 	assert.Nil(t, track, "if the track does not exist in the database the primary key should be zero")
 }
@@ -126,11 +178,11 @@ func TestTrackRepository_Search__by_artist_name__partly(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Artist: "Frank Sinatra"})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Artist: "Dean Martin"})
-	repos.Tracks.Save(&Track{SpotifyID: "3"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Artist: "Frank Sinatra"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Artist: "Dean Martin"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
 
-	tracks, n, err := repos.Tracks.Search("Frank", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "Frank", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, n)
@@ -146,11 +198,11 @@ func TestTrackRepository_Search__by_artist_name__full(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Artist: "Frank Sinatra"})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Artist: "Dean Martin"})
-	repos.Tracks.Save(&Track{SpotifyID: "3"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Artist: "Frank Sinatra"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Artist: "Dean Martin"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
 
-	tracks, n, err := repos.Tracks.Search("Frank Sinatra", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "Frank Sinatra", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
@@ -166,12 +218,12 @@ func TestTrackRepository_Search__by_album_name__partly(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Artist: "Eminem", AlbumName: "The Eminem Show"})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Artist: "Eminem", AlbumName: "Encore"})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Artist: "Eminem", AlbumName: "The Slim Shady LP"})
-	repos.Tracks.Save(&Track{SpotifyID: "4", Artist: "The Bloodhound Gang", AlbumName: "Show us your hits"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Artist: "Eminem", AlbumName: "The Eminem Show"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Artist: "Eminem", AlbumName: "Encore"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Artist: "Eminem", AlbumName: "The Slim Shady LP"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "4", Artist: "The Bloodhound Gang", AlbumName: "Show us your hits"})
 
-	tracks, n, err := repos.Tracks.Search("Show", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "Show", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 2, n)
@@ -188,12 +240,12 @@ func TestTrackRepository_Search__by_album_name(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Artist: "Eminem", AlbumName: "The Eminem Show"})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Artist: "Eminem", AlbumName: "Encore"})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Artist: "Eminem", AlbumName: "The Slim Shady LP"})
-	repos.Tracks.Save(&Track{SpotifyID: "4", Artist: "The Bloodhound Gang", AlbumName: "Show us your hits"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Artist: "Eminem", AlbumName: "The Eminem Show"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Artist: "Eminem", AlbumName: "Encore"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Artist: "Eminem", AlbumName: "The Slim Shady LP"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "4", Artist: "The Bloodhound Gang", AlbumName: "Show us your hits"})
 
-	tracks, _, err := repos.Tracks.Search("Encore", 1, 10, "en")
+	tracks, _, err := repos.Tracks.Search(context.Background(), "Encore", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
@@ -208,11 +260,11 @@ func TestTrackRepository_Search__by_lyrics(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
 
-	tracks, n, err := repos.Tracks.Search("car", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "car", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
@@ -228,11 +280,11 @@ func TestTrackRepository_Search__by_lyrics__multiple_results(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
 
-	tracks, n, err := repos.Tracks.Search("house", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "house", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 2, n)
@@ -247,11 +299,11 @@ func TestTrackRepository_Search__by_lyrics__multiple_query_term(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
 
-	tracks, n, err := repos.Tracks.Search("house money", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "house money", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 2)
@@ -268,11 +320,11 @@ func TestTrackRepository_Search__by_lyrics__multiple_query_term__inclusive_searc
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Name: "A", Lyrics: "house mouse money car", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "B", Lyrics: "house sky school", Loaded: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Name: "C", Lyrics: "fish company tank", Loaded: true})
 
-	tracks, n, err := repos.Tracks.Search("house \"money\"", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "house \"money\"", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
@@ -288,11 +340,11 @@ func TestTrackRepository_Search__by_name(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1", Name: "Without Me"})
-	repos.Tracks.Save(&Track{SpotifyID: "2", Name: "Stan"})
-	repos.Tracks.Save(&Track{SpotifyID: "3", Name: "'Till I Collapse'"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Name: "Without Me"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "Stan"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Name: "'Till I Collapse'"})
 
-	tracks, n, err := repos.Tracks.Search("collapse", 1, 10, "en")
+	tracks, n, err := repos.Tracks.Search(context.Background(), "collapse", TrackFilter{}, 1, 10, "en")
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
@@ -300,6 +352,30 @@ func TestTrackRepository_Search__by_name(t *testing.T) {
 	assert.Equal(t, tracks[0].Name, "'Till I Collapse'", "should find the track A whose title contain the term 'collapse'")
 }
 
+func TestTrackRepository_Search__filter_without_query(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", Artist: "Frank Sinatra", ArtistIDs: []string{"frank"}, ReleaseDate: "1959"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Artist: "Frank Sinatra", ArtistIDs: []string{"frank"}, ReleaseDate: "1966-05", Explicit: true})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Artist: "Dean Martin", ArtistIDs: []string{"dean"}, ReleaseDate: "1966-11-01"})
+
+	tracks, n, err := repos.Tracks.Search(context.Background(), "", TrackFilter{ReleasedFrom: 1960, ReleasedTo: 1966}, 1, 10, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "3", tracks[0].SpotifyID, "should return the latest track first")
+
+	tracks, n, err = repos.Tracks.Search(context.Background(), "", TrackFilter{ArtistID: "frank", Explicit: ExplicitExclude}, 1, 10, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "1", tracks[0].SpotifyID)
+	assert.Equal(t, []string{"Frank Sinatra"}, tracks[0].Artists)
+}
+
 func TestTrackRepository_LatestTracks(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -308,14 +384,305 @@ func TestTrackRepository_LatestTracks(t *testing.T) {
 	repos := setUp()
 	defer tearDown(repos)
 
-	repos.Tracks.Save(&Track{SpotifyID: "1"})
-	repos.Tracks.Save(&Track{SpotifyID: "2"})
-	repos.Tracks.Save(&Track{SpotifyID: "3"})
-	repos.Tracks.Save(&Track{SpotifyID: "4"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "4"})
 
-	tracks, err := repos.Tracks.LatestTracks(1)
+	tracks, err := repos.Tracks.LatestTracks(context.Background(), 1)
 
 	assert.Nil(t, err)
 	assert.Len(t, tracks, 1)
 	assert.Equal(t, tracks[0].SpotifyID, "4", "should return the latest track with regards to the insertion date")
 }
+
+func TestTrackRepository_Groups(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "4"})
+
+	assert.Nil(t, repos.Tracks.SetGroup(context.Background(), "1", "1", false))
+	assert.Nil(t, repos.Tracks.SetGroup(context.Background(), "2", "1", true))
+	assert.Nil(t, repos.Tracks.SetGroup(context.Background(), "3", "3", false))
+	assert.Nil(t, repos.Tracks.SetGroup(context.Background(), "4", "3", false))
+	assert.ErrorIs(t, repos.Tracks.SetGroup(context.Background(), "5", "3", false), ErrTrackNotFound)
+
+	groups, total, err := repos.Tracks.Groups(context.Background(), 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, "1", groups[0].ID)
+	assert.Len(t, groups[0].Tracks, 2)
+
+	members, err := repos.Tracks.FindGroup(context.Background(), "3")
+	assert.Nil(t, err)
+	assert.Len(t, members, 2)
+
+	track, _ := repos.Tracks.FindTrack(context.Background(), "2")
+	assert.True(t, track.GroupLocked)
+
+	// saving an imported track must not reset its group
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", Name: "updated"})
+	track, _ = repos.Tracks.FindTrack(context.Background(), "2")
+	assert.Equal(t, "1", track.GroupID)
+}
+
+func TestTrackRepository_LatestAddedAt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	latest, err := repos.Tracks.LatestAddedAt(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, latest.IsZero(), "should be zero if the library has not been imported")
+
+	addedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", AddedAt: addedAt.Add(-time.Hour)})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", AddedAt: addedAt})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
+
+	latest, err = repos.Tracks.LatestAddedAt(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, addedAt.Equal(latest))
+}
+
+func TestTrackRepository_LibraryWatermark(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	watermark, err := repos.Tracks.LibraryWatermark(ctx)
+	assert.Nil(t, err)
+	assert.True(t, watermark.IsZero(), "should be zero if no import has completed")
+
+	expected := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	assert.Nil(t, repos.Tracks.SetLibraryWatermark(ctx, expected.Add(-time.Hour)))
+	assert.Nil(t, repos.Tracks.SetLibraryWatermark(ctx, expected))
+
+	watermark, err = repos.Tracks.LibraryWatermark(ctx)
+	assert.Nil(t, err)
+	assert.True(t, expected.Equal(watermark))
+}
+
+func TestTrackRepository_CountWithLyricsError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1", LyricsImportErrorCount: 3})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", LyricsImportErrorCount: 2})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3", Loaded: true, Lyrics: "la la la"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "4", LyricsImportErrorCount: 3, Instrumental: true})
+
+	n, err := repos.Tracks.CountWithLyricsError(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = repos.Tracks.CountInstrumental(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestTrackRepository_Save__instrumental(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "1", Instrumental: true}))
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "1"}))
+
+	track, err := repos.Tracks.FindTrack(ctx, "1")
+	assert.Nil(t, err)
+	assert.True(t, track.Instrumental, "saving an imported track should keep the mark")
+}
+
+func TestTrackRepository_TracksWithoutLyricsError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "1", LyricsImportErrorCount: 3})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "2", LyricsImportErrorCount: 2})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "3", Loaded: true, Lyrics: "la la la"})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "4"})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "5"})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "6", LyricsImportErrorCount: 1, LyricsReview: &LyricsReview{Lyrics: "la"}})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "7"})
+	assert.Nil(t, repos.Tracks.SetInstrumental(ctx, "7", true))
+	assert.Nil(t, repos.Tracks.SetGroup(ctx, "4", "group", false))
+	assert.Nil(t, repos.Tracks.SetGroup(ctx, "5", "group", false))
+
+	var ids []string
+	it := repos.Tracks.TracksWithoutLyricsError()
+	for it.Next(ctx) {
+		ids = append(ids, it.Track().SpotifyID)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"2", "4", "5"}, ids)
+
+	n, err := repos.Tracks.CountGroupsWithoutLyricsError(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestTrackRepository_Save__lyrics_review(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	review := &LyricsReview{Lyrics: "la la la", Language: "english", Score: 0.25, Issues: []string{"too-short"}}
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "1", LyricsReview: review}))
+
+	track, err := repos.Tracks.FindTrack(ctx, "1")
+	assert.Nil(t, err)
+	if assert.NotNil(t, track.LyricsReview) {
+		assert.Equal(t, review.Issues, track.LyricsReview.Issues)
+	}
+
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "1"}))
+	track, _ = repos.Tracks.FindTrack(ctx, "1")
+	assert.NotNil(t, track.LyricsReview, "saving a track without lyrics should keep the review")
+
+	assert.Nil(t, repos.Tracks.Save(ctx, &Track{SpotifyID: "1", Lyrics: "la la la", Loaded: true}))
+	track, _ = repos.Tracks.FindTrack(ctx, "1")
+	assert.Nil(t, track.LyricsReview, "loaded lyrics should replace the review")
+}
+
+func TestTrackRepository_TracksAfter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		repos.Tracks.Save(ctx, &Track{SpotifyID: fmt.Sprint(i), Explicit: i != 3})
+	}
+
+	var ids []string
+	cursor := ""
+	for {
+		tracks, next, total, err := repos.Tracks.TracksAfter(ctx, TrackFilter{Explicit: ExplicitOnly}, cursor, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, total)
+		for _, track := range tracks {
+			ids = append(ids, track.SpotifyID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"5", "4", "2", "1"}, ids)
+
+	_, _, _, err := repos.Tracks.TracksAfter(ctx, TrackFilter{}, "invalid", 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestTrackRepository_ReviewTracks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	ctx := context.Background()
+	failed := &Track{SpotifyID: "1"}
+	for i := 0; i < 3; i++ {
+		failed.AddLyricsImportError(errors.New("lyrics not found"), time.Now())
+	}
+	repos.Tracks.Save(ctx, failed)
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "2", LyricsImportErrorCount: 1, LyricsReview: &LyricsReview{Lyrics: "la"}})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "3", LyricsImportErrorCount: 1})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "4", Loaded: true, Lyrics: "la la la"})
+	repos.Tracks.Save(ctx, &Track{SpotifyID: "5", LyricsImportErrorCount: 3})
+	assert.Nil(t, repos.Tracks.SetInstrumental(ctx, "5", true))
+	assert.ErrorIs(t, repos.Tracks.SetInstrumental(ctx, "6", true), ErrTrackNotFound)
+
+	tracks, total, err := repos.Tracks.ReviewTracks(ctx, 1, 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	if assert.Len(t, tracks, 2) {
+		assert.Equal(t, "2", tracks[0].SpotifyID)
+		assert.Equal(t, "1", tracks[1].SpotifyID)
+		assert.Len(t, tracks[1].LyricsImportErrors, 3)
+	}
+
+	assert.Nil(t, repos.Tracks.SetInstrumental(ctx, "2", true))
+	instrumental, _ := repos.Tracks.FindTrack(ctx, "2")
+	assert.True(t, instrumental.Instrumental)
+	assert.Nil(t, instrumental.LyricsReview, "marking a track as instrumental should discard the review")
+}
+
+func TestTrackRepository_Orphans(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	orphanedAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "1"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2"})
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "3"})
+
+	assert.Nil(t, repos.Tracks.SetOrphaned(context.Background(), "1", orphanedAt))
+	assert.Nil(t, repos.Tracks.SetOrphaned(context.Background(), "2", orphanedAt.Add(48*time.Hour)))
+	assert.ErrorIs(t, repos.Tracks.SetOrphaned(context.Background(), "4", orphanedAt), ErrTrackNotFound)
+
+	orphans, total, err := repos.Tracks.Orphans(context.Background(), 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, orphans, 2)
+
+	tracks, _, _ := repos.Tracks.Search(context.Background(), "", TrackFilter{HideOrphans: true}, 1, 10, "")
+	assert.Len(t, tracks, 1)
+
+	// tracks saved to the library again are no longer orphaned
+	repos.Tracks.Save(context.Background(), &Track{SpotifyID: "2", AddedAt: orphanedAt})
+	_, total, _ = repos.Tracks.Orphans(context.Background(), 1, 10)
+	assert.Equal(t, 1, total)
+
+	assert.ErrorIs(t, repos.Tracks.DeleteOrphan(context.Background(), "3"), ErrTrackNotFound, "should not delete tracks that are not orphaned")
+
+	n, err := repos.Tracks.DeleteOrphans(context.Background(), orphanedAt.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	_, err = repos.Tracks.FindTrack(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrTrackNotFound)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMigrator(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	m, err := repos.Migrator()
+	assert.Nil(t, err)
+
+	s, err := m.Status()
+	assert.Nil(t, err)
+	assert.True(t, s.Applied)
+	assert.Empty(t, s.Pending, "New should apply all migrations by default")
+	assert.Equal(t, s.Available[len(s.Available)-1], s.Version)
+
+	assert.Nil(t, repos.CheckMigrations(context.Background()))
+
+	t.Run("rolls back migrations", func(t *testing.T) {
+		assert.Nil(t, m.Down(1))
+
+		s, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, s.Available[len(s.Available)-2], s.Version)
+		assert.Equal(t, s.Available[len(s.Available)-1:], s.Pending)
+		assert.ErrorIs(t, repos.CheckMigrations(context.Background()), ErrPendingMigrations)
+	})
+
+	t.Run("refuses to connect if migrations are pending", func(t *testing.T) {
+		_, err := New(testConfig(), WithMigrationMode(MigrationsRequired))
+		assert.ErrorIs(t, err, ErrPendingMigrations)
+	})
+
+	t.Run("migrates to a specific version", func(t *testing.T) {
+		assert.Nil(t, m.Goto(s.Available[0]))
+
+		s, err := m.Status()
+		assert.Nil(t, err)
+		assert.Equal(t, s.Available[0], s.Version)
+	})
+
+	t.Run("applies pending migrations", func(t *testing.T) {
+		assert.Nil(t, m.Up())
+
+		s, err := m.Status()
+		assert.Nil(t, err)
+		assert.Empty(t, s.Pending)
+		assert.Nil(t, repos.CheckMigrations(context.Background()))
+	})
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	WebhookCollection  = "webhooks"
+	DeliveryCollection = "webhook_deliveries"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookRepository interface {
+	Webhooks(ctx context.Context) ([]*Webhook, error)
+	FindWebhook(ctx context.Context, id string) (*Webhook, error)
+	SaveWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+
+	SaveDelivery(ctx context.Context, delivery *Delivery) error
+	Deliveries(ctx context.Context, webhookID string, page, limit int) ([]*Delivery, int, error)
+}
+
+type MongoWebhookRepository struct {
+	db      *mongo.Database
+	timeout time.Duration
+}
+
+func (r MongoWebhookRepository) Webhooks(ctx context.Context) ([]*Webhook, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	c, err := r.db.Collection(WebhookCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []*Webhook
+	err = c.All(ctx, &webhooks)
+	return webhooks, err
+}
+
+func (r MongoWebhookRepository) FindWebhook(ctx context.Context, id string) (*Webhook, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	var w Webhook
+	err = r.db.Collection(WebhookCollection).FindOne(ctx, bson.M{"_id": oid}).Decode(&w)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SaveWebhook stores a new webhook and sets its ID.
+func (r MongoWebhookRepository) SaveWebhook(ctx context.Context, webhook *Webhook) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	webhook.ID = primitive.NewObjectID()
+	_, err := r.db.Collection(WebhookCollection).InsertOne(ctx, webhook)
+	return err
+}
+
+// DeleteWebhook removes a webhook together with its delivery log.
+func (r MongoWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrWebhookNotFound
+	}
+
+	res, err := r.db.Collection(WebhookCollection).DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+
+	_, err = r.db.Collection(DeliveryCollection).DeleteMany(ctx, bson.M{"webhook_id": oid})
+	return err
+}
+
+func (r MongoWebhookRepository) SaveDelivery(ctx context.Context, delivery *Delivery) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.db.Collection(DeliveryCollection).ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery, opts)
+	return err
+}
+
+// Deliveries returns the delivery log of a webhook, the most recent deliveries first.
+func (r MongoWebhookRepository) Deliveries(ctx context.Context, webhookID string, page, limit int) ([]*Delivery, int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	oid, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, 0, ErrWebhookNotFound
+	}
+
+	filter := bson.M{"webhook_id": oid}
+	total, err := r.db.Collection(DeliveryCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+	c, err := r.db.Collection(DeliveryCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*Delivery
+	err = c.All(ctx, &deliveries)
+	return deliveries, int(total), err
+}
+
+func NewMongoWebhookRepository(db *mongo.Database, timeout time.Duration) MongoWebhookRepository {
+	return MongoWebhookRepository{
+		db:      db,
+		timeout: timeout,
+	}
+}
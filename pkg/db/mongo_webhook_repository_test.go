@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	repos := setUp()
+	defer tearDown(repos)
+
+	createdAt := time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC)
+	hook := Webhook{URL: "https://example.com", Secret: "secret", Events: []string{"lyrics.edited"}, CreatedAt: createdAt}
+	assert.Nil(t, repos.Webhooks.SaveWebhook(context.Background(), &hook))
+	assert.False(t, hook.ID.IsZero())
+
+	found, err := repos.Webhooks.FindWebhook(context.Background(), hook.ID.Hex())
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", found.Secret)
+
+	for i := 0; i < 3; i++ {
+		err := repos.Webhooks.SaveDelivery(context.Background(), &Delivery{WebhookID: hook.ID, Event: "lyrics.edited", CreatedAt: createdAt.Add(time.Duration(i) * time.Hour)})
+		assert.Nil(t, err)
+	}
+
+	deliveries, total, err := repos.Webhooks.Deliveries(context.Background(), hook.ID.Hex(), 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, deliveries, 2)
+	assert.True(t, createdAt.Add(2*time.Hour).Equal(deliveries[0].CreatedAt), "should return the most recent delivery first")
+
+	assert.Nil(t, repos.Webhooks.DeleteWebhook(context.Background(), hook.ID.Hex()))
+	assert.ErrorIs(t, repos.Webhooks.DeleteWebhook(context.Background(), hook.ID.Hex()), ErrWebhookNotFound)
+	_, total, _ = repos.Webhooks.Deliveries(context.Background(), hook.ID.Hex(), 1, 2)
+	assert.Equal(t, 0, total, "should delete the deliveries of the webhook")
+}
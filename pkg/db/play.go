@@ -0,0 +1,26 @@
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Play is a single play event of a track taken from the listening history of the user.
+type Play struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	SpotifyID string             `bson:"spotify_id"`
+	PlayedAt  time.Time          `bson:"played_at"`
+}
+
+// PlayedTrack is a play event together with the played track.
+type PlayedTrack struct {
+	PlayedAt time.Time `bson:"played_at"`
+	Track    *Track    `bson:"track"`
+}
+
+// PlayCount is the number of times a track has been played.
+type PlayCount struct {
+	Track        *Track    `bson:"track"`
+	Count        int       `bson:"count"`
+	LastPlayedAt time.Time `bson:"last_played_at"`
+}
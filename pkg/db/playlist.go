@@ -0,0 +1,40 @@
+package db
+
+import (
+	"github.com/zmb3/spotify/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Playlist is a Spotify playlist whose tracks have been imported.
+// The membership of tracks is stored on the tracks themselves, see Track.PlaylistIDs.
+type Playlist struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	SpotifyID       string             `bson:"spotify_id"`
+	Name            string             `bson:"name"`
+	Owner           string             `bson:"owner"`
+	SnapshotID      string             `bson:"snapshot_id"`
+	ImageURL        string             `bson:"image_url"`
+	TrackCount      int                `bson:"track_count"`
+	IsPublic        bool               `bson:"is_public"`
+	IsCollaborative bool               `bson:"is_collaborative"`
+	ImportedAt      time.Time          `bson:"imported_at"`
+}
+
+func NewPlaylist(p spotify.SimplePlaylist) Playlist {
+	imageUrl := ""
+	if len(p.Images) > 0 {
+		imageUrl = p.Images[0].URL
+	}
+
+	return Playlist{
+		SpotifyID:       p.ID.String(),
+		Name:            p.Name,
+		Owner:           p.Owner.DisplayName,
+		SnapshotID:      p.SnapshotID,
+		ImageURL:        imageUrl,
+		TrackCount:      int(p.Tracks.Total),
+		IsPublic:        p.IsPublic,
+		IsCollaborative: p.Collaborative,
+	}
+}
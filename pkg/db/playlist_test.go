@@ -0,0 +1,28 @@
+package db
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/zmb3/spotify/v2"
+	"testing"
+)
+
+func TestNewPlaylist(t *testing.T) {
+	p := NewPlaylist(spotify.SimplePlaylist{
+		ID:            "some_id",
+		Name:          "a playlist",
+		Owner:         spotify.User{DisplayName: "someone"},
+		SnapshotID:    "snapshot",
+		Images:        []spotify.Image{{URL: "https://test.com/cover.jpg"}, {URL: "https://test.com/cover_small.jpg"}},
+		Collaborative: true,
+		Tracks:        spotify.PlaylistTracks{Total: 12},
+	})
+
+	assert.Equal(t, "some_id", p.SpotifyID)
+	assert.Equal(t, "a playlist", p.Name)
+	assert.Equal(t, "someone", p.Owner)
+	assert.Equal(t, "snapshot", p.SnapshotID)
+	assert.Equal(t, "https://test.com/cover.jpg", p.ImageURL)
+	assert.Equal(t, 12, p.TrackCount)
+	assert.True(t, p.IsCollaborative)
+	assert.False(t, p.IsPublic)
+}
@@ -4,26 +4,114 @@ import (
 	"github.com/zmb3/spotify/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"strings"
+	"time"
+)
+
+const (
+	// SourceAlbum marks tracks imported as part of an album.
+	SourceAlbum = "album"
+	// SourceArtist marks tracks imported from the top tracks or the discography of an artist.
+	SourceArtist = "artist"
+	// SourceHistory marks tracks imported from the listening history or the top tracks of the user.
+	SourceHistory = "history"
+	// SourcePlaylist marks tracks imported from a playlist. Unlike the other sources, it does not keep a track that has
+	// been removed from all playlists.
+	SourcePlaylist = "playlist"
 )
 
 type Track struct {
-	ID                     primitive.ObjectID `bson:"_id"`
-	SpotifyID              string             `bson:"spotify_id"`
-	Artist                 string             `bson:"artist"`
-	AlbumName              string             `bson:"album_name"`
-	ImageURL               string             `bson:"image_url"`
-	PreviewURL             string             `bson:"preview_url"`
-	Name                   string             `bson:"name"`
-	Lyrics                 string             `bson:"lyrics"`
-	LyricsImportErrorCount int                `bson:"lyrics_import_error_count"`
-	Loaded                 bool               `bson:"loaded"`
-	Language               string             `bson:"language"`
+	ID        primitive.ObjectID `bson:"_id"`
+	SpotifyID string             `bson:"spotify_id"`
+	// Artist contains the names of all artists joined by ", ". It is kept for the full text search.
+	Artist                 string   `bson:"artist"`
+	Artists                []string `bson:"artists"`
+	ArtistIDs              []string `bson:"artist_ids"`
+	AlbumName              string   `bson:"album_name"`
+	ImageURL               string   `bson:"image_url"`
+	PreviewURL             string   `bson:"preview_url"`
+	Name                   string   `bson:"name"`
+	Lyrics                 string   `bson:"lyrics"`
+	LyricsImportErrorCount int      `bson:"lyrics_import_error_count"`
+	Loaded                 bool     `bson:"loaded"`
+	Language               string   `bson:"language"`
+	ISRC                   string   `bson:"isrc"`
+	DurationMs             int      `bson:"duration_ms"`
+	Explicit               bool     `bson:"explicit"`
+	// ReleaseDate is the release date of the album with the precision reported by Spotify, e.g. "1981", "1981-12" or "1981-12-15".
+	ReleaseDate string `bson:"release_date"`
+	Popularity  int    `bson:"popularity"`
+	// AddedAt is the time the track has been saved to the library of the user. It is zero for tracks that have only been imported from playlists.
+	AddedAt time.Time `bson:"added_at,omitempty"`
+	// PlaylistIDs contains the Spotify IDs of all imported playlists the track belongs to.
+	PlaylistIDs []string `bson:"playlist_ids"`
+	// Sources lists how the track has been imported besides the library, e.g. SourceAlbum.
+	// Tracks imported explicitly from any source but a playlist are never orphaned.
+	Sources []string `bson:"sources"`
+	// OrphanedAt is set if the track is neither saved in the library nor part of an imported playlist anymore.
+	OrphanedAt time.Time `bson:"orphaned_at,omitempty"`
+	// GroupID links tracks that are the same song released multiple times, e.g. on an album and a compilation.
+	GroupID string `bson:"group_id"`
+	// GroupLocked is set if the group has been changed manually and must not be touched by the duplicate detection.
+	GroupLocked bool `bson:"group_locked"`
+	// LyricsReview holds fetched lyrics that have been flagged as low confidence. They are not shown until they have
+	// been reviewed and the track is not synced again in the meantime.
+	LyricsReview *LyricsReview `bson:"lyrics_review,omitempty"`
+	// LyricsImportErrors contains the most recent errors that occurred while importing the lyrics, the oldest first.
+	LyricsImportErrors []LyricsImportError `bson:"lyrics_import_errors,omitempty"`
+	// Instrumental is set for tracks without lyrics. Their lyrics are never fetched. It is set manually, when the track is
+	// imported for the first time if Spotify considers it instrumental, or if a lyrics provider states that the track is
+	// an instrumental.
+	Instrumental bool `bson:"instrumental"`
+}
+
+// LyricsReview contains lyrics that probably do not belong to the track, e.g. because a provider returned a different
+// song, and the issues found while validating them.
+type LyricsReview struct {
+	Lyrics   string   `bson:"lyrics"`
+	Language string   `bson:"language"`
+	Score    float64  `bson:"score"`
+	Issues   []string `bson:"issues"`
+	// FlaggedAt is the time the lyrics have been fetched.
+	FlaggedAt time.Time `bson:"flagged_at"`
+}
+
+// lyricsImportErrorHistory is the number of lyrics import errors kept per track.
+const lyricsImportErrorHistory = 10
+
+// LyricsImportError is a failed attempt to import the lyrics of a track.
+type LyricsImportError struct {
+	Message string    `bson:"message"`
+	At      time.Time `bson:"at"`
+}
+
+// AddLyricsImportError records a failed attempt to import the lyrics of the track. Only the most recent errors are
+// kept.
+func (t *Track) AddLyricsImportError(err error, at time.Time) {
+	t.LyricsImportErrorCount++
+	t.LyricsImportErrors = append(t.LyricsImportErrors, LyricsImportError{Message: err.Error(), At: at})
+	if n := len(t.LyricsImportErrors); n > lyricsImportErrorHistory {
+		t.LyricsImportErrors = t.LyricsImportErrors[n-lyricsImportErrorHistory:]
+	}
+}
+
+// ArtistNames returns the names of all artists of the track.
+// Tracks imported before the artists have been stored separately fall back to splitting Artist.
+func (t Track) ArtistNames() []string {
+	if len(t.Artists) > 0 {
+		return t.Artists
+	}
+	if t.Artist == "" {
+		return nil
+	}
+	return strings.Split(t.Artist, ", ")
 }
 
 func NewTrack(t spotify.FullTrack) Track {
 	artists := make([]string, len(t.Artists))
+	artistIDs := make([]string, len(t.Artists))
 	for j := range t.Artists {
 		artists[j] = t.Artists[j].Name
+		artistIDs[j] = t.Artists[j].ID.String()
 	}
 
 	imageUrl := ""
@@ -32,11 +120,32 @@ func NewTrack(t spotify.FullTrack) Track {
 	}
 
 	return Track{
-		SpotifyID:  t.ID.String(),
-		Artist:     strings.Join(artists, ", "),
-		AlbumName:  t.Album.Name,
-		ImageURL:   imageUrl,
-		PreviewURL: t.PreviewURL,
-		Name:       t.Name,
+		SpotifyID:   t.ID.String(),
+		Artist:      strings.Join(artists, ", "),
+		Artists:     artists,
+		ArtistIDs:   artistIDs,
+		AlbumName:   t.Album.Name,
+		ImageURL:    imageUrl,
+		PreviewURL:  t.PreviewURL,
+		Name:        t.Name,
+		ISRC:        t.ExternalIDs["isrc"],
+		DurationMs:  t.Duration,
+		Explicit:    t.Explicit,
+		ReleaseDate: t.Album.ReleaseDate,
+		Popularity:  t.Popularity,
+	}
+}
+
+// NewAlbumTrack creates a track of an album. Tracks listed by an album lack the album information, so it is passed separately.
+func NewAlbumTrack(album spotify.SimpleAlbum, t spotify.SimpleTrack) Track {
+	return NewTrack(spotify.FullTrack{SimpleTrack: t, Album: album})
+}
+
+// NewSavedTrack creates a track from a track of the library of the user and keeps the time it has been saved.
+func NewSavedTrack(t spotify.SavedTrack) Track {
+	track := NewTrack(t.FullTrack)
+	if addedAt, err := time.Parse(spotify.TimestampLayout, t.AddedAt); err == nil {
+		track.AddedAt = addedAt
 	}
+	return track
 }
@@ -2,8 +2,10 @@ package db
 
 import (
 	"fmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/zmb3/spotify/v2"
 	"testing"
+	"time"
 )
 
 func TestTrack_sets_SpotifyID(t *testing.T) {
@@ -196,3 +198,88 @@ func TestTrack_does_not_set_ID(t *testing.T) {
 		t.Errorf("expect track to not set the ID field, got: %v", track.ID)
 	}
 }
+
+func TestTrack_sets_ISRC(t *testing.T) {
+	var tests = []struct {
+		externalIDs map[string]string
+		want        string
+	}{
+		{map[string]string{"isrc": "GBUM71029604"}, "GBUM71029604"},
+		{map[string]string{"ean": "123"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v,%s", tt.externalIDs, tt.want), func(t *testing.T) {
+			spotifyTrack := spotify.FullTrack{ExternalIDs: tt.externalIDs}
+			track := NewTrack(spotifyTrack)
+
+			if track.ISRC != tt.want {
+				t.Errorf("expect a track to copy its isrc. expected: %q, got %q", tt.want, track.ISRC)
+			}
+		})
+	}
+}
+
+func TestTrack_sets_metadata(t *testing.T) {
+	spotifyTrack := spotify.FullTrack{
+		SimpleTrack: spotify.SimpleTrack{
+			Artists: []spotify.SimpleArtist{
+				{ID: "a1", Name: "artist A"},
+				{ID: "b2", Name: "artist B, Jr."},
+			},
+			Duration: 215000,
+			Explicit: true,
+		},
+		Album:      spotify.SimpleAlbum{ReleaseDate: "1981-12-15"},
+		Popularity: 42,
+	}
+	track := NewTrack(spotifyTrack)
+
+	assert.Equal(t, []string{"artist A", "artist B, Jr."}, track.Artists)
+	assert.Equal(t, []string{"a1", "b2"}, track.ArtistIDs)
+	assert.Equal(t, 215000, track.DurationMs)
+	assert.True(t, track.Explicit)
+	assert.Equal(t, "1981-12-15", track.ReleaseDate)
+	assert.Equal(t, 42, track.Popularity)
+	assert.True(t, track.AddedAt.IsZero())
+}
+
+func TestNewSavedTrack__sets_AddedAt(t *testing.T) {
+	track := NewSavedTrack(spotify.SavedTrack{
+		AddedAt:   "2021-06-24T19:59:02Z",
+		FullTrack: spotify.FullTrack{SimpleTrack: spotify.SimpleTrack{ID: "some_id"}},
+	})
+
+	assert.Equal(t, "some_id", track.SpotifyID)
+	assert.Equal(t, time.Date(2021, 6, 24, 19, 59, 2, 0, time.UTC), track.AddedAt)
+}
+
+func TestNewAlbumTrack__sets_album(t *testing.T) {
+	track := NewAlbumTrack(
+		spotify.SimpleAlbum{Name: "Album", ReleaseDate: "1981"},
+		spotify.SimpleTrack{ID: "some_id", Name: "Track"})
+
+	assert.Equal(t, "some_id", track.SpotifyID)
+	assert.Equal(t, "Album", track.AlbumName)
+	assert.Equal(t, "1981", track.ReleaseDate)
+}
+
+func TestTrack_ArtistNames(t *testing.T) {
+	assert.Equal(t, []string{"A", "B"}, Track{Artist: "A, B", Artists: []string{"A", "B"}}.ArtistNames())
+	assert.Equal(t, []string{"A", "B"}, Track{Artist: "A, B"}.ArtistNames())
+	assert.Nil(t, Track{}.ArtistNames())
+}
+
+func TestTrack_AddLyricsImportError(t *testing.T) {
+	track := Track{}
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < lyricsImportErrorHistory+2; i++ {
+		track.AddLyricsImportError(fmt.Errorf("error %d", i), start.Add(time.Duration(i)*time.Minute))
+	}
+
+	assert.Equal(t, lyricsImportErrorHistory+2, track.LyricsImportErrorCount)
+	assert.Len(t, track.LyricsImportErrors, lyricsImportErrorHistory)
+	assert.Equal(t, LyricsImportError{Message: "error 2", At: start.Add(2 * time.Minute)}, track.LyricsImportErrors[0], "the oldest errors should be dropped")
+	assert.Equal(t, "error 11", track.LyricsImportErrors[lyricsImportErrorHistory-1].Message)
+}
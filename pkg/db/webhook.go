@@ -0,0 +1,42 @@
+package db
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Webhook is an http endpoint that is notified about the given event types.
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	URL       string             `bson:"url"`
+	Secret    string             `bson:"secret"`
+	Events    []string           `bson:"events"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Subscribes reports whether the webhook is notified about the event.
+func (w Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery records the attempts to send an event to a webhook.
+type Delivery struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	WebhookID  primitive.ObjectID `bson:"webhook_id"`
+	Event      string             `bson:"event"`
+	Payload    string             `bson:"payload"`
+	Attempts   int                `bson:"attempts"`
+	StatusCode int                `bson:"status_code"`
+	Error      string             `bson:"error"`
+	Success    bool               `bson:"success"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	// DeliveredAt is the time of the last attempt.
+	DeliveredAt time.Time `bson:"delivered_at"`
+	// Pending is set until the delivery has succeeded or all attempts have failed.
+	Pending bool `bson:"pending"`
+}
@@ -0,0 +1,375 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"github.com/imba28/spolyr/pkg/db"
+	"net/url"
+	"strings"
+)
+
+// progressInterval is the number of examined tracks after which the progress is reported.
+const progressInterval = 25
+
+const (
+	CheckLoadedWithoutLyrics = "loaded-without-lyrics"
+	CheckMissingLanguage     = "missing-language"
+	CheckDuplicateTracks     = "duplicate-tracks"
+	CheckMissingIndexes      = "missing-indexes"
+	CheckStaleErrorCount     = "stale-import-error-count"
+	CheckBrokenImageURL      = "broken-image-url"
+)
+
+type trackStore interface {
+	IterateTracks() *db.TrackIterator
+	FindTrack(ctx context.Context, spotifyID string) (*db.Track, error)
+	Count(ctx context.Context) (int64, error)
+	Save(ctx context.Context, track *db.Track) error
+	ResetLyrics(ctx context.Context, track *db.Track) error
+}
+
+type indexStore interface {
+	MissingIndexes(ctx context.Context) ([]db.Index, error)
+	CreateIndexes(ctx context.Context, indexes []db.Index) error
+}
+
+type languageDetector interface {
+	Detect(string) (string, error)
+}
+
+// Issue is a single problem found by one of the checks.
+type Issue struct {
+	Check      string   `json:"check"`
+	Message    string   `json:"message"`
+	SpotifyIDs []string `json:"spotifyIds,omitempty"`
+	Fixable    bool     `json:"fixable"`
+	Fixed      bool     `json:"fixed"`
+	Error      string   `json:"error,omitempty"`
+
+	fix func(ctx context.Context) error
+}
+
+// Report summarizes the result of an examination.
+type Report struct {
+	DryRun bool    `json:"dryRun"`
+	Tracks int     `json:"tracks"`
+	Issues []Issue `json:"issues"`
+}
+
+// Fixed returns the number of issues that have been repaired.
+func (r Report) Fixed() int {
+	n := 0
+	for i := range r.Issues {
+		if r.Issues[i].Fixed {
+			n++
+		}
+	}
+	return n
+}
+
+// Doctor checks the integrity of the track collection and optionally repairs the problems it finds.
+type Doctor struct {
+	tracks           trackStore
+	indexes          indexStore
+	languageDetector languageDetector
+
+	// Progress is called periodically while the tracks are examined, if set.
+	Progress func(current, total int)
+}
+
+// trackCheck examines the tracks one at a time and reports the issues it found once all tracks have been visited.
+type trackCheck interface {
+	examine(t *db.Track)
+	issues() []Issue
+}
+
+// perTrackCheck is a trackCheck whose issues only depend on a single track.
+type perTrackCheck struct {
+	check func(t *db.Track) *Issue
+	found []Issue
+}
+
+func (c *perTrackCheck) examine(t *db.Track) {
+	if issue := c.check(t); issue != nil {
+		c.found = append(c.found, *issue)
+	}
+}
+
+func (c *perTrackCheck) issues() []Issue {
+	return c.found
+}
+
+// Examine runs all checks and returns a report. If fix is true, repairs are applied to every fixable issue.
+// The tracks are streamed from the database, so only tracks with issues are held in memory.
+func (d Doctor) Examine(ctx context.Context, fix bool) (Report, error) {
+	checks := []trackCheck{
+		&perTrackCheck{check: d.checkLoadedWithoutLyrics},
+		&perTrackCheck{check: d.checkMissingLanguage},
+		d.newDuplicateCheck(),
+		&perTrackCheck{check: d.checkStaleErrorCount},
+		&perTrackCheck{check: d.checkBrokenImageURL},
+	}
+
+	n, err := d.examineTracks(ctx, checks)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var issues []Issue
+	for _, c := range checks {
+		issues = append(issues, c.issues()...)
+	}
+
+	indexIssues, err := d.checkMissingIndexes(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	issues = append(issues, indexIssues...)
+
+	if fix {
+		for i := range issues {
+			if !issues[i].Fixable {
+				continue
+			}
+			if err := issues[i].fix(ctx); err != nil {
+				issues[i].Error = err.Error()
+				continue
+			}
+			issues[i].Fixed = true
+		}
+	}
+
+	return Report{
+		DryRun: !fix,
+		Tracks: n,
+		Issues: issues,
+	}, nil
+}
+
+// examineTracks passes every track to the checks and returns the number of tracks.
+func (d Doctor) examineTracks(ctx context.Context, checks []trackCheck) (int, error) {
+	total := 0
+	if d.Progress != nil {
+		count, err := d.tracks.Count(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total = int(count)
+	}
+
+	n := 0
+	it := d.tracks.IterateTracks()
+	for it.Next(ctx) {
+		for _, c := range checks {
+			c.examine(it.Track())
+		}
+		n++
+		if d.Progress != nil && n%progressInterval == 0 {
+			d.Progress(n, total)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	if d.Progress != nil && n%progressInterval != 0 {
+		d.Progress(n, total)
+	}
+	return n, nil
+}
+
+func (d Doctor) checkLoadedWithoutLyrics(t *db.Track) *Issue {
+	if !t.Loaded || strings.TrimSpace(t.Lyrics) != "" {
+		return nil
+	}
+	return &Issue{
+		Check:      CheckLoadedWithoutLyrics,
+		Message:    fmt.Sprintf("%s - %s is marked as loaded but has no lyrics", t.Artist, t.Name),
+		SpotifyIDs: []string{t.SpotifyID},
+		Fixable:    true,
+		fix: func(ctx context.Context) error {
+			return d.tracks.ResetLyrics(ctx, t)
+		},
+	}
+}
+
+func (d Doctor) checkMissingLanguage(t *db.Track) *Issue {
+	if !t.Loaded || t.Language != "" || strings.TrimSpace(t.Lyrics) == "" {
+		return nil
+	}
+	return &Issue{
+		Check:      CheckMissingLanguage,
+		Message:    fmt.Sprintf("language of %s - %s is not set", t.Artist, t.Name),
+		SpotifyIDs: []string{t.SpotifyID},
+		Fixable:    d.languageDetector != nil,
+		fix: func(ctx context.Context) error {
+			lang, err := d.languageDetector.Detect(t.Lyrics)
+			if err != nil {
+				return err
+			}
+			t.Language = lang
+			return d.tracks.Save(ctx, t)
+		},
+	}
+}
+
+// duplicateGroup holds what is needed to report and repair tracks of the same song, but not the tracks themselves.
+// They are loaded once the lyrics are copied.
+type duplicateGroup struct {
+	ids    []string
+	artist string
+	name   string
+	// sourceID is the first track with lyrics, targetIDs are the tracks whose lyrics have not been loaded.
+	sourceID  string
+	targetIDs []string
+}
+
+type duplicateCheck struct {
+	d      Doctor
+	groups map[string]*duplicateGroup
+	keys   []string
+}
+
+func (d Doctor) newDuplicateCheck() *duplicateCheck {
+	return &duplicateCheck{
+		d:      d,
+		groups: make(map[string]*duplicateGroup),
+	}
+}
+
+func (c *duplicateCheck) examine(t *db.Track) {
+	if strings.TrimSpace(t.Name) == "" {
+		return
+	}
+	key := strings.ToLower(strings.TrimSpace(t.Artist)) + "\x00" + strings.ToLower(strings.TrimSpace(t.Name))
+	g, ok := c.groups[key]
+	if !ok {
+		g = &duplicateGroup{artist: t.Artist, name: t.Name}
+		c.groups[key] = g
+		c.keys = append(c.keys, key)
+	}
+
+	g.ids = append(g.ids, t.SpotifyID)
+	if g.sourceID == "" && t.Loaded && strings.TrimSpace(t.Lyrics) != "" {
+		g.sourceID = t.SpotifyID
+	}
+	if !t.Loaded {
+		g.targetIDs = append(g.targetIDs, t.SpotifyID)
+	}
+}
+
+func (c *duplicateCheck) issues() []Issue {
+	var issues []Issue
+	for _, key := range c.keys {
+		g := c.groups[key]
+		if len(g.ids) < 2 {
+			continue
+		}
+
+		issue := Issue{
+			Check:      CheckDuplicateTracks,
+			Message:    fmt.Sprintf("%s - %s is stored %d times", g.artist, g.name, len(g.ids)),
+			SpotifyIDs: g.ids,
+			Fixable:    g.sourceID != "" && len(g.targetIDs) > 0,
+		}
+		if issue.Fixable {
+			issue.Message += ", lyrics can be copied to the duplicates without lyrics"
+			issue.fix = func(ctx context.Context) error {
+				source, err := c.d.tracks.FindTrack(ctx, g.sourceID)
+				if err != nil {
+					return err
+				}
+				for _, id := range g.targetIDs {
+					t, err := c.d.tracks.FindTrack(ctx, id)
+					if err != nil {
+						return err
+					}
+					t.Lyrics = source.Lyrics
+					t.Language = source.Language
+					t.Loaded = true
+					t.LyricsImportErrorCount = 0
+					if err := c.d.tracks.Save(ctx, t); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func (d Doctor) checkStaleErrorCount(t *db.Track) *Issue {
+	stale := t.LyricsImportErrorCount < 0 || (t.Loaded && t.LyricsImportErrorCount > 0)
+	if !stale {
+		return nil
+	}
+	return &Issue{
+		Check:      CheckStaleErrorCount,
+		Message:    fmt.Sprintf("%s - %s has a stale lyrics import error count of %d", t.Artist, t.Name, t.LyricsImportErrorCount),
+		SpotifyIDs: []string{t.SpotifyID},
+		Fixable:    true,
+		fix: func(ctx context.Context) error {
+			t.LyricsImportErrorCount = 0
+			return d.tracks.Save(ctx, t)
+		},
+	}
+}
+
+func (d Doctor) checkBrokenImageURL(t *db.Track) *Issue {
+	if t.ImageURL == "" || validURL(t.ImageURL) {
+		return nil
+	}
+	return &Issue{
+		Check:      CheckBrokenImageURL,
+		Message:    fmt.Sprintf("%s - %s has an invalid image url %q", t.Artist, t.Name, t.ImageURL),
+		SpotifyIDs: []string{t.SpotifyID},
+		Fixable:    true,
+		fix: func(ctx context.Context) error {
+			t.ImageURL = ""
+			return d.tracks.Save(ctx, t)
+		},
+	}
+}
+
+func (d Doctor) checkMissingIndexes(ctx context.Context) ([]Issue, error) {
+	if d.indexes == nil {
+		return nil, nil
+	}
+
+	missing, err := d.indexes.MissingIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]Issue, len(missing))
+	for i := range missing {
+		index := missing[i]
+		issues[i] = Issue{
+			Check:   CheckMissingIndexes,
+			Message: fmt.Sprintf("index %s is missing", index),
+			Fixable: true,
+			fix: func(ctx context.Context) error {
+				return d.indexes.CreateIndexes(ctx, []db.Index{index})
+			},
+		}
+	}
+	return issues, nil
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func New(tracks trackStore, indexes indexStore, d languageDetector) Doctor {
+	return Doctor{
+		tracks:           tracks,
+		indexes:          indexes,
+		languageDetector: d,
+	}
+}
@@ -0,0 +1,266 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type trackStoreMock struct {
+	mock.Mock
+}
+
+func (t *trackStoreMock) IterateTracks() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := t.MethodCalled("IterateTracks", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
+}
+func (t *trackStoreMock) FindTrack(ctx context.Context, spotifyID string) (*db.Track, error) {
+	args := t.Called(spotifyID)
+	return args.Get(0).(*db.Track), args.Error(1)
+}
+func (t *trackStoreMock) Count(ctx context.Context) (int64, error) {
+	args := t.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+func (t *trackStoreMock) Save(ctx context.Context, track *db.Track) error {
+	return t.Called(track).Error(0)
+}
+func (t *trackStoreMock) ResetLyrics(ctx context.Context, track *db.Track) error {
+	return t.Called(track).Error(0)
+}
+
+type indexStoreMock struct {
+	mock.Mock
+}
+
+func (i *indexStoreMock) MissingIndexes(ctx context.Context) ([]db.Index, error) {
+	args := i.Called(ctx)
+	return args.Get(0).([]db.Index), args.Error(1)
+}
+func (i *indexStoreMock) CreateIndexes(ctx context.Context, indexes []db.Index) error {
+	return i.Called(ctx, indexes).Error(0)
+}
+
+type languageDetectorMock struct {
+	mock.Mock
+}
+
+func (l *languageDetectorMock) Detect(s string) (string, error) {
+	args := l.Called(s)
+	return args.String(0), args.Error(1)
+}
+
+var _ trackStore = &trackStoreMock{}
+var _ indexStore = &indexStoreMock{}
+
+func issuesOf(r Report, check string) []Issue {
+	var issues []Issue
+	for _, i := range r.Issues {
+		if i.Check == check {
+			issues = append(issues, i)
+		}
+	}
+	return issues
+}
+
+func newDoctor(tracks []*db.Track) (Doctor, *trackStoreMock, *indexStoreMock, *languageDetectorMock) {
+	s := new(trackStoreMock)
+	s.On("IterateTracks", "").Return(tracks, "", nil)
+	i := new(indexStoreMock)
+	i.On("MissingIndexes", mock.Anything).Return([]db.Index{}, nil)
+	l := new(languageDetectorMock)
+
+	return New(s, i, l), s, i, l
+}
+
+func TestDoctor_Examine(t *testing.T) {
+	t.Run("reports tracks marked as loaded without lyrics", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", Loaded: true, Lyrics: " "}
+		d, s, _, _ := newDoctor([]*db.Track{track, {SpotifyID: "2", Loaded: true, Lyrics: "la la la", Language: "english"}})
+
+		r, err := d.Examine(context.Background(), false)
+
+		assert.Nil(t, err)
+		assert.True(t, r.DryRun)
+		assert.Equal(t, 2, r.Tracks)
+		issues := issuesOf(r, CheckLoadedWithoutLyrics)
+		assert.Len(t, issues, 1)
+		assert.Equal(t, []string{"1"}, issues[0].SpotifyIDs)
+		s.AssertNotCalled(t, "ResetLyrics", mock.Anything)
+	})
+
+	t.Run("resets tracks marked as loaded without lyrics in fix mode", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", Loaded: true}
+		d, s, _, _ := newDoctor([]*db.Track{track})
+		s.On("ResetLyrics", track).Return(nil)
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.False(t, r.DryRun)
+		assert.Equal(t, 1, r.Fixed())
+		s.AssertExpectations(t)
+	})
+
+	t.Run("detects the language of tracks without language", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", Loaded: true, Lyrics: "Die Freiheit spielt auf allen Geigen"}
+		d, s, _, l := newDoctor([]*db.Track{track})
+		l.On("Detect", track.Lyrics).Return("german", nil)
+		s.On("Save", track).Return(nil)
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Len(t, issuesOf(r, CheckMissingLanguage), 1)
+		assert.Equal(t, "german", track.Language)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("copies lyrics to duplicates", func(t *testing.T) {
+		original := &db.Track{SpotifyID: "1", Artist: "Eminem", Name: "Stan", Loaded: true, Lyrics: "My tea's gone cold", Language: "english"}
+		duplicate := &db.Track{SpotifyID: "2", Artist: "eminem", Name: "Stan ", LyricsImportErrorCount: 2}
+		d, s, _, _ := newDoctor([]*db.Track{original, duplicate, {SpotifyID: "3", Artist: "Eminem", Name: "Lose Yourself"}})
+		s.On("FindTrack", "1").Return(original, nil)
+		s.On("FindTrack", "2").Return(duplicate, nil)
+		s.On("Save", duplicate).Return(nil)
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		issues := issuesOf(r, CheckDuplicateTracks)
+		assert.Len(t, issues, 1)
+		assert.Equal(t, []string{"1", "2"}, issues[0].SpotifyIDs)
+		assert.True(t, issues[0].Fixed)
+		assert.Equal(t, original.Lyrics, duplicate.Lyrics)
+		assert.True(t, duplicate.Loaded)
+		assert.Equal(t, 0, duplicate.LyricsImportErrorCount)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("reports duplicates without lyrics as not fixable", func(t *testing.T) {
+		d, _, _, _ := newDoctor([]*db.Track{
+			{SpotifyID: "1", Artist: "Eminem", Name: "Stan"},
+			{SpotifyID: "2", Artist: "Eminem", Name: "Stan"},
+		})
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		issues := issuesOf(r, CheckDuplicateTracks)
+		assert.Len(t, issues, 1)
+		assert.False(t, issues[0].Fixable)
+		assert.False(t, issues[0].Fixed)
+	})
+
+	t.Run("resets stale error counts", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", Loaded: true, Lyrics: "la la la", Language: "english", LyricsImportErrorCount: 2}
+		d, s, _, _ := newDoctor([]*db.Track{track, {SpotifyID: "2", LyricsImportErrorCount: 2}})
+		s.On("Save", track).Return(nil)
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Len(t, issuesOf(r, CheckStaleErrorCount), 1)
+		assert.Equal(t, 0, track.LyricsImportErrorCount)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("clears broken image urls", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", ImageURL: "not an url"}
+		d, s, _, _ := newDoctor([]*db.Track{track, {SpotifyID: "2", ImageURL: "https://i.scdn.co/image/ab67616d0000b273"}})
+		s.On("Save", track).Return(nil)
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Len(t, issuesOf(r, CheckBrokenImageURL), 1)
+		assert.Equal(t, "", track.ImageURL)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("creates missing indexes", func(t *testing.T) {
+		missing := []db.Index{{Collection: "tracks", Name: "spotify_id_index"}}
+		s := new(trackStoreMock)
+		s.On("IterateTracks", "").Return([]*db.Track{}, "", nil)
+		i := new(indexStoreMock)
+		i.On("MissingIndexes", mock.Anything).Return(missing, nil)
+		i.On("CreateIndexes", mock.Anything, missing).Return(nil)
+
+		r, err := New(s, i, nil).Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Len(t, issuesOf(r, CheckMissingIndexes), 1)
+		assert.Equal(t, 1, r.Fixed())
+		i.AssertExpectations(t)
+	})
+
+	t.Run("records errors of failed repairs", func(t *testing.T) {
+		track := &db.Track{SpotifyID: "1", Loaded: true}
+		d, s, _, _ := newDoctor([]*db.Track{track})
+		s.On("ResetLyrics", track).Return(errors.New("database error"))
+
+		r, err := d.Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, r.Fixed())
+		assert.Equal(t, "database error", r.Issues[0].Error)
+	})
+
+	t.Run("walks all pages of the library", func(t *testing.T) {
+		firstPage := make([]*db.Track, progressInterval)
+		for i := range firstPage {
+			firstPage[i] = &db.Track{}
+		}
+		s := new(trackStoreMock)
+		s.On("Count").Return(int64(progressInterval+1), nil)
+		s.On("IterateTracks", "").Return(firstPage, "next", nil)
+		s.On("IterateTracks", "next").Return([]*db.Track{{}}, "", nil)
+
+		var progress [][2]int
+		d := New(s, nil, nil)
+		d.Progress = func(current, total int) {
+			progress = append(progress, [2]int{current, total})
+		}
+		r, err := d.Examine(context.Background(), false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, progressInterval+1, r.Tracks)
+		assert.Equal(t, [][2]int{{progressInterval, progressInterval + 1}, {progressInterval + 1, progressInterval + 1}}, progress)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("finds duplicates on different pages", func(t *testing.T) {
+		album := &db.Track{SpotifyID: "1", Artist: "Artist", Name: "Song", Loaded: true, Lyrics: "la la la", Language: "english"}
+		single := &db.Track{SpotifyID: "2", Artist: "artist", Name: "song "}
+		s := new(trackStoreMock)
+		s.On("IterateTracks", "").Return([]*db.Track{album}, "next", nil)
+		s.On("IterateTracks", "next").Return([]*db.Track{single}, "", nil)
+		s.On("FindTrack", "1").Return(album, nil)
+		s.On("FindTrack", "2").Return(single, nil)
+		s.On("Save", single).Return(nil)
+
+		r, err := New(s, nil, nil).Examine(context.Background(), true)
+
+		assert.Nil(t, err)
+		if assert.Len(t, issuesOf(r, CheckDuplicateTracks), 1) {
+			assert.Equal(t, []string{"1", "2"}, issuesOf(r, CheckDuplicateTracks)[0].SpotifyIDs)
+		}
+		assert.Equal(t, "la la la", single.Lyrics)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("returns error if tracks cannot be loaded", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		s := new(trackStoreMock)
+		s.On("IterateTracks", "").Return([]*db.Track{}, "", expectedErr)
+
+		_, err := New(s, nil, nil).Examine(context.Background(), false)
+
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
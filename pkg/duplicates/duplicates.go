@@ -0,0 +1,376 @@
+package duplicates
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var ErrNotEnoughTracks = errors.New("at least two tracks are required to form a group")
+
+var (
+	// qualifiers that mark a different release of the same song, e.g. "Song - Remastered 2011" or "Song (Live)". They
+	// must be whole words, so titles like "Somebody (Olive Tree)" or "Song - Deliver Me" are kept.
+	qualifiers     = `\b(?:remaster(?:ed)?|live|version|edit|mono|stereo|deluxe|anniversary|single|album|feat|ft|with|explicit|bonus)\b`
+	suffixPattern  = regexp.MustCompile(`(?i)\s+-\s+[^-]*(` + qualifiers + `).*$`)
+	bracketPattern = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*(` + qualifiers + `)[^)\]]*[)\]]`)
+)
+
+type store interface {
+	IterateTracks() *db.TrackIterator
+	FindTrack(ctx context.Context, spotifyID string) (*db.Track, error)
+	FindGroup(ctx context.Context, groupID string) ([]*db.Track, error)
+	SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error
+	Save(ctx context.Context, track *db.Track) error
+}
+
+type groupStore interface {
+	FindGroup(ctx context.Context, groupID string) ([]*db.Track, error)
+	Save(ctx context.Context, track *db.Track) error
+}
+
+// Key returns the normalized artist and title of a track. Tracks with the same key are considered to be the same song.
+func Key(artist, title string) string {
+	if i := strings.Index(artist, ", "); i > -1 {
+		artist = artist[:i]
+	}
+
+	title = bracketPattern.ReplaceAllString(title, "")
+	title = suffixPattern.ReplaceAllString(title, "")
+
+	return normalize(artist) + "\x00" + normalize(title)
+}
+
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Result summarizes a run of the duplicate detection.
+type Result struct {
+	Groups        int
+	LyricsShared  int
+	TracksChanged int
+}
+
+// Detector groups tracks that are the same song released multiple times.
+type Detector struct {
+	store store
+}
+
+// candidate is the part of a track required to group it. The tracks themselves are only loaded to share lyrics.
+type candidate struct {
+	spotifyID string
+	groupID   string
+	loaded    bool
+}
+
+// Detect groups all tracks by their normalized artist and title as well as their ISRC and shares lyrics within
+// each group. Tracks whose group has been changed manually are left untouched.
+func (d Detector) Detect(ctx context.Context) (Result, error) {
+	var r Result
+
+	var candidates []candidate
+	var u union
+	first := make(map[string]int)
+	it := d.store.IterateTracks()
+	for it.Next(ctx) {
+		t := it.Track()
+		if t.GroupLocked {
+			continue
+		}
+		i := u.add()
+		candidates = append(candidates, candidate{spotifyID: t.SpotifyID, groupID: t.GroupID, loaded: t.Loaded})
+
+		keys := []string{"key:" + Key(t.Artist, t.Name)}
+		if t.ISRC != "" {
+			keys = append(keys, "isrc:"+strings.ToUpper(t.ISRC))
+		}
+		for _, k := range keys {
+			if j, ok := first[k]; ok {
+				u.join(i, j)
+			} else {
+				first[k] = i
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return r, err
+	}
+
+	members := make(map[int][]int)
+	for i := range candidates {
+		root := u.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	for _, group := range members {
+		groupID := ""
+		if len(group) > 1 {
+			ids := make([]string, len(group))
+			for j, i := range group {
+				ids[j] = candidates[i].spotifyID
+			}
+			groupID = lowestID(ids)
+			r.Groups++
+		}
+
+		for _, i := range group {
+			c := &candidates[i]
+			if c.groupID == groupID {
+				continue
+			}
+			if err := d.store.SetGroup(ctx, c.spotifyID, groupID, false); err != nil {
+				return r, err
+			}
+			c.groupID = groupID
+			r.TracksChanged++
+		}
+
+		if groupID != "" {
+			n, err := d.shareGroupLyrics(ctx, candidates, group)
+			if err != nil {
+				return r, err
+			}
+			r.LyricsShared += n
+		}
+	}
+
+	return r, nil
+}
+
+// shareGroupLyrics copies the lyrics of the first member of a group with lyrics to all members without lyrics.
+func (d Detector) shareGroupLyrics(ctx context.Context, candidates []candidate, group []int) (int, error) {
+	source := -1
+	missing := 0
+	for _, i := range group {
+		if !candidates[i].loaded {
+			missing++
+		} else if source == -1 {
+			source = i
+		}
+	}
+	if source == -1 || missing == 0 {
+		return 0, nil
+	}
+
+	from, err := d.store.FindTrack(ctx, candidates[source].spotifyID)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, i := range group {
+		if candidates[i].loaded {
+			continue
+		}
+		t, err := d.store.FindTrack(ctx, candidates[i].spotifyID)
+		if err != nil {
+			return n, err
+		}
+		copyLyrics(from, t)
+		if err := d.store.Save(ctx, t); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Merge puts the given tracks and all members of their current groups into a single group.
+// The group is locked, so it is not changed by the automatic detection.
+func (d Detector) Merge(ctx context.Context, spotifyIDs []string) (string, []*db.Track, error) {
+	seen := make(map[string]bool)
+	var tracks []*db.Track
+	for _, id := range spotifyIDs {
+		t, err := d.store.FindTrack(ctx, id)
+		if err != nil {
+			return "", nil, err
+		}
+
+		group := []*db.Track{t}
+		if t.GroupID != "" {
+			group, err = d.store.FindGroup(ctx, t.GroupID)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		for _, member := range group {
+			if !seen[member.SpotifyID] {
+				seen[member.SpotifyID] = true
+				tracks = append(tracks, member)
+			}
+		}
+	}
+
+	if len(tracks) < 2 {
+		return "", nil, ErrNotEnoughTracks
+	}
+
+	groupID := groupIDOf(tracks)
+	for _, t := range tracks {
+		if err := d.store.SetGroup(ctx, t.SpotifyID, groupID, true); err != nil {
+			return "", nil, err
+		}
+		t.GroupID = groupID
+		t.GroupLocked = true
+	}
+
+	_, err := shareLyrics(ctx, d.store, tracks)
+	return groupID, tracks, err
+}
+
+// Unmerge removes a track from its group. The track is locked, so the automatic detection does not add it again.
+// If only one track remains in the group, the group is dissolved.
+func (d Detector) Unmerge(ctx context.Context, groupID, spotifyID string) error {
+	group, err := d.store.FindGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+
+	var remaining []*db.Track
+	found := false
+	for _, t := range group {
+		if t.SpotifyID == spotifyID {
+			found = true
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	if !found {
+		return db.ErrTrackNotFound
+	}
+
+	if err := d.store.SetGroup(ctx, spotifyID, "", true); err != nil {
+		return err
+	}
+	if len(remaining) == 1 {
+		return d.store.SetGroup(ctx, remaining[0].SpotifyID, "", true)
+	}
+	return nil
+}
+
+// Dissolve removes all tracks from a group and locks them.
+func (d Detector) Dissolve(ctx context.Context, groupID string) error {
+	group, err := d.store.FindGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if len(group) == 0 {
+		return db.ErrTracksNotFound
+	}
+
+	for _, t := range group {
+		if err := d.store.SetGroup(ctx, t.SpotifyID, "", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShareLyrics copies the lyrics of a track to all members of its group that do not have lyrics yet.
+// It returns the tracks that have been updated.
+func ShareLyrics(ctx context.Context, s groupStore, t *db.Track) ([]*db.Track, error) {
+	if t.GroupID == "" || !t.Loaded {
+		return nil, nil
+	}
+
+	group, err := s.FindGroup(ctx, t.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []*db.Track
+	for _, member := range group {
+		if member.SpotifyID == t.SpotifyID || member.Loaded {
+			continue
+		}
+		copyLyrics(t, member)
+		if err := s.Save(ctx, member); err != nil {
+			return updated, err
+		}
+		updated = append(updated, member)
+	}
+	return updated, nil
+}
+
+func shareLyrics(ctx context.Context, s groupStore, group []*db.Track) (int, error) {
+	var source *db.Track
+	for _, t := range group {
+		if t.Loaded {
+			source = t
+			break
+		}
+	}
+	if source == nil {
+		return 0, nil
+	}
+
+	n := 0
+	for _, t := range group {
+		if t.Loaded {
+			continue
+		}
+		copyLyrics(source, t)
+		if err := s.Save(ctx, t); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func copyLyrics(from, to *db.Track) {
+	to.Lyrics = from.Lyrics
+	to.Language = from.Language
+	to.Loaded = true
+	to.LyricsImportErrorCount = 0
+}
+
+func groupIDOf(tracks []*db.Track) string {
+	ids := make([]string, len(tracks))
+	for i := range tracks {
+		ids[i] = tracks[i].SpotifyID
+	}
+	return lowestID(ids)
+}
+
+func lowestID(ids []string) string {
+	sort.Strings(ids)
+	return ids[0]
+}
+
+func New(s store) Detector {
+	return Detector{store: s}
+}
+
+type union struct {
+	parent []int
+}
+
+// add adds a new set and returns its index.
+func (u *union) add() int {
+	u.parent = append(u.parent, len(u.parent))
+	return len(u.parent) - 1
+}
+
+func (u *union) find(i int) int {
+	for u.parent[i] != i {
+		u.parent[i] = u.parent[u.parent[i]]
+		i = u.parent[i]
+	}
+	return u.parent[i]
+}
+
+func (u *union) join(a, b int) {
+	u.parent[u.find(a)] = u.find(b)
+}
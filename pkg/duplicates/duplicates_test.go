@@ -0,0 +1,206 @@
+package duplicates
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type storeMock struct {
+	mock.Mock
+}
+
+func (s *storeMock) IterateTracks() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := s.MethodCalled("IterateTracks", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
+}
+func (s *storeMock) FindTrack(ctx context.Context, spotifyID string) (*db.Track, error) {
+	args := s.Called(spotifyID)
+	return args.Get(0).(*db.Track), args.Error(1)
+}
+func (s *storeMock) FindGroup(ctx context.Context, groupID string) ([]*db.Track, error) {
+	args := s.Called(groupID)
+	return args.Get(0).([]*db.Track), args.Error(1)
+}
+func (s *storeMock) SetGroup(ctx context.Context, spotifyID, groupID string, locked bool) error {
+	return s.Called(spotifyID, groupID, locked).Error(0)
+}
+func (s *storeMock) Save(ctx context.Context, track *db.Track) error {
+	return s.Called(track).Error(0)
+}
+
+var _ store = &storeMock{}
+
+func TestKey(t *testing.T) {
+	testCases := []struct {
+		artist, title string
+		same          []string
+	}{
+		{"Queen", "Bohemian Rhapsody", []string{"Bohemian Rhapsody - Remastered 2011", "Bohemian Rhapsody (Live Aid)", "Bohemian Rhapsody - Live at Wembley '86", "bohemian rhapsody"}},
+		{"Eminem", "Stan", []string{"Stan (feat. Dido)", "Stan [Explicit]", "Stan - Single Version"}},
+		{"Eminem, Dido", "Stan", []string{"Stan"}},
+	}
+
+	for _, tc := range testCases {
+		for _, title := range tc.same {
+			t.Run(title, func(t *testing.T) {
+				assert.Equal(t, Key(tc.artist, tc.title), Key(tc.artist, title))
+			})
+		}
+	}
+
+	assert.NotEqual(t, Key("Queen", "Bohemian Rhapsody"), Key("Queen", "Bohemian Rhapsody (Instrumental)"), "instrumentals have different lyrics")
+	assert.NotEqual(t, Key("Queen", "Bohemian Rhapsody"), Key("Panic! At The Disco", "Bohemian Rhapsody"))
+	assert.NotEqual(t, Key("Jay-Z", "Run This Town - Part 1"), Key("Jay-Z", "Run This Town - Part 2"))
+}
+
+func TestKey__qualifiers_are_whole_words(t *testing.T) {
+	testCases := []struct {
+		title, other string
+	}{
+		{"Somebody", "Somebody (Olive Tree)"},
+		{"Love Song", "Love Song (Monologue)"},
+		{"Hello", "Hello (Credits)"},
+		{"Song", "Song - Deliver Me"},
+		{"Song", "Song - Albumen"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.other, func(t *testing.T) {
+			assert.NotEqual(t, Key("Artist", tc.title), Key("Artist", tc.other))
+		})
+	}
+
+	assert.Equal(t, Key("Artist", "Song"), Key("Artist", "Song (ft. Someone)"))
+	assert.Equal(t, Key("Artist", "Song"), Key("Artist", "Song - Remastered"))
+}
+
+func TestDetector_Detect(t *testing.T) {
+	t.Run("groups tracks and shares lyrics", func(t *testing.T) {
+		album := &db.Track{SpotifyID: "b", Artist: "Queen", Name: "Bohemian Rhapsody", Loaded: true, Lyrics: "Is this the real life?", Language: "english"}
+		remaster := &db.Track{SpotifyID: "a", Artist: "Queen", Name: "Bohemian Rhapsody - Remastered 2011", ISRC: "GBUM71029604"}
+		compilation := &db.Track{SpotifyID: "c", Artist: "Queen", Name: "Bohemian Rhapsody (from the film)", ISRC: "gbum71029604"}
+		other := &db.Track{SpotifyID: "d", Artist: "Queen", Name: "Under Pressure", GroupID: "x"}
+		locked := &db.Track{SpotifyID: "e", Artist: "Queen", Name: "Bohemian Rhapsody", GroupLocked: true}
+
+		s := new(storeMock)
+		s.On("IterateTracks", "").Return([]*db.Track{album, remaster, compilation}, "next", nil)
+		s.On("IterateTracks", "next").Return([]*db.Track{other, locked}, "", nil)
+		s.On("SetGroup", "a", "a", false).Return(nil)
+		s.On("SetGroup", "b", "a", false).Return(nil)
+		s.On("SetGroup", "c", "a", false).Return(nil)
+		s.On("SetGroup", "d", "", false).Return(nil)
+		s.On("FindTrack", "b").Return(album, nil)
+		s.On("FindTrack", "a").Return(remaster, nil)
+		s.On("FindTrack", "c").Return(compilation, nil)
+		s.On("Save", remaster).Return(nil)
+		s.On("Save", compilation).Return(nil)
+
+		r, err := New(s).Detect(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Groups: 1, LyricsShared: 2, TracksChanged: 4}, r)
+		assert.Equal(t, album.Lyrics, remaster.Lyrics)
+		assert.True(t, compilation.Loaded)
+		s.AssertExpectations(t)
+		s.AssertNotCalled(t, "SetGroup", "e", mock.Anything, mock.Anything)
+	})
+
+	t.Run("loads tracks only to share lyrics", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("IterateTracks", "").Return([]*db.Track{
+			{SpotifyID: "a", Artist: "Queen", Name: "Bohemian Rhapsody", GroupID: "a", Loaded: true},
+			{SpotifyID: "b", Artist: "Queen", Name: "Bohemian Rhapsody - Remastered 2011", GroupID: "a", Loaded: true},
+			{SpotifyID: "c", Artist: "Queen", Name: "Under Pressure"},
+			{SpotifyID: "d", Artist: "Queen", Name: "Under Pressure (Live)"},
+		}, "", nil)
+		s.On("SetGroup", "c", "c", false).Return(nil)
+		s.On("SetGroup", "d", "c", false).Return(nil)
+
+		r, err := New(s).Detect(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Groups: 2, TracksChanged: 2}, r)
+		s.AssertExpectations(t)
+		s.AssertNotCalled(t, "FindTrack", mock.Anything)
+	})
+}
+
+func TestDetector_Merge(t *testing.T) {
+	t.Run("merges tracks and their groups", func(t *testing.T) {
+		a := &db.Track{SpotifyID: "a", GroupID: "a"}
+		b := &db.Track{SpotifyID: "b", GroupID: "a", Loaded: true, Lyrics: "la la la"}
+		c := &db.Track{SpotifyID: "c"}
+
+		s := new(storeMock)
+		s.On("FindTrack", "b").Return(b, nil)
+		s.On("FindTrack", "c").Return(c, nil)
+		s.On("FindGroup", "a").Return([]*db.Track{a, b}, nil)
+		s.On("SetGroup", mock.Anything, "a", true).Times(3).Return(nil)
+		s.On("Save", a).Return(nil)
+		s.On("Save", c).Return(nil)
+
+		groupID, tracks, err := New(s).Merge(context.Background(), []string{"c", "b"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "a", groupID)
+		assert.Len(t, tracks, 3)
+		assert.Equal(t, "la la la", c.Lyrics)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("requires at least two tracks", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("FindTrack", "a").Return(&db.Track{SpotifyID: "a"}, nil)
+
+		_, _, err := New(s).Merge(context.Background(), []string{"a", "a"})
+
+		assert.ErrorIs(t, err, ErrNotEnoughTracks)
+	})
+}
+
+func TestDetector_Unmerge(t *testing.T) {
+	t.Run("removes track from group and dissolves groups with a single track", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("FindGroup", "a").Return([]*db.Track{{SpotifyID: "a"}, {SpotifyID: "b"}}, nil)
+		s.On("SetGroup", "b", "", true).Return(nil)
+		s.On("SetGroup", "a", "", true).Return(nil)
+
+		err := New(s).Unmerge(context.Background(), "a", "b")
+
+		assert.Nil(t, err)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("returns error if track is not part of the group", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("FindGroup", "a").Return([]*db.Track{{SpotifyID: "a"}, {SpotifyID: "b"}}, nil)
+
+		err := New(s).Unmerge(context.Background(), "a", "c")
+
+		assert.ErrorIs(t, err, db.ErrTrackNotFound)
+	})
+}
+
+func TestShareLyrics(t *testing.T) {
+	source := &db.Track{SpotifyID: "a", GroupID: "a", Loaded: true, Lyrics: "la la la", Language: "english"}
+	withLyrics := &db.Track{SpotifyID: "b", GroupID: "a", Loaded: true, Lyrics: "other lyrics"}
+	withoutLyrics := &db.Track{SpotifyID: "c", GroupID: "a", LyricsImportErrorCount: 1}
+
+	s := new(storeMock)
+	s.On("FindGroup", "a").Return([]*db.Track{source, withLyrics, withoutLyrics}, nil)
+	s.On("Save", withoutLyrics).Return(nil)
+
+	updated, err := ShareLyrics(context.Background(), s, source)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*db.Track{withoutLyrics}, updated)
+	assert.Equal(t, "other lyrics", withLyrics.Lyrics, "should not overwrite existing lyrics")
+	assert.Equal(t, "la la la", withoutLyrics.Lyrics)
+	assert.Equal(t, 0, withoutLyrics.LyricsImportErrorCount)
+	s.AssertExpectations(t)
+}
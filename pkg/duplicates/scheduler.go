@@ -0,0 +1,99 @@
+package duplicates
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/logging"
+	"sync"
+)
+
+type detector interface {
+	Detect(ctx context.Context) (Result, error)
+}
+
+// Scheduler runs the duplicate detection in the background, so imports do not wait for it. Only one detection runs at
+// a time. Detections requested while one is running are merged into a single detection that starts afterwards.
+type Scheduler struct {
+	detector detector
+
+	running bool
+	pending bool
+	closed  bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	sync.Mutex
+}
+
+// Schedule starts a detection in the background, or once the running detection has finished. The detection outlives
+// ctx, but keeps its logger.
+func (s *Scheduler) Schedule(ctx context.Context) {
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return
+	}
+	if s.running {
+		s.pending = true
+		return
+	}
+
+	s.running = true
+	runCtx, cancel := context.WithCancel(logging.Detach(ctx))
+	s.cancel = cancel
+	s.wg.Add(1)
+	go s.run(runCtx)
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+	logger := logging.FromContext(ctx)
+
+	for {
+		r, err := s.detector.Detect(ctx)
+		if err != nil {
+			logger.Warn("could not detect duplicate tracks", "error", err)
+		} else {
+			logger.Debug("detected duplicate tracks", "groups", r.Groups, "changed", r.TracksChanged, "shared", r.LyricsShared)
+		}
+
+		s.Lock()
+		if !s.pending || s.closed {
+			s.running = false
+			s.pending = false
+			s.cancel()
+			s.cancel = nil
+			s.Unlock()
+			return
+		}
+		s.pending = false
+		s.Unlock()
+	}
+}
+
+// Shutdown cancels a running detection and prevents new detections from being started. Groups are detected again by
+// the next detection. Shutdown waits until the detection has stopped or ctx is done.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Lock()
+	s.closed = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func NewScheduler(d detector) *Scheduler {
+	return &Scheduler{detector: d}
+}
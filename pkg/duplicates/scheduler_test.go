@@ -0,0 +1,85 @@
+package duplicates
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingDetector blocks every detection until it is released or its context is done.
+type blockingDetector struct {
+	started chan struct{}
+	release chan struct{}
+
+	sync.Mutex
+	calls int
+}
+
+func (d *blockingDetector) Detect(ctx context.Context) (Result, error) {
+	d.Lock()
+	d.calls++
+	d.Unlock()
+	d.started <- struct{}{}
+
+	select {
+	case <-d.release:
+		return Result{}, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+func (d *blockingDetector) Calls() int {
+	d.Lock()
+	defer d.Unlock()
+	return d.calls
+}
+
+func newBlockingDetector() *blockingDetector {
+	return &blockingDetector{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func TestScheduler_Schedule(t *testing.T) {
+	t.Run("merges detections requested while one is running", func(t *testing.T) {
+		d := newBlockingDetector()
+		s := NewScheduler(d)
+
+		s.Schedule(context.Background())
+		<-d.started
+		s.Schedule(context.Background())
+		s.Schedule(context.Background())
+
+		d.release <- struct{}{}
+		<-d.started
+		d.release <- struct{}{}
+
+		assert.Nil(t, s.Shutdown(context.Background()))
+		assert.Equal(t, 2, d.Calls())
+	})
+
+	t.Run("does not start detections after shutdown", func(t *testing.T) {
+		d := newBlockingDetector()
+		s := NewScheduler(d)
+		assert.Nil(t, s.Shutdown(context.Background()))
+
+		s.Schedule(context.Background())
+
+		assert.Nil(t, s.Shutdown(context.Background()))
+		assert.Equal(t, 0, d.Calls())
+	})
+}
+
+func TestScheduler_Shutdown(t *testing.T) {
+	d := newBlockingDetector()
+	s := NewScheduler(d)
+	s.Schedule(context.Background())
+	<-d.started
+	s.Schedule(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, s.Shutdown(ctx), "running detections are cancelled")
+	assert.Equal(t, 1, d.Calls(), "pending detections are dropped")
+}
@@ -0,0 +1,28 @@
+// Package frontend contains the compiled single page application. The files are embedded at compile time, so the
+// frontend must be built using `make frontend` before building the binary.
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+const root = "build/public"
+
+//go:embed build
+var files embed.FS
+
+// FS returns the files of the compiled frontend.
+func FS() fs.FS {
+	sub, err := fs.Sub(files, root)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Built reports whether the frontend has been built before compiling the binary.
+func Built() bool {
+	_, err := fs.Stat(FS(), "index.html")
+	return err == nil
+}
@@ -0,0 +1,13 @@
+package frontend
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"testing"
+)
+
+func TestFS(t *testing.T) {
+	_, err := fs.Stat(FS(), "README.md")
+
+	assert.ErrorIs(t, err, fs.ErrNotExist, "only the compiled frontend should be served")
+}
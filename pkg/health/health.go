@@ -0,0 +1,142 @@
+// Package health implements the liveness and readiness endpoints used by container orchestrators.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+var errTimeout = errors.New("check timed out")
+
+// Check tests a single dependency.
+type Check struct {
+	Name string
+	// Optional checks are reported as warnings and do not render the service unready if they fail.
+	Optional bool
+	Run      func(ctx context.Context) error
+}
+
+type Result struct {
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Checker runs all checks concurrently. Each check is cancelled after the timeout.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func New(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Run executes all checks. The report fails if a required check fails.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			r := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = r
+			if r.Status == StatusFail || (r.Status == StatusWarn && report.Status == StatusOK) {
+				report.Status = r.Status
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// not every check respects the context, do not wait for them
+		err = errTimeout
+	}
+
+	r := Result{
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		r.Status = StatusFail
+		if check.Optional {
+			r.Status = StatusWarn
+		}
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Readiness returns a handler responding with the report of all checks. The status code is 503 if a required check
+// fails.
+func (c *Checker) Readiness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+// Liveness returns a handler that responds as long as the process is able to serve requests. It does not check any
+// dependency, so an unavailable database does not cause restarts.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func passing(ctx context.Context) error {
+	return nil
+}
+
+func failing(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestChecker_Run(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"all checks pass", []Check{{Name: "a", Run: passing}, {Name: "b", Run: passing}}, StatusOK},
+		{"required check fails", []Check{{Name: "a", Run: passing}, {Name: "b", Run: failing}}, StatusFail},
+		{"optional check fails", []Check{{Name: "a", Run: passing}, {Name: "b", Run: failing, Optional: true}}, StatusWarn},
+		{"required and optional checks fail", []Check{{Name: "a", Run: failing}, {Name: "b", Run: failing, Optional: true}}, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(time.Second, tt.checks...).Run(context.Background())
+
+			assert.Equal(t, tt.want, r.Status)
+			assert.Len(t, r.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestChecker_Run__reports_errors(t *testing.T) {
+	r := New(time.Second, Check{Name: "mongo", Run: failing}).Run(context.Background())
+
+	assert.Equal(t, StatusFail, r.Checks["mongo"].Status)
+	assert.Equal(t, "connection refused", r.Checks["mongo"].Error)
+}
+
+func TestChecker_Run__times_out(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	slow := func(ctx context.Context) error {
+		<-block
+		return nil
+	}
+
+	r := New(10*time.Millisecond, Check{Name: "slow", Run: slow}).Run(context.Background())
+
+	assert.Equal(t, StatusFail, r.Checks["slow"].Status)
+	assert.Equal(t, errTimeout.Error(), r.Checks["slow"].Error)
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      Check
+		wantStatus int
+	}{
+		{"ready", Check{Name: "mongo", Run: passing}, http.StatusOK},
+		{"optional check fails", Check{Name: "lyrics", Run: failing, Optional: true}, http.StatusOK},
+		{"not ready", Check{Name: "mongo", Run: failing}, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			New(time.Second, tt.check).Readiness().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json; charset=UTF-8", rec.Header().Get("Content-Type"))
+
+			var report Report
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Contains(t, report.Checks, tt.check.Name)
+		})
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Liveness().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
@@ -0,0 +1,192 @@
+// Package logging writes structured log entries as JSON lines. Loggers are passed along using a context, so all
+// entries written while handling a request or running a sync share the same request or sync id.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name, e.g. "info".
+func ParseLevel(name string) (Level, error) {
+	for l, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, expected one of debug, info, warn or error", name)
+}
+
+type output struct {
+	sync.Mutex
+	w io.Writer
+}
+
+// Logger writes entries of at least its level. Every entry contains the fields of the logger.
+type Logger struct {
+	out    *output
+	level  Level
+	fields []interface{}
+	now    func() time.Time
+}
+
+// With returns a logger that adds the key value pairs to every entry.
+func (l *Logger) With(keyValues ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyValues...)
+
+	return &Logger{
+		out:    l.out,
+		level:  l.level,
+		fields: fields,
+		now:    l.now,
+	}
+}
+
+func (l *Logger) Debug(msg string, keyValues ...interface{}) {
+	l.log(LevelDebug, msg, keyValues)
+}
+
+func (l *Logger) Info(msg string, keyValues ...interface{}) {
+	l.log(LevelInfo, msg, keyValues)
+}
+
+func (l *Logger) Warn(msg string, keyValues ...interface{}) {
+	l.log(LevelWarn, msg, keyValues)
+}
+
+func (l *Logger) Error(msg string, keyValues ...interface{}) {
+	l.log(LevelError, msg, keyValues)
+}
+
+// Enabled reports whether entries of the level are written.
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) log(level Level, msg string, keyValues []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	entry := map[string]interface{}{
+		"time":  l.now().UTC().Format(time.RFC3339Nano),
+		"level": level.String(),
+		"msg":   msg,
+	}
+	addFields(entry, l.fields)
+	addFields(entry, keyValues)
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(map[string]interface{}{
+			"time":  entry["time"],
+			"level": entry["level"],
+			"msg":   msg,
+			"error": fmt.Sprintf("could not encode log entry: %s", err),
+		})
+	}
+
+	l.out.Lock()
+	defer l.out.Unlock()
+	_, _ = l.out.w.Write(append(b, '\n'))
+}
+
+func addFields(entry map[string]interface{}, keyValues []interface{}) {
+	for i := 0; i < len(keyValues); i += 2 {
+		key := fmt.Sprintf("%v", keyValues[i])
+		if i+1 == len(keyValues) {
+			entry[key] = nil
+			break
+		}
+
+		switch v := keyValues[i+1].(type) {
+		case error:
+			entry[key] = v.Error()
+		case time.Duration:
+			entry[key] = v.String()
+		case fmt.Stringer:
+			entry[key] = v.String()
+		default:
+			entry[key] = v
+		}
+	}
+}
+
+// New creates a logger writing entries of at least the given level to w.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{
+		out:   &output{w: w},
+		level: level,
+		now:   time.Now,
+	}
+}
+
+var (
+	defaultLogger = New(os.Stderr, LevelInfo)
+	defaultMutex  sync.RWMutex
+)
+
+// Default returns the logger used if a context does not carry a logger.
+func Default() *Logger {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultLogger
+}
+
+// SetDefault replaces the logger used if a context does not carry a logger.
+func SetDefault(l *Logger) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultLogger = l
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger.
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of ctx or the default logger.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return Default()
+}
+
+// Detach returns a new background context carrying the logger of ctx. It is used to start work that outlives a
+// request, e.g. a sync, without losing the request id.
+func Detach(ctx context.Context) context.Context {
+	return WithLogger(context.Background(), FromContext(ctx))
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(level Level) (*Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l := New(buf, level)
+	l.now = func() time.Time {
+		return time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC)
+	}
+	return l, buf
+}
+
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger__writes_json_lines(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo)
+
+	l.With("request_id", "abc").Info("request handled", "status", 200, "duration", 1500*time.Millisecond, "error", errors.New("failed"))
+
+	entries := decodeEntries(t, buf)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{
+		"time":       "2022-05-01T12:00:00Z",
+		"level":      "info",
+		"msg":        "request handled",
+		"request_id": "abc",
+		"status":     float64(200),
+		"duration":   "1.5s",
+		"error":      "failed",
+	}, entries[0])
+}
+
+func TestLogger__skips_entries_below_level(t *testing.T) {
+	l, buf := newTestLogger(LevelWarn)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	entries := decodeEntries(t, buf)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "warn", entries[0]["level"])
+	assert.Equal(t, "error", entries[1]["level"])
+	assert.False(t, l.Enabled(LevelInfo))
+	assert.True(t, l.Enabled(LevelError))
+}
+
+func TestLogger_With__does_not_modify_parent(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo)
+
+	_ = l.With("sync_id", "1")
+	l.Info("message")
+
+	entries := decodeEntries(t, buf)
+	assert.NotContains(t, entries[0], "sync_id")
+}
+
+func TestLogger__odd_number_of_fields(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo)
+
+	l.Info("message", "key")
+
+	entries := decodeEntries(t, buf)
+	assert.Contains(t, entries[0], "key")
+	assert.Nil(t, entries[0]["key"])
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{"debug", LevelDebug, false},
+		{"INFO", LevelInfo, false},
+		{"warn", LevelWarn, false},
+		{"error", LevelError, false},
+		{"verbose", LevelInfo, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	l, _ := newTestLogger(LevelDebug)
+
+	assert.Same(t, Default(), FromContext(context.Background()))
+	assert.Same(t, l, FromContext(WithLogger(context.Background(), l)))
+}
+
+func TestDetach(t *testing.T) {
+	l, _ := newTestLogger(LevelDebug)
+	ctx, cancel := context.WithCancel(WithLogger(context.Background(), l))
+	cancel()
+
+	detached := Detach(ctx)
+
+	assert.Nil(t, detached.Err())
+	assert.Same(t, l, FromContext(detached))
+}
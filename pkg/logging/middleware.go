@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is read from incoming requests, e.g. set by a reverse proxy, and returned in every response.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// NewID returns a random id used to correlate log entries.
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestID returns the id of the request handled using ctx.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware assigns an id to every request and stores a logger including this id in the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = NewID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = WithLogger(ctx, FromContext(ctx).With("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware__generates_request_id(t *testing.T) {
+	var id string
+	var logger *Logger
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r.Context())
+		logger = FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, []interface{}{"request_id", id}, logger.fields)
+}
+
+func TestMiddleware__reuses_request_id_of_request(t *testing.T) {
+	var id string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "proxy-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "proxy-id", id)
+	assert.Equal(t, "proxy-id", rec.Header().Get(RequestIDHeader))
+}
+
+func TestMiddleware__ignores_long_request_ids(t *testing.T) {
+	var id string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, strings.Repeat("a", 65))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Len(t, id, 16)
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/event"
+	"time"
+)
+
+// MongoMonitor logs every database command at debug level using the logger of the command's context, so commands can
+// be traced back to the request or sync they were sent by.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			FromContext(ctx).Debug("mongo command succeeded",
+				"command", e.CommandName,
+				"duration", time.Duration(e.DurationNanos))
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			FromContext(ctx).Warn("mongo command failed",
+				"command", e.CommandName,
+				"duration", time.Duration(e.DurationNanos),
+				"error", e.Failure)
+		},
+	}
+}
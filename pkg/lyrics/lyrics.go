@@ -1,15 +1,18 @@
 package lyrics
 
 import (
+	"context"
 	"errors"
-	lyrics "github.com/imba28/lyric-api-go"
+	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
-	ErrBusy = errors.New("sync already started")
+	ErrBusy   = errors.New("sync already started")
+	ErrClosed = errors.New("syncer has been shut down")
 )
 
 type Result struct {
@@ -19,33 +22,70 @@ type Result struct {
 
 type Fetcher interface {
 	Fetch(*db.Track) error
-	FetchAll([]*db.Track) (<-chan Result, error)
+	FetchAll(context.Context, []*db.Track) (<-chan Result, error)
 }
 
 type provider interface {
 	Search(string, string) (string, error)
 }
 
-func fetchTrackLyrics(t *db.Track, l provider, d languageDetector) error {
-	artist := t.Artist
-	if strings.Index(t.Artist, ", ") > -1 {
-		artist = strings.Split(artist, ", ")[0]
+// FetchOptions change how the lyrics of a single track are searched, e.g. to retry a track whose lyrics could not be
+// found.
+type FetchOptions struct {
+	// Provider limits the search to the lyrics provider with this name, see Providers.
+	Provider string
+	// Artist and Title replace the first artist and the name of the track in the search.
+	Artist string
+	Title  string
+}
+
+// fetchTrackLyrics fetches, cleans and validates the lyrics of the track. Lyrics with a low confidence are not loaded,
+// but flagged for review and ErrLowConfidence is returned. If the provider states that the track is an instrumental,
+// the track is marked as instrumental instead, so its lyrics are not fetched again.
+func fetchTrackLyrics(t *db.Track, l provider, d languageDetector, opts FetchOptions) error {
+	artist, title := opts.Artist, opts.Title
+	if artist == "" {
+		artist = t.Artist
+		if strings.Index(t.Artist, ", ") > -1 {
+			artist = strings.Split(artist, ", ")[0]
+		}
 	}
-	lyric, err := l.Search(artist, t.Name)
+	if title == "" {
+		title = t.Name
+	}
+	lyric, err := l.Search(artist, title)
 	if err != nil {
 		return err
 	}
+	lyric = clean(lyric, title)
 
-	t.Lyrics = lyric
-	t.Loaded = true
+	language, languageErr := d.Detect(lyric)
+	if languageErr != nil {
+		language = "english"
+	}
 
-	languageOfLyrics, err := d.Detect(t.Lyrics)
-	if err != nil {
-		t.Language = "english"
-	} else {
-		t.Language = languageOfLyrics
+	v := validate(title, lyric, languageErr)
+	if v.instrumental() {
+		t.Instrumental = true
+		t.LyricsReview = nil
+		return nil
+	}
+	if v.flagged() {
+		t.LyricsReview = &db.LyricsReview{
+			Lyrics:    lyric,
+			Language:  language,
+			Score:     v.score,
+			Issues:    v.issues,
+			FlaggedAt: time.Now(),
+		}
+		return fmt.Errorf("%w: %s", ErrLowConfidence, strings.Join(v.issues, ", "))
 	}
 
+	t.Lyrics = lyric
+	t.Loaded = true
+	t.Language = language
+	t.LyricsReview = nil
+
 	return nil
 }
 
@@ -54,23 +94,40 @@ type AsyncFetcher struct {
 	ready            chan struct{}
 	fetchingQueue    chan *db.Track
 	lyricsFetcher    provider
+	providers        providerChain
 	languageDetector languageDetector
 }
 
 func (s AsyncFetcher) Fetch(t *db.Track) error {
-	err := fetchTrackLyrics(t, s.lyricsFetcher, s.languageDetector)
+	err := fetchTrackLyrics(t, s.lyricsFetcher, s.languageDetector, FetchOptions{})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s AsyncFetcher) FetchAll(tracks []*db.Track) (<-chan Result, error) {
+// FetchWith fetches the lyrics of the track using the given options. ErrUnknownProvider is returned if the requested
+// provider is not available.
+func (s AsyncFetcher) FetchWith(t *db.Track, opts FetchOptions) error {
+	var p provider = s.lyricsFetcher
+	if opts.Provider != "" {
+		chain, err := s.providers.only(opts.Provider)
+		if err != nil {
+			return err
+		}
+		p = chain
+	}
+	return fetchTrackLyrics(t, p, s.languageDetector, opts)
+}
+
+// FetchAll fetches the lyrics of the tracks concurrently. If ctx is cancelled, no more tracks are queued, but the
+// results of tracks that are already being fetched are still sent before the channel is closed.
+func (s AsyncFetcher) FetchAll(ctx context.Context, tracks []*db.Track) (<-chan Result, error) {
 	results := make(chan Result)
 	var wg sync.WaitGroup
 
 	queue := s.initWorkers(results, &wg)
-	go s.run(tracks, queue, &wg)
+	go s.run(ctx, tracks, queue, &wg)
 
 	return results, nil
 }
@@ -80,14 +137,12 @@ type languageDetector interface {
 }
 
 func New(geniusAPIToken string, concurrencyLevel int, d languageDetector) AsyncFetcher {
-	provider := lyrics.New(
-		lyrics.WithGeniusLyrics(geniusAPIToken),
-		lyrics.WithSongLyrics(),
-	)
+	providers := newProviderChain(geniusAPIToken)
 	return AsyncFetcher{
 		ready:            make(chan struct{}, 1),
 		concurrency:      concurrencyLevel,
-		lyricsFetcher:    &provider,
+		lyricsFetcher:    providers,
+		providers:        providers,
 		languageDetector: d,
 	}
 }
@@ -99,7 +154,7 @@ func (s *AsyncFetcher) initWorkers(results chan<- Result, wg *sync.WaitGroup) ch
 	for i := 0; i < s.concurrency; i++ {
 		go func() {
 			for t := range c {
-				err := fetchTrackLyrics(t, s.lyricsFetcher, s.languageDetector)
+				err := fetchTrackLyrics(t, s.lyricsFetcher, s.languageDetector, FetchOptions{})
 				results <- Result{Track: t, Err: err}
 				wg.Done()
 			}
@@ -113,12 +168,22 @@ func (s *AsyncFetcher) initWorkers(results chan<- Result, wg *sync.WaitGroup) ch
 	return c
 }
 
-func (s *AsyncFetcher) run(tracks []*db.Track, queue chan<- *db.Track, wg *sync.WaitGroup) {
+func (s *AsyncFetcher) run(ctx context.Context, tracks []*db.Track, queue chan<- *db.Track, wg *sync.WaitGroup) {
 	defer close(queue)
 
+queueing:
 	for i := range tracks {
+		if ctx.Err() != nil {
+			break
+		}
+
 		wg.Add(1)
-		queue <- tracks[i]
+		select {
+		case queue <- tracks[i]:
+		case <-ctx.Done():
+			wg.Done()
+			break queueing
+		}
 	}
 
 	wg.Wait()
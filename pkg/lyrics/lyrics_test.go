@@ -1,6 +1,7 @@
 package lyrics
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
@@ -32,10 +33,16 @@ func (l *languageDetectorMock) Detect(s string) (string, error) {
 
 var _ languageDetector = &languageDetectorMock{}
 
+// testLyrics passes the validation of fetched lyrics.
+const testLyrics = `This is a song about nothing at all
+La la la la, sing it with me
+La la la la, till the morning comes
+And then we sing it all again`
+
 func TestAsyncFetcher_Fetch(t *testing.T) {
 	t.Run("fetches lyrics", func(t *testing.T) {
 		artist, song := "artist", "a song"
-		expectedLyrics := "la la la la"
+		expectedLyrics := testLyrics
 		expectedLanguage := "english"
 		track := db.Track{
 			Artist: artist,
@@ -58,7 +65,7 @@ func TestAsyncFetcher_Fetch(t *testing.T) {
 
 	t.Run("picks the first artist if track has multiple artists", func(t *testing.T) {
 		artist, song := "Eminem, Nate Dog", "'Till I Collapse"
-		expectedLyrics := "la la la la"
+		expectedLyrics := testLyrics
 		track := db.Track{
 			Artist: artist,
 			Name:   song,
@@ -76,6 +83,59 @@ func TestAsyncFetcher_Fetch(t *testing.T) {
 		providerMock.AssertExpectations(t)
 	})
 
+	t.Run("cleans lyrics", func(t *testing.T) {
+		track := db.Track{Artist: "artist", Name: "a song"}
+		providerMock := providerMock{}
+		providerMock.On("Search", "artist", "a song").Return("3 ContributorsA Song Lyrics\n"+testLyrics+"42Embed", nil)
+		languageDetector := languageDetectorMock{}
+		languageDetector.On("Detect", testLyrics).Return("english", nil)
+		fetcher := AsyncFetcher{lyricsFetcher: &providerMock, languageDetector: &languageDetector}
+
+		err := fetcher.Fetch(&track)
+
+		assert.Nil(t, err)
+		assert.Equal(t, testLyrics, track.Lyrics)
+		assert.True(t, track.Loaded)
+	})
+
+	t.Run("flags lyrics with a low confidence", func(t *testing.T) {
+		track := db.Track{Artist: "artist", Name: "a song"}
+		providerMock := providerMock{}
+		providerMock.On("Search", "artist", "a song").Return("We do not have the lyrics for this one yet", nil)
+		languageDetector := languageDetectorMock{}
+		languageDetector.On("Detect", mock.Anything).Return("english", nil)
+		fetcher := AsyncFetcher{lyricsFetcher: &providerMock, languageDetector: &languageDetector}
+
+		err := fetcher.Fetch(&track)
+
+		assert.ErrorIs(t, err, ErrLowConfidence)
+		assert.False(t, track.Loaded)
+		assert.Empty(t, track.Lyrics)
+		if assert.NotNil(t, track.LyricsReview) {
+			assert.Equal(t, "We do not have the lyrics for this one yet", track.LyricsReview.Lyrics)
+			assert.Equal(t, "english", track.LyricsReview.Language)
+			assert.Equal(t, []string{IssueTooShort, IssueBoilerplate, IssueTitleMissing}, track.LyricsReview.Issues)
+			assert.Equal(t, 0.0, track.LyricsReview.Score)
+		}
+	})
+
+	t.Run("marks instrumental tracks", func(t *testing.T) {
+		track := db.Track{Artist: "artist", Name: "a song"}
+		providerMock := providerMock{}
+		providerMock.On("Search", "artist", "a song").Return("[Instrumental]", nil)
+		languageDetector := languageDetectorMock{}
+		languageDetector.On("Detect", mock.Anything).Return("", errors.New("unknown language"))
+		fetcher := AsyncFetcher{lyricsFetcher: &providerMock, languageDetector: &languageDetector}
+
+		err := fetcher.Fetch(&track)
+
+		assert.Nil(t, err)
+		assert.True(t, track.Instrumental)
+		assert.False(t, track.Loaded)
+		assert.Empty(t, track.Lyrics)
+		assert.Nil(t, track.LyricsReview)
+	})
+
 	t.Run("returns error if provider return error", func(t *testing.T) {
 		track := db.Track{}
 		expectedErr := errors.New("something went wrong")
@@ -91,6 +151,51 @@ func TestAsyncFetcher_Fetch(t *testing.T) {
 	})
 }
 
+func TestAsyncFetcher_FetchWith(t *testing.T) {
+	var searched []string
+	source := func(name string) sourceFunc {
+		return func(artist, song string) (string, error) {
+			searched = append(searched, name+": "+artist+" - "+song)
+			return testLyrics, nil
+		}
+	}
+	providers := providerChain{{"genius", source("genius")}, {"songlyrics", source("songlyrics")}}
+	languageDetector := new(languageDetectorMock)
+	languageDetector.On("Detect", testLyrics).Return("english", nil)
+	fetcher := AsyncFetcher{lyricsFetcher: providers, providers: providers, languageDetector: languageDetector}
+
+	t.Run("overrides artist and title", func(t *testing.T) {
+		searched = nil
+		track := db.Track{Artist: "artist, other artist", Name: "a song - Remastered"}
+
+		err := fetcher.FetchWith(&track, FetchOptions{Artist: "the artist", Title: "a song"})
+
+		assert.Nil(t, err)
+		assert.True(t, track.Loaded)
+		assert.Equal(t, []string{"genius: the artist - a song"}, searched)
+	})
+
+	t.Run("uses the given provider", func(t *testing.T) {
+		searched = nil
+		track := db.Track{Artist: "artist, other artist", Name: "a song"}
+
+		err := fetcher.FetchWith(&track, FetchOptions{Provider: "songlyrics"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"songlyrics: artist - a song"}, searched)
+	})
+
+	t.Run("rejects unknown providers", func(t *testing.T) {
+		searched = nil
+		track := db.Track{Artist: "artist", Name: "a song"}
+
+		err := fetcher.FetchWith(&track, FetchOptions{Provider: "unknown"})
+
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+		assert.Empty(t, searched)
+	})
+}
+
 func TestAsyncFetcher_FetchAll(t *testing.T) {
 	t.Run("it works with different concurrency levels", withTimeout(func(t *testing.T) {
 		tests := []int{1, 2, 5, 10}
@@ -118,7 +223,7 @@ func TestAsyncFetcher_FetchAll(t *testing.T) {
 					Return(expectedLyrics, nil)
 				fetcher := AsyncFetcher{lyricsFetcher: &providerMock, concurrency: tt, languageDetector: lm}
 
-				c, err := fetcher.FetchAll(tracks)
+				c, err := fetcher.FetchAll(context.Background(), tracks)
 
 				for r := range c {
 					assert.Nil(t, r.Err)
@@ -150,7 +255,7 @@ func TestAsyncFetcher_FetchAll(t *testing.T) {
 			Return(expectedLyrics, expectedError)
 		fetcher := AsyncFetcher{lyricsFetcher: &providerMock, concurrency: 2, languageDetector: lm}
 
-		c, err := fetcher.FetchAll(tracks)
+		c, err := fetcher.FetchAll(context.Background(), tracks)
 		assert.Nil(t, err)
 
 		for r := range c {
@@ -164,6 +269,29 @@ func TestAsyncFetcher_FetchAll(t *testing.T) {
 		}
 		providerMock.AssertExpectations(t)
 	}, 2*time.Second))
+
+	t.Run("it stops queueing tracks if the context is cancelled", withTimeout(func(t *testing.T) {
+		tracks := []*db.Track{
+			{Artist: "a", Name: "a"},
+			{Artist: "b", Name: "b"},
+			{Artist: "c", Name: "c"},
+		}
+
+		providerMock := providerMock{}
+		fetcher := AsyncFetcher{lyricsFetcher: &providerMock, concurrency: 1, languageDetector: new(languageDetectorMock)}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		c, err := fetcher.FetchAll(ctx, tracks)
+		assert.Nil(t, err)
+
+		n := 0
+		for range c {
+			n++
+		}
+		assert.Equal(t, 0, n)
+		providerMock.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+	}, 2*time.Second))
 }
 
 type testerFunc func(t *testing.T)
@@ -0,0 +1,85 @@
+package lyrics
+
+import (
+	"errors"
+	"github.com/imba28/lyric-api-go/genius"
+	"github.com/imba28/lyric-api-go/songlyrics"
+	"github.com/imba28/spolyr/pkg/metrics"
+	"time"
+)
+
+var (
+	// ErrUnknownProvider is returned if lyrics are requested from a provider that is not available.
+	ErrUnknownProvider = errors.New("unknown lyrics provider")
+
+	errNoProviders    = errors.New("no lyrics providers configured")
+	errLyricsNotFound = errors.New("lyrics not found")
+)
+
+type source interface {
+	Fetch(artist, song string) (string, error)
+}
+
+type namedSource struct {
+	name   string
+	source source
+}
+
+// providerChain asks its sources for lyrics one by one, the same way lyric-api-go does, and records the requests of
+// every source.
+type providerChain []namedSource
+
+func (c providerChain) Search(artist, song string) (string, error) {
+	if len(c) == 0 {
+		return "", errNoProviders
+	}
+
+	for _, s := range c {
+		start := time.Now()
+		lyric, err := s.source.Fetch(artist, song)
+		if err != nil {
+			metrics.ObserveLyricsRequest(s.name, time.Since(start), err)
+			return lyric, err
+		}
+		// lyric-api-go treats very short results as empty pages
+		if len(lyric) > 5 {
+			metrics.ObserveLyricsRequest(s.name, time.Since(start), nil)
+			return lyric, nil
+		}
+		metrics.ObserveLyricsRequest(s.name, time.Since(start), errLyricsNotFound)
+	}
+	return "", errLyricsNotFound
+}
+
+func (c providerChain) names() []string {
+	names := make([]string, len(c))
+	for i := range c {
+		names[i] = c[i].name
+	}
+	return names
+}
+
+// only returns a chain containing only the provider with the given name.
+func (c providerChain) only(name string) (providerChain, error) {
+	for _, s := range c {
+		if s.name == name {
+			return providerChain{s}, nil
+		}
+	}
+	return nil, ErrUnknownProvider
+}
+
+// Providers returns the names of the lyrics providers used with the api token in the order they are asked for lyrics.
+func Providers(geniusAPIToken string) []string {
+	return newProviderChain(geniusAPIToken).names()
+}
+
+// newProviderChain returns all providers that can be used. Genius is skipped without an api token, since every request
+// would fail and abort the search before the remaining providers are asked.
+func newProviderChain(geniusAPIToken string) providerChain {
+	var c providerChain
+	if geniusAPIToken != "" {
+		c = append(c, namedSource{name: "genius", source: genius.New(geniusAPIToken)})
+	}
+	return append(c, namedSource{name: "songlyrics", source: songlyrics.New()})
+}
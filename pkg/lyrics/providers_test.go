@@ -0,0 +1,73 @@
+package lyrics
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type sourceFunc func(artist, song string) (string, error)
+
+func (f sourceFunc) Fetch(artist, song string) (string, error) {
+	return f(artist, song)
+}
+
+func TestProviderChain_Search(t *testing.T) {
+	empty := sourceFunc(func(artist, song string) (string, error) {
+		return "", nil
+	})
+	found := sourceFunc(func(artist, song string) (string, error) {
+		return "la la la la la", nil
+	})
+	failing := sourceFunc(func(artist, song string) (string, error) {
+		return "", errors.New("rate limited")
+	})
+
+	t.Run("asks the next provider if no lyrics have been found", func(t *testing.T) {
+		c := providerChain{{"a", empty}, {"b", found}}
+
+		l, err := c.Search("artist", "song")
+
+		assert.Nil(t, err)
+		assert.Equal(t, "la la la la la", l)
+	})
+
+	t.Run("returns error of provider", func(t *testing.T) {
+		c := providerChain{{"a", failing}, {"b", found}}
+
+		_, err := c.Search("artist", "song")
+
+		assert.EqualError(t, err, "rate limited")
+	})
+
+	t.Run("returns error if no provider has found lyrics", func(t *testing.T) {
+		_, err := providerChain{{"a", empty}}.Search("artist", "song")
+		assert.ErrorIs(t, err, errLyricsNotFound)
+
+		_, err = providerChain{}.Search("artist", "song")
+		assert.ErrorIs(t, err, errNoProviders)
+	})
+}
+
+func TestNewProviderChain(t *testing.T) {
+	assert.Equal(t, []string{"genius", "songlyrics"}, newProviderChain("token").names())
+	assert.Equal(t, []string{"songlyrics"}, newProviderChain("").names(), "genius requires an api token")
+}
+
+func TestProviders(t *testing.T) {
+	assert.Equal(t, []string{"genius", "songlyrics"}, Providers("token"))
+}
+
+func TestProviderChain_only(t *testing.T) {
+	found := sourceFunc(func(artist, song string) (string, error) {
+		return "la la la la la", nil
+	})
+	c := providerChain{{"a", found}, {"b", found}}
+
+	only, err := c.only("b")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, only.names())
+
+	_, err = c.only("c")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
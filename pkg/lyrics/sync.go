@@ -1,15 +1,35 @@
 package lyrics
 
 import (
+	"context"
 	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/duplicates"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/imba28/spolyr/pkg/metrics"
 	"strings"
 	"sync"
+	"time"
 )
 
 type tracksSyncFetcherSaver interface {
-	Save(track *db.Track) error
-	TracksWithoutLyricsError() ([]*db.Track, error)
+	Save(ctx context.Context, track *db.Track) error
+	SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error)
+	TracksWithoutLyricsError() *db.TrackIterator
+	CountGroupsWithoutLyricsError(ctx context.Context) (int64, error)
+	FindGroup(ctx context.Context, groupID string) ([]*db.Track, error)
+}
+
+// syncBatchSize is the number of tracks whose lyrics are fetched at once. Only the current batch is held in memory.
+const syncBatchSize = 100
+
+// SyncResult summarizes a finished sync.
+type SyncResult struct {
+	Total      int
+	Successful int
+	Failed     int
+	// Cancelled is set if the sync has been stopped before the lyrics of all tracks have been fetched.
+	Cancelled bool
 }
 
 type Syncer struct {
@@ -20,31 +40,91 @@ type Syncer struct {
 	tracksFailed            int
 	syncLog                 []string
 
-	fetcher Fetcher
-	db      tracksSyncFetcherSaver
+	fetcher    Fetcher
+	db         tracksSyncFetcherSaver
+	onFinished func(SyncResult)
+
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	closed  bool
 
 	sync.Mutex
 }
 
-func (s *Syncer) Sync() (<-chan struct{}, error) {
-	tracks, err := s.db.TracksWithoutLyricsError()
+// Sync fetches the lyrics of all tracks without lyrics in the background. The sync outlives ctx, but keeps its logger,
+// so the entries of a sync can be traced back to the request that started it.
+func (s *Syncer) Sync(ctx context.Context) (<-chan struct{}, error) {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+		return nil, ErrBusy
+	}
+
+	s.Lock()
+	closed := s.closed
+	s.Unlock()
+	if closed {
+		<-s.ready
+		return nil, ErrClosed
+	}
+
+	total, err := s.db.CountGroupsWithoutLyricsError(ctx)
 	if err != nil {
+		<-s.ready
 		return nil, err
 	}
+	batches := &trackBatches{tracks: s.db.TracksWithoutLyricsError(), seen: make(map[string]bool)}
+	// the first batch is loaded right away, so database errors are reported to the caller
+	batch, err := batches.next(ctx)
+	if err != nil {
+		<-s.ready
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	// Shutdown might have been called while the first batch was loaded
+	if s.closed {
+		<-s.ready
+		return nil, ErrClosed
+	}
+
+	s.syncLyricsTracksCurrent = 0
+	s.tracksSuccess = 0
+	s.tracksFailed = 0
+	s.syncLyricsTrackTotal = int(total)
 
 	finished := make(chan struct{})
+	logger := logging.FromContext(ctx).With("sync_id", logging.NewID())
+	runCtx, cancel := context.WithCancel(logging.WithLogger(context.Background(), logger))
+	s.cancel = cancel
+	s.running.Add(1)
+	go s.run(runCtx, batches, batch, finished)
+	return finished, nil
+}
 
-	select {
-	case s.ready <- struct{}{}:
-		s.syncLyricsTracksCurrent = 0
-		s.tracksSuccess = 0
-		s.tracksFailed = 0
-		s.syncLyricsTrackTotal = len(tracks)
+// Shutdown cancels a running sync and prevents new syncs from being started. Lyrics that are being fetched are still
+// saved, the remaining tracks are picked up by the next sync. Shutdown waits until the sync has stopped or ctx is done.
+func (s *Syncer) Shutdown(ctx context.Context) error {
+	s.Lock()
+	s.closed = true
+	cancel := s.cancel
+	s.Unlock()
+	if cancel != nil {
+		cancel()
+	}
 
-		go s.run(tracks, finished)
-		return finished, nil
-	default:
-		return nil, ErrBusy
+	stopped := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -62,8 +142,52 @@ func (s *Syncer) TracksFailed() int {
 	return s.tracksFailed
 }
 
-func (s *Syncer) run(tracks []*db.Track, finishedSignal chan<- struct{}) {
+// OnFinished registers a function that is called with the result of every sync once it has finished.
+func (s *Syncer) OnFinished(f func(SyncResult)) {
+	s.Lock()
+	defer s.Unlock()
+	s.onFinished = f
+}
+
+func (s *Syncer) run(ctx context.Context, batches *trackBatches, batch []*db.Track, finishedSignal chan<- struct{}) {
+	logger := logging.FromContext(ctx)
+	logger.Info("lyrics sync started", "tracks", s.syncLyricsTrackTotal)
+	// fetched lyrics are saved even if the sync has been cancelled
+	storeCtx := logging.Detach(ctx)
+
+	start := time.Now()
 	defer func() {
+		defer s.running.Done()
+
+		cancelled := ctx.Err() != nil
+		s.Lock()
+		s.cancel()
+		s.cancel = nil
+		s.Unlock()
+
+		metrics.ObserveSync(time.Since(start))
+		msg := "lyrics sync finished"
+		if cancelled {
+			msg = "lyrics sync cancelled"
+		}
+		logger.Info(msg,
+			"tracks", s.syncLyricsTrackTotal,
+			"successful", s.TracksSuccess(),
+			"failed", s.TracksFailed(),
+			"duration", time.Since(start))
+
+		s.Lock()
+		onFinished := s.onFinished
+		s.Unlock()
+		if onFinished != nil {
+			onFinished(SyncResult{
+				Total:      s.syncLyricsTrackTotal,
+				Successful: s.TracksSuccess(),
+				Failed:     s.TracksFailed(),
+				Cancelled:  cancelled,
+			})
+		}
+
 		// Do not block if no one is waiting for us to end.
 		select {
 		case finishedSignal <- struct{}{}:
@@ -71,39 +195,152 @@ func (s *Syncer) run(tracks []*db.Track, finishedSignal chan<- struct{}) {
 		}
 		close(finishedSignal)
 
+		s.Lock()
 		s.syncLyricsTracksCurrent = -1
 		s.syncLog = nil
+		s.Unlock()
 		<-s.ready
 	}()
 
-	c, err := s.fetcher.FetchAll(tracks)
-	if err != nil {
-		return
+	for len(batch) > 0 {
+		c, err := s.fetcher.FetchAll(ctx, batch)
+		if err != nil {
+			logger.Error("could not fetch lyrics", "error", err)
+			return
+		}
+
+		for {
+			results, ok := receiveResults(c)
+			if !ok {
+				break
+			}
+			s.save(storeCtx, results)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		batch, err = batches.next(ctx)
+		if err != nil {
+			logger.Error("could not load tracks without lyrics", "error", err)
+			return
+		}
 	}
+}
 
-	for result := range c {
+// receiveResults waits for the next result and returns it together with all results that are ready as well, so
+// lyrics that have been fetched at the same time are saved at once. ok is false once c has been closed.
+func receiveResults(c <-chan Result) (results []Result, ok bool) {
+	result, ok := <-c
+	if !ok {
+		return nil, false
+	}
+	results = append(results, result)
+	for len(results) < syncBatchSize {
+		select {
+		case result, ok := <-c:
+			if !ok {
+				return results, true
+			}
+			results = append(results, result)
+		default:
+			return results, true
+		}
+	}
+	return results, true
+}
+
+// save stores the results of lyrics imports and shares fetched lyrics with the duplicates of the tracks.
+func (s *Syncer) save(ctx context.Context, results []Result) {
+	tracks := make([]*db.Track, len(results))
+	s.Lock()
+	for i, result := range results {
 		s.syncLyricsTracksCurrent++
 
 		if result.Err != nil {
 			s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9D\x8C %s - %s: %s", result.Track.Artist, result.Track.Name, result.Err.Error()))
-			result.Track.LyricsImportErrorCount++
+			result.Track.AddLyricsImportError(result.Err, time.Now())
 		} else {
 			result.Track.LyricsImportErrorCount = 0
 		}
+		tracks[i] = result.Track
+	}
+	s.Unlock()
 
-		err = s.db.Save(result.Track)
-		if result.Err != nil || err != nil {
-			message := result.Err
-			if err != nil {
-				message = err
+	saved, err := s.db.SaveMany(ctx, tracks)
+	for i, result := range results {
+		saveErr := err
+		if err == nil {
+			saveErr = saved[i].Err
+		}
+		s.saved(ctx, result, saveErr)
+	}
+}
+
+// saved records the outcome of a single lyrics import. err is the error that occurred while saving the track.
+func (s *Syncer) saved(ctx context.Context, result Result, err error) {
+	logger := logging.FromContext(ctx)
+	if result.Err != nil || err != nil {
+		message := result.Err
+		if err != nil {
+			message = err
+		}
+		logger.Warn("could not import lyrics", "spotify_id", result.Track.SpotifyID, "error", message)
+		s.Lock()
+		s.tracksFailed++
+		s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9D\x8C %s - %s: %s", result.Track.Name, result.Track.Artist, message.Error()))
+		s.Unlock()
+		return
+	}
+
+	if result.Track.Instrumental {
+		logger.Debug("marked track as instrumental", "spotify_id", result.Track.SpotifyID)
+		s.Lock()
+		s.tracksSuccess++
+		s.syncLog = append(s.syncLog, fmt.Sprintf("\xF0\x9F\x8E\xB5 %s - %s (instrumental)", result.Track.Name, result.Track.Artist))
+		s.Unlock()
+		return
+	}
+	logger.Debug("imported lyrics", "spotify_id", result.Track.SpotifyID, "language", result.Track.Language)
+	s.Lock()
+	s.tracksSuccess++
+	s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9C\x85 %s - %s", result.Track.Name, result.Track.Artist))
+	s.Unlock()
+
+	// the lyrics are shared without holding the lock, so the status can be read in the meantime
+	shared, err := duplicates.ShareLyrics(ctx, s.db, result.Track)
+	s.Lock()
+	defer s.Unlock()
+	if err != nil {
+		logger.Warn("could not share lyrics with duplicates", "spotify_id", result.Track.SpotifyID, "error", err)
+		s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9D\x8C %s - %s: could not share lyrics with duplicates: %s", result.Track.Name, result.Track.Artist, err.Error()))
+	}
+	for _, t := range shared {
+		s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9C\x85 %s - %s (%s)", t.Name, t.Artist, t.AlbumName))
+	}
+}
+
+// trackBatches splits the tracks of an iterator into batches. Tracks that belong to the same group as a preceding
+// track are skipped, their lyrics are shared once the lyrics of the first track have been fetched.
+type trackBatches struct {
+	tracks *db.TrackIterator
+	seen   map[string]bool
+}
+
+// next returns the next batch of tracks. The batch is empty once all tracks have been visited.
+func (b *trackBatches) next(ctx context.Context) ([]*db.Track, error) {
+	var batch []*db.Track
+	for len(batch) < syncBatchSize && b.tracks.Next(ctx) {
+		t := b.tracks.Track()
+		if t.GroupID != "" {
+			if b.seen[t.GroupID] {
+				continue
 			}
-			s.tracksFailed++
-			s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9D\x8C %s - %s: %s", result.Track.Name, result.Track.Artist, message.Error()))
-		} else {
-			s.tracksSuccess++
-			s.syncLog = append(s.syncLog, fmt.Sprintf("\xE2\x9C\x85 %s - %s", result.Track.Name, result.Track.Artist))
+			b.seen[t.GroupID] = true
 		}
+		batch = append(batch, t)
 	}
+	return batch, b.tracks.Err()
 }
 
 func (s *Syncer) Syncing() bool {
@@ -125,6 +362,9 @@ func (s *Syncer) TotalTracks() int {
 }
 
 func (s *Syncer) Logs() string {
+	s.Lock()
+	defer s.Unlock()
+
 	b := strings.Builder{}
 	for i := len(s.syncLog) - 1; i >= 0; i-- {
 		b.WriteString(s.syncLog[i] + "<br>")
@@ -1,7 +1,9 @@
 package lyrics
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/imba28/spolyr/pkg/db"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,12 +15,29 @@ type trackStoreMock struct {
 	mock.Mock
 }
 
-func (t *trackStoreMock) Save(track *db.Track) error {
+func (t *trackStoreMock) Save(ctx context.Context, track *db.Track) error {
 	args := t.Called(track)
 	return args.Error(0)
 }
-func (t *trackStoreMock) TracksWithoutLyricsError() ([]*db.Track, error) {
+func (t *trackStoreMock) SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error) {
+	results := make([]db.SaveResult, len(tracks))
+	for i := range tracks {
+		results[i].Err = t.MethodCalled("SaveMany", tracks[i]).Error(0)
+	}
+	return results, nil
+}
+func (t *trackStoreMock) TracksWithoutLyricsError() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := t.MethodCalled("TracksWithoutLyricsError", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
+}
+func (t *trackStoreMock) CountGroupsWithoutLyricsError(ctx context.Context) (int64, error) {
 	args := t.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+func (t *trackStoreMock) FindGroup(ctx context.Context, groupID string) ([]*db.Track, error) {
+	args := t.Called(groupID)
 	return args.Get(0).([]*db.Track), args.Error(1)
 }
 
@@ -29,7 +48,7 @@ type lyricsFetcherMock struct {
 func (l *lyricsFetcherMock) Fetch(ts *db.Track) error {
 	panic("not implemented")
 }
-func (l *lyricsFetcherMock) FetchAll(ts []*db.Track) (<-chan Result, error) {
+func (l *lyricsFetcherMock) FetchAll(ctx context.Context, ts []*db.Track) (<-chan Result, error) {
 	args := l.Called(ts)
 	return args.Get(0).(chan Result), args.Error(1)
 }
@@ -62,8 +81,9 @@ func TestSyncer_Sync(t *testing.T) {
 			}
 
 			dbMock := trackStoreMock{}
-			dbMock.On("Save", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
-			dbMock.On("TracksWithoutLyricsError").Times(1).Return(tracks, nil)
+			dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
+			dbMock.On("TracksWithoutLyricsError", "").Times(1).Return(tracks, "", nil)
+			dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 			results := make(chan Result)
 
@@ -71,7 +91,7 @@ func TestSyncer_Sync(t *testing.T) {
 			fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 			syncer := NewSyncer(&fetcherMock, &dbMock)
-			finished, err := syncer.Sync()
+			finished, err := syncer.Sync(context.Background())
 
 			// simulate fetching of lyrics
 			go fetcherMock.writeFakeResults(tracks, results)
@@ -86,10 +106,41 @@ func TestSyncer_Sync(t *testing.T) {
 		}, time.Second)(t)
 	})
 
+	t.Run("reports the progress while syncing", func(t *testing.T) {
+		withTimeout(func(t *testing.T) {
+			tracks := []*db.Track{{Name: "track A"}, {Name: "track B"}}
+
+			dbMock := trackStoreMock{}
+			dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Return(nil)
+			dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+			dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
+
+			results := make(chan Result)
+			fetcherMock := lyricsFetcherMock{}
+			fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Return(results, nil)
+
+			syncer := NewSyncer(&fetcherMock, &dbMock)
+			finished, err := syncer.Sync(context.Background())
+			assert.Nil(t, err)
+			go fetcherMock.writeFakeResults(tracks, results)
+
+			for syncer.Syncing() {
+				_ = syncer.Logs()
+				_ = syncer.SyncedTracks()
+				_ = syncer.TracksSuccess() + syncer.TracksFailed()
+			}
+			<-finished
+
+			assert.Equal(t, "", syncer.Logs())
+			assert.Equal(t, 2, syncer.TracksSuccess())
+		}, time.Second)(t)
+	})
+
 	t.Run("prevents clients from starting multiple syncs", func(t *testing.T) {
 		withTimeout(func(t *testing.T) {
 			dbMock := trackStoreMock{}
-			dbMock.On("TracksWithoutLyricsError").Return([]*db.Track{{}}, nil)
+			dbMock.On("TracksWithoutLyricsError", "").Once().Return([]*db.Track{{}}, "", nil)
+			dbMock.On("CountGroupsWithoutLyricsError").Once().Return(int64(1), nil)
 
 			results := make(chan Result)
 
@@ -97,11 +148,11 @@ func TestSyncer_Sync(t *testing.T) {
 			fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 			syncer := NewSyncer(&fetcherMock, &dbMock)
-			finished, err := syncer.Sync()
+			finished, err := syncer.Sync(context.Background())
 
 			assert.Nil(t, err)
 
-			_, err = syncer.Sync()
+			_, err = syncer.Sync(context.Background())
 			assert.ErrorIs(t, err, ErrBusy)
 
 			close(results)
@@ -113,6 +164,202 @@ func TestSyncer_Sync(t *testing.T) {
 	})
 }
 
+func TestSyncer_Sync__duplicates(t *testing.T) {
+	t.Run("fetches lyrics once per group and shares them", func(t *testing.T) {
+		withTimeout(func(t *testing.T) {
+			album := &db.Track{SpotifyID: "a", Name: "track A", GroupID: "a"}
+			single := &db.Track{SpotifyID: "b", Name: "track A", GroupID: "a"}
+			other := &db.Track{SpotifyID: "c", Name: "track C"}
+
+			dbMock := trackStoreMock{}
+			dbMock.On("TracksWithoutLyricsError", "").Return([]*db.Track{album, single, other}, "", nil)
+			dbMock.On("CountGroupsWithoutLyricsError").Return(int64(2), nil)
+			dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(2).Return(nil)
+			dbMock.On("Save", single).Once().Return(nil)
+			dbMock.On("FindGroup", "a").Return([]*db.Track{album, single}, nil)
+
+			results := make(chan Result)
+
+			fetcherMock := lyricsFetcherMock{}
+			fetcherMock.On("FetchAll", []*db.Track{album, other}).Times(1).Return(results, nil)
+
+			syncer := NewSyncer(&fetcherMock, &dbMock)
+			finished, err := syncer.Sync(context.Background())
+			assert.Nil(t, err)
+
+			album.Loaded = true
+			go fetcherMock.writeFakeResults([]*db.Track{album, other}, results)
+			<-finished
+
+			assert.True(t, single.Loaded)
+			assert.Equal(t, "la la la", single.Lyrics)
+			fetcherMock.AssertExpectations(t)
+			dbMock.AssertExpectations(t)
+		}, time.Second)(t)
+	})
+}
+
+func TestSyncer_Sync__batches(t *testing.T) {
+	withTimeout(func(t *testing.T) {
+		first := make([]*db.Track, syncBatchSize)
+		for i := range first {
+			first[i] = &db.Track{SpotifyID: fmt.Sprint(i)}
+		}
+		first[0].GroupID = "a"
+		duplicate := &db.Track{SpotifyID: "duplicate", GroupID: "a"}
+		last := &db.Track{SpotifyID: "last"}
+
+		dbMock := trackStoreMock{}
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(syncBatchSize+1), nil)
+		dbMock.On("TracksWithoutLyricsError", "").Return(first, "next", nil)
+		dbMock.On("TracksWithoutLyricsError", "next").Return([]*db.Track{duplicate, last}, "", nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Return(nil)
+		dbMock.On("FindGroup", "a").Return([]*db.Track{first[0]}, nil)
+
+		fetcherMock := lyricsFetcherMock{}
+		for _, batch := range [][]*db.Track{first, {last}} {
+			results := make(chan Result, len(batch))
+			for _, track := range batch {
+				results <- Result{Track: track, Err: errors.New("not found")}
+			}
+			close(results)
+			fetcherMock.On("FetchAll", batch).Once().Return(results, nil)
+		}
+
+		syncer := NewSyncer(&fetcherMock, &dbMock)
+		finished, err := syncer.Sync(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, syncBatchSize+1, syncer.TotalTracks())
+		<-finished
+
+		fetcherMock.AssertExpectations(t)
+		dbMock.AssertNumberOfCalls(t, "SaveMany", syncBatchSize+1)
+	}, time.Second)(t)
+}
+
+func TestSyncer_OnFinished(t *testing.T) {
+	withTimeout(func(t *testing.T) {
+		tracks := []*db.Track{{Name: "track A"}, {Name: "track B"}}
+
+		dbMock := trackStoreMock{}
+		dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Return(nil)
+
+		results := make(chan Result)
+
+		fetcherMock := lyricsFetcherMock{}
+		fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Return(results, nil)
+
+		var result SyncResult
+		syncer := NewSyncer(&fetcherMock, &dbMock)
+		syncer.OnFinished(func(r SyncResult) {
+			result = r
+		})
+		finished, err := syncer.Sync(context.Background())
+		assert.Nil(t, err)
+
+		go func() {
+			results <- Result{Track: tracks[0]}
+			results <- Result{Track: tracks[1], Err: errors.New("not found")}
+			close(results)
+		}()
+		<-finished
+
+		assert.Equal(t, SyncResult{Total: 2, Successful: 1, Failed: 1}, result)
+	}, time.Second)(t)
+}
+
+func TestSyncer_Sync__save_errors(t *testing.T) {
+	withTimeout(func(t *testing.T) {
+		a, b := &db.Track{Name: "track A"}, &db.Track{Name: "track B"}
+		tracks := []*db.Track{a, b}
+
+		dbMock := trackStoreMock{}
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
+		dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+		dbMock.On("SaveMany", a).Return(errors.New("invalid document"))
+		dbMock.On("SaveMany", b).Return(nil)
+
+		results := make(chan Result, len(tracks))
+		results <- Result{Track: a}
+		results <- Result{Track: b}
+		close(results)
+
+		fetcherMock := lyricsFetcherMock{}
+		fetcherMock.On("FetchAll", tracks).Return(results, nil)
+
+		var result SyncResult
+		syncer := NewSyncer(&fetcherMock, &dbMock)
+		syncer.OnFinished(func(r SyncResult) {
+			result = r
+		})
+		finished, err := syncer.Sync(context.Background())
+		assert.Nil(t, err)
+		<-finished
+
+		assert.Equal(t, SyncResult{Total: 2, Successful: 1, Failed: 1}, result, "other tracks should be saved")
+	}, time.Second)(t)
+}
+
+func TestReceiveResults(t *testing.T) {
+	c := make(chan Result, 3)
+	c <- Result{Track: &db.Track{Name: "track A"}}
+	c <- Result{Track: &db.Track{Name: "track B"}}
+
+	results, ok := receiveResults(c)
+	assert.True(t, ok)
+	assert.Len(t, results, 2, "should return all results that are ready")
+
+	c <- Result{Track: &db.Track{Name: "track C"}}
+	close(c)
+	results, ok = receiveResults(c)
+	assert.True(t, ok)
+	assert.Len(t, results, 1)
+
+	_, ok = receiveResults(c)
+	assert.False(t, ok)
+}
+
+func TestSyncer_Shutdown(t *testing.T) {
+	withTimeout(func(t *testing.T) {
+		tracks := []*db.Track{{Name: "track A"}, {Name: "track B"}}
+
+		dbMock := trackStoreMock{}
+		dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Return(nil)
+
+		results := make(chan Result)
+
+		fetcherMock := lyricsFetcherMock{}
+		fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Return(results, nil)
+
+		var result SyncResult
+		syncer := NewSyncer(&fetcherMock, &dbMock)
+		syncer.OnFinished(func(r SyncResult) {
+			result = r
+		})
+		finished, err := syncer.Sync(context.Background())
+		assert.Nil(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, syncer.Shutdown(ctx), context.DeadlineExceeded, "sync is still saving lyrics")
+
+		results <- Result{Track: tracks[0]}
+		close(results)
+		<-finished
+
+		assert.Nil(t, syncer.Shutdown(context.Background()))
+		assert.Equal(t, SyncResult{Total: 2, Successful: 1, Cancelled: true}, result)
+		dbMock.AssertNumberOfCalls(t, "SaveMany", 1)
+
+		_, err = syncer.Sync(context.Background())
+		assert.ErrorIs(t, err, ErrClosed)
+	}, time.Second)(t)
+}
+
 func TestSyncer_Syncing(t *testing.T) {
 	t.Run("returns correct syncing state", func(t *testing.T) {
 		tracks := []*db.Track{
@@ -128,8 +375,9 @@ func TestSyncer_Syncing(t *testing.T) {
 		}
 
 		dbMock := trackStoreMock{}
-		dbMock.On("Save", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
-		dbMock.On("TracksWithoutLyricsError").Times(1).Return(tracks, nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
+		dbMock.On("TracksWithoutLyricsError", "").Times(1).Return(tracks, "", nil)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 		results := make(chan Result)
 
@@ -137,7 +385,7 @@ func TestSyncer_Syncing(t *testing.T) {
 		fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 		syncer := NewSyncer(&fetcherMock, &dbMock)
-		finished, _ := syncer.Sync()
+		finished, _ := syncer.Sync(context.Background())
 
 		assert.True(t, syncer.Syncing())
 
@@ -151,16 +399,22 @@ func TestSyncer_Syncing(t *testing.T) {
 		tracks := []*db.Track{}
 
 		dbMock := trackStoreMock{}
-		dbMock.On("TracksWithoutLyricsError").Times(1).Return(tracks, expectedError)
+		dbMock.On("TracksWithoutLyricsError", "").Times(2).Return(tracks, "", expectedError)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 		fetcherMock := lyricsFetcherMock{}
 
 		syncer := NewSyncer(&fetcherMock, &dbMock)
-		finished, err := syncer.Sync()
+		finished, err := syncer.Sync(context.Background())
 
 		assert.Nil(t, finished)
 		assert.ErrorIs(t, err, expectedError)
 		assert.False(t, syncer.Syncing())
+
+		// a failed start does not block the next sync
+		_, err = syncer.Sync(context.Background())
+		assert.ErrorIs(t, err, expectedError)
+		dbMock.AssertExpectations(t)
 	})
 }
 
@@ -175,7 +429,8 @@ func TestSyncer_TotalTracks(t *testing.T) {
 	}
 
 	dbMock := trackStoreMock{}
-	dbMock.On("TracksWithoutLyricsError").Return(tracks, nil)
+	dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+	dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 	results := make(chan Result)
 	defer close(results)
@@ -184,7 +439,7 @@ func TestSyncer_TotalTracks(t *testing.T) {
 	fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 	syncer := NewSyncer(&fetcherMock, &dbMock)
-	_, _ = syncer.Sync()
+	_, _ = syncer.Sync(context.Background())
 
 	assert.Equal(t, syncer.TotalTracks(), len(tracks))
 }
@@ -201,17 +456,18 @@ func TestSyncer_SyncedTracks(t *testing.T) {
 		}
 
 		dbMock := trackStoreMock{}
-		dbMock.On("TracksWithoutLyricsError").Return(tracks, nil)
+		dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 		results := make(chan Result)
 		defer close(results)
 
 		fetcherMock := lyricsFetcherMock{}
-		dbMock.On("Save", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
 		fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 		syncer := NewSyncer(&fetcherMock, &dbMock)
-		_, _ = syncer.Sync()
+		_, _ = syncer.Sync(context.Background())
 
 		assert.Equal(t, syncer.SyncedTracks(), 0)
 		results <- Result{
@@ -232,16 +488,17 @@ func TestSyncer_SyncedTracks(t *testing.T) {
 			}
 
 			dbMock := trackStoreMock{}
-			dbMock.On("TracksWithoutLyricsError").Return(tracks, nil)
+			dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+			dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 			results := make(chan Result)
 
 			fetcherMock := lyricsFetcherMock{}
-			dbMock.On("Save", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
+			dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
 			fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 			syncer := NewSyncer(&fetcherMock, &dbMock)
-			finished, _ := syncer.Sync()
+			finished, _ := syncer.Sync(context.Background())
 
 			go fetcherMock.writeFakeResults(tracks, results)
 
@@ -258,22 +515,24 @@ func TestSyncer_SyncedTracks(t *testing.T) {
 		}
 
 		dbMock := trackStoreMock{}
-		dbMock.On("TracksWithoutLyricsError").Return(tracks, nil)
+		dbMock.On("TracksWithoutLyricsError", "").Return(tracks, "", nil)
+		dbMock.On("CountGroupsWithoutLyricsError").Return(int64(len(tracks)), nil)
 
 		results := make(chan Result)
-		defer close(results)
 
 		fetcherMock := lyricsFetcherMock{}
-		dbMock.On("Save", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
+		dbMock.On("SaveMany", mock.AnythingOfType("*db.Track")).Times(len(tracks)).Return(nil)
 		fetcherMock.On("FetchAll", mock.AnythingOfType("[]*db.Track")).Times(1).Return(results, nil)
 
 		syncer := NewSyncer(&fetcherMock, &dbMock)
-		_, _ = syncer.Sync()
+		finished, _ := syncer.Sync(context.Background())
 
 		results <- Result{
 			Track: tracks[0],
 			Err:   errors.New("something went wrong during the lyrics import"),
 		}
+		close(results)
+		<-finished
 
 		assert.Equal(t, tracks[0].LyricsImportErrorCount, 1, "should increase error counter if import fails")
 		dbMock.AssertExpectations(t)
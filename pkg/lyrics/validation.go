@@ -0,0 +1,239 @@
+package lyrics
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrLowConfidence is returned if fetched lyrics probably do not belong to the track, e.g. because the provider
+// returned a different song or a placeholder. The lyrics are kept in db.Track.LyricsReview, so they can be reviewed.
+var ErrLowConfidence = errors.New("lyrics have a low confidence")
+
+// Issues found while validating lyrics.
+const (
+	IssueTooShort        = "too-short"
+	IssueTooLong         = "too-long"
+	IssueTitleMissing    = "title-missing"
+	IssueUnknownLanguage = "unknown-language"
+	IssueScriptMismatch  = "script-mismatch"
+	IssueNoText          = "no-text"
+	IssueBoilerplate     = "boilerplate"
+	IssueInstrumental    = "instrumental"
+)
+
+// penalties lists how much each issue lowers the score of lyrics. A single issue that is common for correct lyrics,
+// e.g. songs not mentioning their title, does not flag the lyrics on its own.
+var penalties = map[string]float64{
+	IssueTooShort:        0.5,
+	IssueTooLong:         0.25,
+	IssueTitleMissing:    0.25,
+	IssueUnknownLanguage: 0.25,
+	IssueScriptMismatch:  0.5,
+	IssueNoText:          0.5,
+	IssueBoilerplate:     0.5,
+	IssueInstrumental:    1,
+}
+
+const (
+	// minConfidence is the score below which lyrics are flagged for review instead of being saved.
+	minConfidence = 0.5
+
+	minLyricsLength = 100
+	maxLyricsLength = 15000
+)
+
+var (
+	lineBreakTag       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTag            = regexp.MustCompile(`<[a-zA-Z/!][^>]*>`)
+	embedSuffix        = regexp.MustCompile(`\d*\s*Embed\s*$`)
+	mightAlsoLike      = regexp.MustCompile(`You might also like`)
+	ticketAd           = regexp.MustCompile(`See [^\n]{1,100} LiveGet tickets as low as \$\d+`)
+	contributorsHeader = regexp.MustCompile(`(?i)^\d+\s*contributors?`)
+	trailingSpace      = regexp.MustCompile(`[ \t]+\n`)
+	blankLines         = regexp.MustCompile(`\n{3,}`)
+
+	boilerplate = []*regexp.Regexp{
+		regexp.MustCompile(`https?://`),
+		regexp.MustCompile(`(?i)\bcookies?\b.*\b(accept|consent|policy)\b`),
+		regexp.MustCompile(`(?i)\bjavascript\b`),
+		regexp.MustCompile(`(?i)lyrics (are )?(not available|unavailable)`),
+		regexp.MustCompile(`(?i)we (do not|don't) have (the )?lyrics`),
+		regexp.MustCompile(`(?i)be the first to (add|submit) (the )?lyrics`),
+	}
+
+	// titleDecorations matches parts of track names that are usually not sung, e.g. "(feat. X)" or "- Remastered 2011".
+	titleDecorations = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]|\s-\s.*$`)
+)
+
+// scripts lists the writing systems used to check whether lyrics are written in the same script as the track name.
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Arabic, unicode.Hebrew, unicode.Hangul, unicode.Han,
+	unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Devanagari,
+}
+
+// clean removes artifacts that providers add to lyrics, e.g. html tags, the contributors header and "Embed" footer of
+// Genius and inline recommendations.
+func clean(lyrics, title string) string {
+	lyrics = strings.ReplaceAll(lyrics, "\r\n", "\n")
+	lyrics = lineBreakTag.ReplaceAllString(lyrics, "\n")
+	lyrics = htmlTag.ReplaceAllString(lyrics, "")
+	lyrics = html.UnescapeString(lyrics)
+	lyrics = ticketAd.ReplaceAllString(lyrics, "\n")
+	lyrics = mightAlsoLike.ReplaceAllString(lyrics, "\n")
+	lyrics = embedSuffix.ReplaceAllString(strings.TrimSpace(lyrics), "")
+
+	lines := strings.SplitN(strings.TrimSpace(lyrics), "\n", 2)
+	first := strings.TrimSpace(lines[0])
+	if len(lines) == 2 && (contributorsHeader.MatchString(first) || strings.EqualFold(first, title+" Lyrics")) {
+		lyrics = lines[1]
+	}
+
+	lyrics = trailingSpace.ReplaceAllString(lyrics, "\n")
+	lyrics = blankLines.ReplaceAllString(lyrics, "\n\n")
+	return strings.TrimSpace(lyrics)
+}
+
+// validation is the result of validating lyrics. The score ranges from 0 to 1.
+type validation struct {
+	score  float64
+	issues []string
+}
+
+func (v validation) flagged() bool {
+	return v.score < minConfidence
+}
+
+// instrumental reports whether the provider returned a note that the track has no lyrics instead of lyrics.
+func (v validation) instrumental() bool {
+	for _, issue := range v.issues {
+		if issue == IssueInstrumental {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validation) add(issue string) {
+	v.issues = append(v.issues, issue)
+	v.score -= penalties[issue]
+	if v.score < 0 {
+		v.score = 0
+	}
+}
+
+// validate scores how likely the cleaned lyrics belong to the track with the given title. languageErr is the error
+// returned when the language of the lyrics has been detected.
+func validate(title, lyrics string, languageErr error) validation {
+	v := validation{score: 1}
+	length := utf8.RuneCountInString(lyrics)
+	lower := strings.ToLower(lyrics)
+
+	if length < minLyricsLength && strings.Contains(lower, "instrumental") {
+		v.add(IssueInstrumental)
+		return v
+	}
+	if length < minLyricsLength {
+		v.add(IssueTooShort)
+	}
+	if length > maxLyricsLength {
+		v.add(IssueTooLong)
+	}
+	for _, b := range boilerplate {
+		if b.MatchString(lyrics) {
+			v.add(IssueBoilerplate)
+			break
+		}
+	}
+	if letterRatio(lyrics) < 0.6 {
+		v.add(IssueNoText)
+	}
+	if !containsTitle(lower, title) {
+		v.add(IssueTitleMissing)
+	}
+	if languageErr != nil {
+		v.add(IssueUnknownLanguage)
+	}
+	if script := dominantScript(title); script != nil && script != unicode.Latin && scriptRatio(lyrics, script) < 0.1 {
+		v.add(IssueScriptMismatch)
+	}
+	return v
+}
+
+// containsTitle reports whether any significant word of the title occurs in the lyrics. Titles without significant
+// words are always found.
+func containsTitle(lyrics, title string) bool {
+	words := strings.FieldsFunc(strings.ToLower(titleDecorations.ReplaceAllString(title, "")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	significant := 0
+	for _, w := range words {
+		if utf8.RuneCountInString(w) < 3 {
+			continue
+		}
+		significant++
+		if strings.Contains(lyrics, w) {
+			return true
+		}
+	}
+	return significant == 0
+}
+
+// letterRatio returns the share of letters among all characters except whitespace.
+func letterRatio(s string) float64 {
+	letters, total := 0, 0
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(letters) / float64(total)
+}
+
+// scriptRatio returns the share of letters written in the script.
+func scriptRatio(s string, script *unicode.RangeTable) float64 {
+	matching, letters := 0, 0
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(script, r) {
+			matching++
+		}
+	}
+	if letters == 0 {
+		return 0
+	}
+	return float64(matching) / float64(letters)
+}
+
+// dominantScript returns the script most letters of s are written in or nil if s does not contain letters of a known
+// script.
+func dominantScript(s string) *unicode.RangeTable {
+	var dominant *unicode.RangeTable
+	max := 0
+	for _, script := range scripts {
+		n := 0
+		for _, r := range s {
+			if unicode.Is(script, r) {
+				n++
+			}
+		}
+		if n > max {
+			dominant, max = script, n
+		}
+	}
+	return dominant
+}
@@ -0,0 +1,65 @@
+package lyrics
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name   string
+		lyrics string
+		want   string
+	}{
+		{"unchanged", "line one\nline two", "line one\nline two"},
+		{"html", "line one<br>line <i>two</i><br/>it&#39;s &amp; more", "line one\nline two\nit's & more"},
+		{"windows line breaks", "line one\r\nline two", "line one\nline two"},
+		{"genius header", "12 ContributorsTranslationsDeutschSong Title Lyrics\nline one", "line one"},
+		{"title header", "Song Title Lyrics\n\nline one", "line one"},
+		{"embed footer", "line one\nline two123Embed", "line one\nline two"},
+		{"recommendations", "line oneYou might also likeline two", "line one\nline two"},
+		{"ticket ads", "line one\nSee Some Artist LiveGet tickets as low as $45\nline two", "line one\n\nline two"},
+		{"blank lines", "line one  \n\n\n\nline two\n\n", "line one\n\nline two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clean(tt.lyrics, "Song Title"))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	lyrics := strings.Repeat("Hold on to the night, the night is young\n", 4)
+	tests := []struct {
+		name        string
+		title       string
+		lyrics      string
+		languageErr error
+		wantIssues  []string
+		wantFlagged bool
+	}{
+		{"valid", "The Night", lyrics, nil, nil, false},
+		{"decorated title", "Night (feat. Someone) - Remastered 2011", lyrics, nil, nil, false},
+		{"short title", "Yo", lyrics, nil, nil, false},
+		{"missing title", "Bohemian Rhapsody", lyrics, nil, []string{IssueTitleMissing}, false},
+		{"missing title and unknown language", "Bohemian Rhapsody", lyrics, errors.New("unknown"), []string{IssueTitleMissing, IssueUnknownLanguage}, false},
+		{"too short and missing title", "Bohemian Rhapsody", "la la la", nil, []string{IssueTooShort, IssueTitleMissing}, true},
+		{"too short", "The Night", "the night", nil, []string{IssueTooShort}, false},
+		{"too long", "The Night", strings.Repeat(lyrics, 100), nil, []string{IssueTooLong}, false},
+		{"instrumental", "The Night", "[Instrumental]", nil, []string{IssueInstrumental}, true},
+		{"boilerplate", "The Night", lyrics + "Read more at https://example.com", nil, []string{IssueBoilerplate}, false},
+		{"no text", "The Night", "the night " + strings.Repeat("{}();=+- 0123456789 ", 10), nil, []string{IssueNoText}, false},
+		{"script mismatch", "Кино", lyrics, nil, []string{IssueTitleMissing, IssueScriptMismatch}, true},
+		{"matching script", "Кино", strings.Repeat("Мы ждём перемен, кино\n", 6), nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validate(tt.title, tt.lyrics, tt.languageErr)
+
+			assert.Equal(t, tt.wantIssues, v.issues)
+			assert.Equal(t, tt.wantFlagged, v.flagged(), "score %f", v.score)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package metrics exposes Prometheus metrics about http requests, lyrics providers, lyrics syncs, the track index,
+// database operations and caches.
+package metrics
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "spolyr"
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled api requests by route and status code.",
+	}, []string{"route", "method", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of api requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route"})
+
+	lyricsRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lyrics_provider_requests_total",
+		Help:      "Number of lyrics requests by provider.",
+	}, []string{"provider"})
+	lyricsFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lyrics_provider_failures_total",
+		Help:      "Number of lyrics requests by provider that failed or did not return any lyrics.",
+	}, []string{"provider"})
+	lyricsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lyrics_provider_request_duration_seconds",
+		Help:      "Latency of lyrics requests by provider.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider"})
+
+	syncDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "lyrics_sync_duration_seconds",
+		Help:      "Duration of lyrics sync runs.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "Latency of MongoDB commands by command name and outcome.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"command", "status"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cache lookups by cache and result.",
+	}, []string{"cache", "result"})
+)
+
+// Registry contains all metrics of spolyr as well as the default go and process metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		requests,
+		requestDuration,
+		lyricsRequests,
+		lyricsFailures,
+		lyricsDuration,
+		syncDuration,
+		mongoDuration,
+		cacheRequests,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware counts the requests handled by a mux router and measures their latency. Requests are labeled with the
+// name of the matched route, e.g. the openapi operation, instead of the path to keep the number of series bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil && current.GetName() != "" {
+			route = current.GetName()
+		}
+
+		requests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveLyricsRequest records a request to a lyrics provider.
+func ObserveLyricsRequest(provider string, d time.Duration, err error) {
+	lyricsRequests.WithLabelValues(provider).Inc()
+	if err != nil {
+		lyricsFailures.WithLabelValues(provider).Inc()
+	}
+	lyricsDuration.WithLabelValues(provider).Observe(d.Seconds())
+}
+
+// ObserveSync records the duration of a lyrics sync run.
+func ObserveSync(d time.Duration) {
+	syncDuration.Observe(d.Seconds())
+}
+
+// ObserveCache records a lookup of a cache, which either found the value or not.
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// MongoMonitor returns a command monitor that measures the duration of every command sent to MongoDB.
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "success").Observe(time.Duration(e.DurationNanos).Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoDuration.WithLabelValues(e.CommandName, "failure").Observe(time.Duration(e.DurationNanos).Seconds())
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.Methods("GET").Path("/tracks/{id}").Name("TracksIdGet").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tracks/a", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/tracks/b", nil))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(requests.WithLabelValues("TracksIdGet", "GET", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(requestDuration, namespace+"_http_request_duration_seconds"))
+}
+
+func TestObserveLyricsRequest(t *testing.T) {
+	ObserveLyricsRequest("test", time.Second, nil)
+	ObserveLyricsRequest("test", time.Second, errors.New("not found"))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(lyricsRequests.WithLabelValues("test")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(lyricsFailures.WithLabelValues("test")))
+}
+
+func TestObserveCache(t *testing.T) {
+	ObserveCache("test", true)
+	ObserveCache("test", false)
+	ObserveCache("test", false)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(cacheRequests.WithLabelValues("test", "hit")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(cacheRequests.WithLabelValues("test", "miss")))
+}
+
+func TestMongoMonitor(t *testing.T) {
+	m := MongoMonitor()
+	m.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", DurationNanos: int64(time.Millisecond)}})
+	m.Failed(context.Background(), &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", DurationNanos: int64(time.Millisecond)}})
+
+	assert.Equal(t, 2, testutil.CollectAndCount(mongoDuration))
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "go_goroutines"))
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type trackCounter interface {
+	Count(ctx context.Context) (int64, error)
+	CountWithLyrics(ctx context.Context) (int64, error)
+	CountWithLyricsError(ctx context.Context) (int64, error)
+	CountInstrumental(ctx context.Context) (int64, error)
+}
+
+var tracksDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "tracks"),
+	"Number of tracks in the index by state.",
+	[]string{"state"}, nil,
+)
+
+// TrackCollector reports the number of tracks of the index. The tracks are counted on every scrape.
+type TrackCollector struct {
+	counter trackCounter
+}
+
+func (c TrackCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tracksDesc
+}
+
+func (c TrackCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	counts := []struct {
+		state string
+		count func(context.Context) (int64, error)
+	}{
+		{"total", c.counter.Count},
+		{"with_lyrics", c.counter.CountWithLyrics},
+		{"with_errors", c.counter.CountWithLyricsError},
+		{"instrumental", c.counter.CountInstrumental},
+	}
+
+	for _, s := range counts {
+		n, err := s.count(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warn("could not count tracks", "state", s.state, "error", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(tracksDesc, prometheus.GaugeValue, float64(n), s.state)
+	}
+}
+
+// NewTrackCollector creates a collector for the tracks counted by counter. It has to be registered using Registry.
+func NewTrackCollector(counter trackCounter) TrackCollector {
+	return TrackCollector{
+		counter: counter,
+	}
+}
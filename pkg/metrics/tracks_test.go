@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type trackCounterMock struct {
+	total, withLyrics, instrumental int64
+	err                             error
+}
+
+func (t trackCounterMock) Count(ctx context.Context) (int64, error) {
+	return t.total, nil
+}
+func (t trackCounterMock) CountWithLyrics(ctx context.Context) (int64, error) {
+	return t.withLyrics, nil
+}
+func (t trackCounterMock) CountWithLyricsError(ctx context.Context) (int64, error) {
+	return 0, t.err
+}
+func (t trackCounterMock) CountInstrumental(ctx context.Context) (int64, error) {
+	return t.instrumental, nil
+}
+
+var _ trackCounter = trackCounterMock{}
+
+func TestTrackCollector(t *testing.T) {
+	t.Run("reports the number of tracks", func(t *testing.T) {
+		c := NewTrackCollector(trackCounterMock{total: 10, withLyrics: 7, instrumental: 2})
+
+		expected := `
+# HELP spolyr_tracks Number of tracks in the index by state.
+# TYPE spolyr_tracks gauge
+spolyr_tracks{state="instrumental"} 2
+spolyr_tracks{state="total"} 10
+spolyr_tracks{state="with_errors"} 0
+spolyr_tracks{state="with_lyrics"} 7
+`
+		assert.Nil(t, testutil.CollectAndCompare(c, strings.NewReader(expected)))
+	})
+
+	t.Run("skips counts that fail", func(t *testing.T) {
+		c := NewTrackCollector(trackCounterMock{total: 10, err: errors.New("connection lost")})
+
+		assert.Equal(t, 3, testutil.CollectAndCount(c))
+	})
+}
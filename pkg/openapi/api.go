@@ -24,10 +24,33 @@ type AuthApiRouter interface {
 	AuthRefreshGet(http.ResponseWriter, *http.Request)
 }
 
+// DuplicatesApiRouter defines the required methods for binding the api requests to a responses for the DuplicatesApi
+// The DuplicatesApiRouter implementation should parse necessary information from the http request,
+// pass the data to a DuplicatesApiServicer to perform the required actions, then write the service results to the http response.
+type DuplicatesApiRouter interface {
+	DuplicatesDetectPost(http.ResponseWriter, *http.Request)
+	DuplicatesGet(http.ResponseWriter, *http.Request)
+	DuplicatesIdDelete(http.ResponseWriter, *http.Request)
+	DuplicatesIdTracksTrackIdDelete(http.ResponseWriter, *http.Request)
+	DuplicatesPost(http.ResponseWriter, *http.Request)
+}
+
+// HistoryApiRouter defines the required methods for binding the api requests to a responses for the HistoryApi
+// The HistoryApiRouter implementation should parse necessary information from the http request,
+// pass the data to a HistoryApiServicer to perform the required actions, then write the service results to the http response.
+type HistoryApiRouter interface {
+	HistoryCountsGet(http.ResponseWriter, *http.Request)
+	HistoryRecentGet(http.ResponseWriter, *http.Request)
+}
+
 // ImportApiRouter defines the required methods for binding the api requests to a responses for the ImportApi
 // The ImportApiRouter implementation should parse necessary information from the http request,
 // pass the data to a ImportApiServicer to perform the required actions, then write the service results to the http response.
 type ImportApiRouter interface {
+	ImportAlbumIdPost(http.ResponseWriter, *http.Request)
+	ImportAlbumsPost(http.ResponseWriter, *http.Request)
+	ImportArtistsPost(http.ResponseWriter, *http.Request)
+	ImportHistoryPost(http.ResponseWriter, *http.Request)
 	ImportLibraryPost(http.ResponseWriter, *http.Request)
 	ImportLyricsGet(http.ResponseWriter, *http.Request)
 	ImportLyricsPost(http.ResponseWriter, *http.Request)
@@ -35,11 +58,40 @@ type ImportApiRouter interface {
 	ImportPlaylistIdPost(http.ResponseWriter, *http.Request)
 }
 
+// OrphansApiRouter defines the required methods for binding the api requests to a responses for the OrphansApi
+// The OrphansApiRouter implementation should parse necessary information from the http request,
+// pass the data to a OrphansApiServicer to perform the required actions, then write the service results to the http response.
+type OrphansApiRouter interface {
+	OrphansGet(http.ResponseWriter, *http.Request)
+	OrphansIdDelete(http.ResponseWriter, *http.Request)
+	OrphansReconcilePost(http.ResponseWriter, *http.Request)
+}
+
+// PlayerApiRouter defines the required methods for binding the api requests to a responses for the PlayerApi
+// The PlayerApiRouter implementation should parse necessary information from the http request,
+// pass the data to a PlayerApiServicer to perform the required actions, then write the service results to the http response.
+type PlayerApiRouter interface {
+	PlayerNowPlayingGet(http.ResponseWriter, *http.Request)
+}
+
 // PlaylistsApiRouter defines the required methods for binding the api requests to a responses for the PlaylistsApi
 // The PlaylistsApiRouter implementation should parse necessary information from the http request,
 // pass the data to a PlaylistsApiServicer to perform the required actions, then write the service results to the http response.
 type PlaylistsApiRouter interface {
 	PlaylistsGet(http.ResponseWriter, *http.Request)
+	PlaylistsImportedGet(http.ResponseWriter, *http.Request)
+	PlaylistsImportedIdTracksGet(http.ResponseWriter, *http.Request)
+}
+
+// ReviewApiRouter defines the required methods for binding the api requests to a responses for the ReviewApi
+// The ReviewApiRouter implementation should parse necessary information from the http request,
+// pass the data to a ReviewApiServicer to perform the required actions, then write the service results to the http response.
+type ReviewApiRouter interface {
+	ReviewGet(http.ResponseWriter, *http.Request)
+	ReviewIdAcceptPost(http.ResponseWriter, *http.Request)
+	ReviewIdInstrumentalDelete(http.ResponseWriter, *http.Request)
+	ReviewIdInstrumentalPost(http.ResponseWriter, *http.Request)
+	ReviewIdRetryPost(http.ResponseWriter, *http.Request)
 }
 
 // TracksApiRouter defines the required methods for binding the api requests to a responses for the TracksApi
@@ -52,6 +104,16 @@ type TracksApiRouter interface {
 	TracksStatsGet(http.ResponseWriter, *http.Request)
 }
 
+// WebhooksApiRouter defines the required methods for binding the api requests to a responses for the WebhooksApi
+// The WebhooksApiRouter implementation should parse necessary information from the http request,
+// pass the data to a WebhooksApiServicer to perform the required actions, then write the service results to the http response.
+type WebhooksApiRouter interface {
+	WebhooksGet(http.ResponseWriter, *http.Request)
+	WebhooksIdDelete(http.ResponseWriter, *http.Request)
+	WebhooksIdDeliveriesGet(http.ResponseWriter, *http.Request)
+	WebhooksPost(http.ResponseWriter, *http.Request)
+}
+
 // AuthApiServicer defines the api actions for the AuthApi service
 // This interface intended to stay up to date with the openapi yaml used to generate it,
 // while the service implementation can be ignored with the .openapi-generator-ignore file
@@ -63,16 +125,59 @@ type AuthApiServicer interface {
 	AuthRefreshGet(context.Context) (ImplResponse, error)
 }
 
+// DuplicatesApiServicer defines the api actions for the DuplicatesApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type DuplicatesApiServicer interface {
+	DuplicatesDetectPost(context.Context) (ImplResponse, error)
+	DuplicatesGet(context.Context, int32, int32) (ImplResponse, error)
+	DuplicatesIdDelete(context.Context, string) (ImplResponse, error)
+	DuplicatesIdTracksTrackIdDelete(context.Context, string, string) (ImplResponse, error)
+	DuplicatesPost(context.Context, DuplicatesPostRequest) (ImplResponse, error)
+}
+
+// HistoryApiServicer defines the api actions for the HistoryApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type HistoryApiServicer interface {
+	HistoryCountsGet(context.Context, int32, int32) (ImplResponse, error)
+	HistoryRecentGet(context.Context, int32, int32) (ImplResponse, error)
+}
+
 // ImportApiServicer defines the api actions for the ImportApi service
 // This interface intended to stay up to date with the openapi yaml used to generate it,
 // while the service implementation can be ignored with the .openapi-generator-ignore file
 // and updated with the logic required for the API.
 type ImportApiServicer interface {
-	ImportLibraryPost(context.Context) (ImplResponse, error)
+	ImportAlbumIdPost(context.Context, string) (ImplResponse, error)
+	ImportAlbumsPost(context.Context) (ImplResponse, error)
+	ImportArtistsPost(context.Context, string) (ImplResponse, error)
+	ImportHistoryPost(context.Context) (ImplResponse, error)
+	ImportLibraryPost(context.Context, string) (ImplResponse, error)
 	ImportLyricsGet(context.Context) (ImplResponse, error)
 	ImportLyricsPost(context.Context) (ImplResponse, error)
 	ImportLyricsTrackIdPost(context.Context, string) (ImplResponse, error)
-	ImportPlaylistIdPost(context.Context, string) (ImplResponse, error)
+	ImportPlaylistIdPost(context.Context, string, string) (ImplResponse, error)
+}
+
+// OrphansApiServicer defines the api actions for the OrphansApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type OrphansApiServicer interface {
+	OrphansGet(context.Context, int32, int32) (ImplResponse, error)
+	OrphansIdDelete(context.Context, string) (ImplResponse, error)
+	OrphansReconcilePost(context.Context) (ImplResponse, error)
+}
+
+// PlayerApiServicer defines the api actions for the PlayerApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type PlayerApiServicer interface {
+	PlayerNowPlayingGet(context.Context) (ImplResponse, error)
 }
 
 // PlaylistsApiServicer defines the api actions for the PlaylistsApi service
@@ -81,6 +186,20 @@ type ImportApiServicer interface {
 // and updated with the logic required for the API.
 type PlaylistsApiServicer interface {
 	PlaylistsGet(context.Context, int32, int32) (ImplResponse, error)
+	PlaylistsImportedGet(context.Context, int32, int32) (ImplResponse, error)
+	PlaylistsImportedIdTracksGet(context.Context, string, int32, int32) (ImplResponse, error)
+}
+
+// ReviewApiServicer defines the api actions for the ReviewApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type ReviewApiServicer interface {
+	ReviewGet(context.Context, int32, int32) (ImplResponse, error)
+	ReviewIdAcceptPost(context.Context, string) (ImplResponse, error)
+	ReviewIdInstrumentalDelete(context.Context, string) (ImplResponse, error)
+	ReviewIdInstrumentalPost(context.Context, string) (ImplResponse, error)
+	ReviewIdRetryPost(context.Context, string, LyricsRetryRequest) (ImplResponse, error)
 }
 
 // TracksApiServicer defines the api actions for the TracksApi service
@@ -88,8 +207,19 @@ type PlaylistsApiServicer interface {
 // while the service implementation can be ignored with the .openapi-generator-ignore file
 // and updated with the logic required for the API.
 type TracksApiServicer interface {
-	TracksGet(context.Context, int32, int32, string) (ImplResponse, error)
+	TracksGet(context.Context, int32, int32, string, string, string, int32, int32, string, string, int32, string, string) (ImplResponse, error)
 	TracksIdGet(context.Context, string) (ImplResponse, error)
 	TracksIdPatch(context.Context, string, Lyrics) (ImplResponse, error)
 	TracksStatsGet(context.Context) (ImplResponse, error)
 }
+
+// WebhooksApiServicer defines the api actions for the WebhooksApi service
+// This interface intended to stay up to date with the openapi yaml used to generate it,
+// while the service implementation can be ignored with the .openapi-generator-ignore file
+// and updated with the logic required for the API.
+type WebhooksApiServicer interface {
+	WebhooksGet(context.Context) (ImplResponse, error)
+	WebhooksIdDelete(context.Context, string) (ImplResponse, error)
+	WebhooksIdDeliveriesGet(context.Context, string, int32, int32) (ImplResponse, error)
+	WebhooksPost(context.Context, WebhookRequest) (ImplResponse, error)
+}
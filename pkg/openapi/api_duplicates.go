@@ -0,0 +1,179 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// DuplicatesApiController binds http requests to an api service and writes the service results to the http response
+type DuplicatesApiController struct {
+	service      DuplicatesApiServicer
+	errorHandler ErrorHandler
+}
+
+// DuplicatesApiOption for how the controller is set up.
+type DuplicatesApiOption func(*DuplicatesApiController)
+
+// WithDuplicatesApiErrorHandler inject ErrorHandler into controller
+func WithDuplicatesApiErrorHandler(h ErrorHandler) DuplicatesApiOption {
+	return func(c *DuplicatesApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewDuplicatesApiController creates a default api controller
+func NewDuplicatesApiController(s DuplicatesApiServicer, opts ...DuplicatesApiOption) Router {
+	controller := &DuplicatesApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the DuplicatesApiController
+func (c *DuplicatesApiController) Routes() Routes {
+	return Routes{
+		{
+			"DuplicatesDetectPost",
+			strings.ToUpper("Post"),
+			"/api/duplicates/detect",
+			c.DuplicatesDetectPost,
+		},
+		{
+			"DuplicatesGet",
+			strings.ToUpper("Get"),
+			"/api/duplicates",
+			c.DuplicatesGet,
+		},
+		{
+			"DuplicatesIdDelete",
+			strings.ToUpper("Delete"),
+			"/api/duplicates/{id}",
+			c.DuplicatesIdDelete,
+		},
+		{
+			"DuplicatesIdTracksTrackIdDelete",
+			strings.ToUpper("Delete"),
+			"/api/duplicates/{id}/tracks/{trackId}",
+			c.DuplicatesIdTracksTrackIdDelete,
+		},
+		{
+			"DuplicatesPost",
+			strings.ToUpper("Post"),
+			"/api/duplicates",
+			c.DuplicatesPost,
+		},
+	}
+}
+
+// DuplicatesDetectPost - Detects duplicate tracks and shares lyrics within each group
+func (c *DuplicatesApiController) DuplicatesDetectPost(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.DuplicatesDetectPost(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// DuplicatesGet - Returns a list of groups of duplicate tracks
+func (c *DuplicatesApiController) DuplicatesGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.DuplicatesGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// DuplicatesIdDelete - Dissolves a group
+func (c *DuplicatesApiController) DuplicatesIdDelete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.DuplicatesIdDelete(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// DuplicatesIdTracksTrackIdDelete - Removes a track from a group
+func (c *DuplicatesApiController) DuplicatesIdTracksTrackIdDelete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	trackIdParam := params["trackId"]
+
+	result, err := c.service.DuplicatesIdTracksTrackIdDelete(r.Context(), idParam, trackIdParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// DuplicatesPost - Merges tracks and their groups into a single group
+func (c *DuplicatesApiController) DuplicatesPost(w http.ResponseWriter, r *http.Request) {
+	duplicatesPostRequestParam := DuplicatesPostRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&duplicatesPostRequestParam); err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	if err := AssertDuplicatesPostRequestRequired(duplicatesPostRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.DuplicatesPost(r.Context(), duplicatesPostRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
@@ -0,0 +1,111 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HistoryApiController binds http requests to an api service and writes the service results to the http response
+type HistoryApiController struct {
+	service      HistoryApiServicer
+	errorHandler ErrorHandler
+}
+
+// HistoryApiOption for how the controller is set up.
+type HistoryApiOption func(*HistoryApiController)
+
+// WithHistoryApiErrorHandler inject ErrorHandler into controller
+func WithHistoryApiErrorHandler(h ErrorHandler) HistoryApiOption {
+	return func(c *HistoryApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewHistoryApiController creates a default api controller
+func NewHistoryApiController(s HistoryApiServicer, opts ...HistoryApiOption) Router {
+	controller := &HistoryApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the HistoryApiController
+func (c *HistoryApiController) Routes() Routes {
+	return Routes{
+		{
+			"HistoryCountsGet",
+			strings.ToUpper("Get"),
+			"/api/history/counts",
+			c.HistoryCountsGet,
+		},
+		{
+			"HistoryRecentGet",
+			strings.ToUpper("Get"),
+			"/api/history/recent",
+			c.HistoryRecentGet,
+		},
+	}
+}
+
+// HistoryCountsGet - Returns the number of plays per track
+func (c *HistoryApiController) HistoryCountsGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.HistoryCountsGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// HistoryRecentGet - Returns the recently played tracks that have lyrics
+func (c *HistoryApiController) HistoryRecentGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.HistoryRecentGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
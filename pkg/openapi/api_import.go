@@ -49,6 +49,30 @@ func NewImportApiController(s ImportApiServicer, opts ...ImportApiOption) Router
 // Routes returns all the api routes for the ImportApiController
 func (c *ImportApiController) Routes() Routes {
 	return Routes{
+		{
+			"ImportAlbumIdPost",
+			strings.ToUpper("Post"),
+			"/api/import/album/{id}",
+			c.ImportAlbumIdPost,
+		},
+		{
+			"ImportAlbumsPost",
+			strings.ToUpper("Post"),
+			"/api/import/albums",
+			c.ImportAlbumsPost,
+		},
+		{
+			"ImportArtistsPost",
+			strings.ToUpper("Post"),
+			"/api/import/artists",
+			c.ImportArtistsPost,
+		},
+		{
+			"ImportHistoryPost",
+			strings.ToUpper("Post"),
+			"/api/import/history",
+			c.ImportHistoryPost,
+		},
 		{
 			"ImportLibraryPost",
 			strings.ToUpper("Post"),
@@ -82,9 +106,68 @@ func (c *ImportApiController) Routes() Routes {
 	}
 }
 
+// ImportAlbumIdPost - Start import of tracks from album
+func (c *ImportApiController) ImportAlbumIdPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.ImportAlbumIdPost(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ImportAlbumsPost - Start import of tracks from albums saved in the spotify library
+func (c *ImportApiController) ImportAlbumsPost(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.ImportAlbumsPost(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ImportArtistsPost - Start import of tracks from followed artists
+func (c *ImportApiController) ImportArtistsPost(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	includeParam := query.Get("include")
+	result, err := c.service.ImportArtistsPost(r.Context(), includeParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ImportHistoryPost - Start import of recently played tracks and top tracks
+func (c *ImportApiController) ImportHistoryPost(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.ImportHistoryPost(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
 // ImportLibraryPost - Start import of tracks from spotify library
 func (c *ImportApiController) ImportLibraryPost(w http.ResponseWriter, r *http.Request) {
-	result, err := c.service.ImportLibraryPost(r.Context())
+	query := r.URL.Query()
+	modeParam := query.Get("mode")
+	result, err := c.service.ImportLibraryPost(r.Context(), modeParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -140,9 +223,11 @@ func (c *ImportApiController) ImportLyricsTrackIdPost(w http.ResponseWriter, r *
 // ImportPlaylistIdPost - Start import of tracks from playlist
 func (c *ImportApiController) ImportPlaylistIdPost(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
+	query := r.URL.Query()
 	idParam := params["id"]
 
-	result, err := c.service.ImportPlaylistIdPost(r.Context(), idParam)
+	modeParam := query.Get("mode")
+	result, err := c.service.ImportPlaylistIdPost(r.Context(), idParam, modeParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -0,0 +1,124 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// OrphansApiController binds http requests to an api service and writes the service results to the http response
+type OrphansApiController struct {
+	service      OrphansApiServicer
+	errorHandler ErrorHandler
+}
+
+// OrphansApiOption for how the controller is set up.
+type OrphansApiOption func(*OrphansApiController)
+
+// WithOrphansApiErrorHandler inject ErrorHandler into controller
+func WithOrphansApiErrorHandler(h ErrorHandler) OrphansApiOption {
+	return func(c *OrphansApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewOrphansApiController creates a default api controller
+func NewOrphansApiController(s OrphansApiServicer, opts ...OrphansApiOption) Router {
+	controller := &OrphansApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the OrphansApiController
+func (c *OrphansApiController) Routes() Routes {
+	return Routes{
+		{
+			"OrphansGet",
+			strings.ToUpper("Get"),
+			"/api/orphans",
+			c.OrphansGet,
+		},
+		{
+			"OrphansIdDelete",
+			strings.ToUpper("Delete"),
+			"/api/orphans/{id}",
+			c.OrphansIdDelete,
+		},
+		{
+			"OrphansReconcilePost",
+			strings.ToUpper("Post"),
+			"/api/orphans/reconcile",
+			c.OrphansReconcilePost,
+		},
+	}
+}
+
+// OrphansGet - Returns a list of tracks that have been removed from the library and all imported playlists
+func (c *OrphansApiController) OrphansGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.OrphansGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// OrphansIdDelete - Deletes an orphaned track
+func (c *OrphansApiController) OrphansIdDelete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.OrphansIdDelete(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// OrphansReconcilePost - Compares the stored tracks with the Spotify library and the imported playlists
+func (c *OrphansApiController) OrphansReconcilePost(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.OrphansReconcilePost(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
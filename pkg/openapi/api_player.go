@@ -0,0 +1,70 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PlayerApiController binds http requests to an api service and writes the service results to the http response
+type PlayerApiController struct {
+	service      PlayerApiServicer
+	errorHandler ErrorHandler
+}
+
+// PlayerApiOption for how the controller is set up.
+type PlayerApiOption func(*PlayerApiController)
+
+// WithPlayerApiErrorHandler inject ErrorHandler into controller
+func WithPlayerApiErrorHandler(h ErrorHandler) PlayerApiOption {
+	return func(c *PlayerApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewPlayerApiController creates a default api controller
+func NewPlayerApiController(s PlayerApiServicer, opts ...PlayerApiOption) Router {
+	controller := &PlayerApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the PlayerApiController
+func (c *PlayerApiController) Routes() Routes {
+	return Routes{
+		{
+			"PlayerNowPlayingGet",
+			strings.ToUpper("Get"),
+			"/api/player/now-playing",
+			c.PlayerNowPlayingGet,
+		},
+	}
+}
+
+// PlayerNowPlayingGet - Returns the track currently playing on Spotify together with its lyrics
+func (c *PlayerApiController) PlayerNowPlayingGet(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.PlayerNowPlayingGet(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
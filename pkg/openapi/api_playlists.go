@@ -12,6 +12,8 @@ package openapi
 import (
 	"net/http"
 	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 // PlaylistsApiController binds http requests to an api service and writes the service results to the http response
@@ -53,6 +55,18 @@ func (c *PlaylistsApiController) Routes() Routes {
 			"/api/playlists",
 			c.PlaylistsGet,
 		},
+		{
+			"PlaylistsImportedGet",
+			strings.ToUpper("Get"),
+			"/api/playlists/imported",
+			c.PlaylistsImportedGet,
+		},
+		{
+			"PlaylistsImportedIdTracksGet",
+			strings.ToUpper("Get"),
+			"/api/playlists/imported/{id}/tracks",
+			c.PlaylistsImportedIdTracksGet,
+		},
 	}
 }
 
@@ -79,3 +93,54 @@ func (c *PlaylistsApiController) PlaylistsGet(w http.ResponseWriter, r *http.Req
 	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
 
 }
+
+// PlaylistsImportedGet - Returns a list of playlists whose tracks have been imported
+func (c *PlaylistsApiController) PlaylistsImportedGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.PlaylistsImportedGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// PlaylistsImportedIdTracksGet - Returns the tracks of an imported playlist
+func (c *PlaylistsApiController) PlaylistsImportedIdTracksGet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	query := r.URL.Query()
+	idParam := params["id"]
+
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.PlaylistsImportedIdTracksGet(r.Context(), idParam, pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
@@ -0,0 +1,183 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ReviewApiController binds http requests to an api service and writes the service results to the http response
+type ReviewApiController struct {
+	service      ReviewApiServicer
+	errorHandler ErrorHandler
+}
+
+// ReviewApiOption for how the controller is set up.
+type ReviewApiOption func(*ReviewApiController)
+
+// WithReviewApiErrorHandler inject ErrorHandler into controller
+func WithReviewApiErrorHandler(h ErrorHandler) ReviewApiOption {
+	return func(c *ReviewApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewReviewApiController creates a default api controller
+func NewReviewApiController(s ReviewApiServicer, opts ...ReviewApiOption) Router {
+	controller := &ReviewApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the ReviewApiController
+func (c *ReviewApiController) Routes() Routes {
+	return Routes{
+		{
+			"ReviewGet",
+			strings.ToUpper("Get"),
+			"/api/review",
+			c.ReviewGet,
+		},
+		{
+			"ReviewIdAcceptPost",
+			strings.ToUpper("Post"),
+			"/api/review/{id}/accept",
+			c.ReviewIdAcceptPost,
+		},
+		{
+			"ReviewIdInstrumentalDelete",
+			strings.ToUpper("Delete"),
+			"/api/review/{id}/instrumental",
+			c.ReviewIdInstrumentalDelete,
+		},
+		{
+			"ReviewIdInstrumentalPost",
+			strings.ToUpper("Post"),
+			"/api/review/{id}/instrumental",
+			c.ReviewIdInstrumentalPost,
+		},
+		{
+			"ReviewIdRetryPost",
+			strings.ToUpper("Post"),
+			"/api/review/{id}/retry",
+			c.ReviewIdRetryPost,
+		},
+	}
+}
+
+// ReviewGet - Returns the tracks whose lyrics could not be imported or have been flagged for review
+func (c *ReviewApiController) ReviewGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.ReviewGet(r.Context(), pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ReviewIdAcceptPost - Accepts the flagged lyrics of a track
+func (c *ReviewApiController) ReviewIdAcceptPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.ReviewIdAcceptPost(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ReviewIdInstrumentalDelete - Removes the instrumental mark of a track, so its lyrics are synced again
+func (c *ReviewApiController) ReviewIdInstrumentalDelete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.ReviewIdInstrumentalDelete(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ReviewIdInstrumentalPost - Marks a track as instrumental, so its lyrics are never fetched again
+func (c *ReviewApiController) ReviewIdInstrumentalPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.ReviewIdInstrumentalPost(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// ReviewIdRetryPost - Fetches the lyrics of a track again, optionally using a single provider or different search terms
+func (c *ReviewApiController) ReviewIdRetryPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	lyricsRetryRequestParam := LyricsRetryRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&lyricsRetryRequestParam); err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	if err := AssertLyricsRetryRequestRequired(lyricsRetryRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.ReviewIdRetryPost(r.Context(), idParam, lyricsRetryRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
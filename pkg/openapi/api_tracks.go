@@ -91,7 +91,28 @@ func (c *TracksApiController) TracksGet(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	queryParam := query.Get("query")
-	result, err := c.service.TracksGet(r.Context(), pageParam, limitParam, queryParam)
+	explicitParam := query.Get("explicit")
+	artistIdParam := query.Get("artistId")
+	releasedFromParam, err := parseInt32Parameter(query.Get("releasedFrom"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	releasedToParam, err := parseInt32Parameter(query.Get("releasedTo"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	addedAfterParam := query.Get("addedAfter")
+	addedBeforeParam := query.Get("addedBefore")
+	minPopularityParam, err := parseInt32Parameter(query.Get("minPopularity"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	playlistIdParam := query.Get("playlistId")
+	cursorParam := query.Get("cursor")
+	result, err := c.service.TracksGet(r.Context(), pageParam, limitParam, queryParam, explicitParam, artistIdParam, releasedFromParam, releasedToParam, addedAfterParam, addedBeforeParam, minPopularityParam, playlistIdParam, cursorParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
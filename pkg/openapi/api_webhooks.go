@@ -0,0 +1,158 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhooksApiController binds http requests to an api service and writes the service results to the http response
+type WebhooksApiController struct {
+	service      WebhooksApiServicer
+	errorHandler ErrorHandler
+}
+
+// WebhooksApiOption for how the controller is set up.
+type WebhooksApiOption func(*WebhooksApiController)
+
+// WithWebhooksApiErrorHandler inject ErrorHandler into controller
+func WithWebhooksApiErrorHandler(h ErrorHandler) WebhooksApiOption {
+	return func(c *WebhooksApiController) {
+		c.errorHandler = h
+	}
+}
+
+// NewWebhooksApiController creates a default api controller
+func NewWebhooksApiController(s WebhooksApiServicer, opts ...WebhooksApiOption) Router {
+	controller := &WebhooksApiController{
+		service:      s,
+		errorHandler: DefaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(controller)
+	}
+
+	return controller
+}
+
+// Routes returns all the api routes for the WebhooksApiController
+func (c *WebhooksApiController) Routes() Routes {
+	return Routes{
+		{
+			"WebhooksGet",
+			strings.ToUpper("Get"),
+			"/api/webhooks",
+			c.WebhooksGet,
+		},
+		{
+			"WebhooksIdDelete",
+			strings.ToUpper("Delete"),
+			"/api/webhooks/{id}",
+			c.WebhooksIdDelete,
+		},
+		{
+			"WebhooksIdDeliveriesGet",
+			strings.ToUpper("Get"),
+			"/api/webhooks/{id}/deliveries",
+			c.WebhooksIdDeliveriesGet,
+		},
+		{
+			"WebhooksPost",
+			strings.ToUpper("Post"),
+			"/api/webhooks",
+			c.WebhooksPost,
+		},
+	}
+}
+
+// WebhooksGet - Returns the configured webhooks
+func (c *WebhooksApiController) WebhooksGet(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.WebhooksGet(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// WebhooksIdDelete - Deletes a webhook and its deliveries
+func (c *WebhooksApiController) WebhooksIdDelete(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	idParam := params["id"]
+
+	result, err := c.service.WebhooksIdDelete(r.Context(), idParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// WebhooksIdDeliveriesGet - Returns the log of deliveries of a webhook
+func (c *WebhooksApiController) WebhooksIdDeliveriesGet(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	query := r.URL.Query()
+	idParam := params["id"]
+
+	pageParam, err := parseInt32Parameter(query.Get("page"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	limitParam, err := parseInt32Parameter(query.Get("limit"), false)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.WebhooksIdDeliveriesGet(r.Context(), idParam, pageParam, limitParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
+
+// WebhooksPost - Adds a webhook that is notified about the given events
+func (c *WebhooksApiController) WebhooksPost(w http.ResponseWriter, r *http.Request) {
+	webhookRequestParam := WebhookRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&webhookRequestParam); err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	if err := AssertWebhookRequestRequired(webhookRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.WebhooksPost(r.Context(), webhookRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	EncodeJSONResponse(result.Body, &result.Code, result.Headers, w)
+
+}
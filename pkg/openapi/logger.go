@@ -10,7 +10,7 @@
 package openapi
 
 import (
-	"log"
+	"github.com/imba28/spolyr/pkg/logging"
 	"net/http"
 	"time"
 )
@@ -21,12 +21,12 @@ func Logger(inner http.Handler, name string) http.Handler {
 
 		inner.ServeHTTP(w, r)
 
-		log.Printf(
-			"%s %s %s %s",
-			r.Method,
-			r.RequestURI,
-			name,
-			time.Since(start),
+		logging.FromContext(r.Context()).Info(
+			"request handled",
+			"method", r.Method,
+			"uri", r.RequestURI,
+			"route", name,
+			"duration", time.Since(start),
 		)
 	})
 }
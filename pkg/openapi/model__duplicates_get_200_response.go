@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type DuplicatesGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []TrackGroup `json:"data"`
+}
+
+// AssertDuplicatesGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertDuplicatesGet200ResponseRequired(obj DuplicatesGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertTrackGroupRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseDuplicatesGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of DuplicatesGet200Response (e.g. [][]DuplicatesGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseDuplicatesGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aDuplicatesGet200Response, ok := obj.(DuplicatesGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertDuplicatesGet200ResponseRequired(aDuplicatesGet200Response)
+	})
+}
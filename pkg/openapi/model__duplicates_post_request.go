@@ -0,0 +1,40 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type DuplicatesPostRequest struct {
+	SpotifyIds []string `json:"spotifyIds"`
+}
+
+// AssertDuplicatesPostRequestRequired checks if the required fields are not zero-ed
+func AssertDuplicatesPostRequestRequired(obj DuplicatesPostRequest) error {
+	elements := map[string]interface{}{
+		"spotifyIds": obj.SpotifyIds,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseDuplicatesPostRequestRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of DuplicatesPostRequest (e.g. [][]DuplicatesPostRequest), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseDuplicatesPostRequestRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aDuplicatesPostRequest, ok := obj.(DuplicatesPostRequest)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertDuplicatesPostRequestRequired(aDuplicatesPostRequest)
+	})
+}
@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type HistoryCountsGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []PlayCount `json:"data"`
+}
+
+// AssertHistoryCountsGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertHistoryCountsGet200ResponseRequired(obj HistoryCountsGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertPlayCountRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseHistoryCountsGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of HistoryCountsGet200Response (e.g. [][]HistoryCountsGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseHistoryCountsGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aHistoryCountsGet200Response, ok := obj.(HistoryCountsGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertHistoryCountsGet200ResponseRequired(aHistoryCountsGet200Response)
+	})
+}
@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type HistoryRecentGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []PlayedTrack `json:"data"`
+}
+
+// AssertHistoryRecentGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertHistoryRecentGet200ResponseRequired(obj HistoryRecentGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertPlayedTrackRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseHistoryRecentGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of HistoryRecentGet200Response (e.g. [][]HistoryRecentGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseHistoryRecentGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aHistoryRecentGet200Response, ok := obj.(HistoryRecentGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertHistoryRecentGet200ResponseRequired(aHistoryRecentGet200Response)
+	})
+}
@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type OrphansGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []TrackInfo `json:"data"`
+}
+
+// AssertOrphansGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertOrphansGet200ResponseRequired(obj OrphansGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertTrackInfoRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseOrphansGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of OrphansGet200Response (e.g. [][]OrphansGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseOrphansGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aOrphansGet200Response, ok := obj.(OrphansGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertOrphansGet200ResponseRequired(aOrphansGet200Response)
+	})
+}
@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type PlaylistsImportedIdTracksGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []TrackInfo `json:"data"`
+}
+
+// AssertPlaylistsImportedIdTracksGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertPlaylistsImportedIdTracksGet200ResponseRequired(obj PlaylistsImportedIdTracksGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertTrackInfoRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecursePlaylistsImportedIdTracksGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of PlaylistsImportedIdTracksGet200Response (e.g. [][]PlaylistsImportedIdTracksGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecursePlaylistsImportedIdTracksGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aPlaylistsImportedIdTracksGet200Response, ok := obj.(PlaylistsImportedIdTracksGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertPlaylistsImportedIdTracksGet200ResponseRequired(aPlaylistsImportedIdTracksGet200Response)
+	})
+}
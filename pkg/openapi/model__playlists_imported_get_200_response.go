@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type PlaylistsImportedGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []PlaylistInfo `json:"data"`
+}
+
+// AssertPlaylistsImportedGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertPlaylistsImportedGet200ResponseRequired(obj PlaylistsImportedGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertPlaylistInfoRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecursePlaylistsImportedGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of PlaylistsImportedGet200Response (e.g. [][]PlaylistsImportedGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecursePlaylistsImportedGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aPlaylistsImportedGet200Response, ok := obj.(PlaylistsImportedGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertPlaylistsImportedGet200ResponseRequired(aPlaylistsImportedGet200Response)
+	})
+}
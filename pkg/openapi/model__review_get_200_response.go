@@ -0,0 +1,55 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type ReviewGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []ReviewItem `json:"data"`
+
+	// Names of the lyrics providers a track can be retried with
+	Providers []string `json:"providers"`
+}
+
+// AssertReviewGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertReviewGet200ResponseRequired(obj ReviewGet200Response) error {
+	elements := map[string]interface{}{
+		"meta":      obj.Meta,
+		"data":      obj.Data,
+		"providers": obj.Providers,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertReviewItemRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseReviewGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of ReviewGet200Response (e.g. [][]ReviewGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseReviewGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aReviewGet200Response, ok := obj.(ReviewGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertReviewGet200ResponseRequired(aReviewGet200Response)
+	})
+}
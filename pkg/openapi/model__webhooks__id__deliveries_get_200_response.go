@@ -0,0 +1,51 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type WebhooksIdDeliveriesGet200Response struct {
+	Meta PaginationMetadata `json:"meta"`
+
+	Data []WebhookDelivery `json:"data"`
+}
+
+// AssertWebhooksIdDeliveriesGet200ResponseRequired checks if the required fields are not zero-ed
+func AssertWebhooksIdDeliveriesGet200ResponseRequired(obj WebhooksIdDeliveriesGet200Response) error {
+	elements := map[string]interface{}{
+		"meta": obj.Meta,
+		"data": obj.Data,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertPaginationMetadataRequired(obj.Meta); err != nil {
+		return err
+	}
+	for _, el := range obj.Data {
+		if err := AssertWebhookDeliveryRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseWebhooksIdDeliveriesGet200ResponseRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of WebhooksIdDeliveriesGet200Response (e.g. [][]WebhooksIdDeliveriesGet200Response), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseWebhooksIdDeliveriesGet200ResponseRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aWebhooksIdDeliveriesGet200Response, ok := obj.(WebhooksIdDeliveriesGet200Response)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertWebhooksIdDeliveriesGet200ResponseRequired(aWebhooksIdDeliveriesGet200Response)
+	})
+}
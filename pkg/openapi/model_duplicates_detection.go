@@ -0,0 +1,46 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type DuplicatesDetection struct {
+	NumberOfGroups int32 `json:"numberOfGroups"`
+
+	NumberOfTracksChanged int32 `json:"numberOfTracksChanged"`
+
+	NumberOfSharedLyrics int32 `json:"numberOfSharedLyrics"`
+}
+
+// AssertDuplicatesDetectionRequired checks if the required fields are not zero-ed
+func AssertDuplicatesDetectionRequired(obj DuplicatesDetection) error {
+	elements := map[string]interface{}{
+		"numberOfGroups":        obj.NumberOfGroups,
+		"numberOfTracksChanged": obj.NumberOfTracksChanged,
+		"numberOfSharedLyrics":  obj.NumberOfSharedLyrics,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseDuplicatesDetectionRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of DuplicatesDetection (e.g. [][]DuplicatesDetection), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseDuplicatesDetectionRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aDuplicatesDetection, ok := obj.(DuplicatesDetection)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertDuplicatesDetectionRequired(aDuplicatesDetection)
+	})
+}
@@ -0,0 +1,56 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type ImportSummary struct {
+
+	// Number of tracks that have not been stored before
+	New int32 `json:"new"`
+
+	// Number of tracks that were already known
+	Unchanged int32 `json:"unchanged"`
+
+	// Set if nothing has been downloaded because the source has not changed since the last import
+	Skipped bool `json:"skipped"`
+
+	// Number of play events recorded by an import of the listening history
+	Plays int32 `json:"plays,omitempty"`
+
+	// Number of tracks that could not be saved
+	Failed int32 `json:"failed,omitempty"`
+}
+
+// AssertImportSummaryRequired checks if the required fields are not zero-ed
+func AssertImportSummaryRequired(obj ImportSummary) error {
+	elements := map[string]interface{}{
+		"new":       obj.New,
+		"unchanged": obj.Unchanged,
+		"skipped":   obj.Skipped,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseImportSummaryRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of ImportSummary (e.g. [][]ImportSummary), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseImportSummaryRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aImportSummary, ok := obj.(ImportSummary)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertImportSummaryRequired(aImportSummary)
+	})
+}
@@ -0,0 +1,47 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type LyricsImportError struct {
+	Message string `json:"message"`
+
+	At time.Time `json:"at"`
+}
+
+// AssertLyricsImportErrorRequired checks if the required fields are not zero-ed
+func AssertLyricsImportErrorRequired(obj LyricsImportError) error {
+	elements := map[string]interface{}{
+		"message": obj.Message,
+		"at":      obj.At,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseLyricsImportErrorRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of LyricsImportError (e.g. [][]LyricsImportError), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseLyricsImportErrorRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aLyricsImportError, ok := obj.(LyricsImportError)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertLyricsImportErrorRequired(aLyricsImportError)
+	})
+}
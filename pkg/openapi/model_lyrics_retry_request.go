@@ -0,0 +1,38 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type LyricsRetryRequest struct {
+	// Only asks the lyrics provider with this name
+	Provider string `json:"provider,omitempty"`
+
+	// Searches for this artist instead of the first artist of the track
+	Artist string `json:"artist,omitempty"`
+
+	// Searches for this title instead of the name of the track
+	Title string `json:"title,omitempty"`
+}
+
+// AssertLyricsRetryRequestRequired checks if the required fields are not zero-ed
+func AssertLyricsRetryRequestRequired(obj LyricsRetryRequest) error {
+	return nil
+}
+
+// AssertRecurseLyricsRetryRequestRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of LyricsRetryRequest (e.g. [][]LyricsRetryRequest), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseLyricsRetryRequestRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aLyricsRetryRequest, ok := obj.(LyricsRetryRequest)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertLyricsRetryRequestRequired(aLyricsRetryRequest)
+	})
+}
@@ -0,0 +1,57 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type LyricsReview struct {
+	Lyrics string `json:"lyrics"`
+
+	Language string `json:"language,omitempty"`
+
+	// Confidence that the lyrics belong to the track, between 0 and 1
+	Score float64 `json:"score"`
+
+	// Issues found while validating the lyrics, e.g. too-short or title-missing
+	Issues []string `json:"issues"`
+
+	FlaggedAt time.Time `json:"flaggedAt"`
+}
+
+// AssertLyricsReviewRequired checks if the required fields are not zero-ed
+func AssertLyricsReviewRequired(obj LyricsReview) error {
+	elements := map[string]interface{}{
+		"lyrics":    obj.Lyrics,
+		"score":     obj.Score,
+		"issues":    obj.Issues,
+		"flaggedAt": obj.FlaggedAt,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseLyricsReviewRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of LyricsReview (e.g. [][]LyricsReview), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseLyricsReviewRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aLyricsReview, ok := obj.(LyricsReview)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertLyricsReviewRequired(aLyricsReview)
+	})
+}
@@ -0,0 +1,54 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type NowPlaying struct {
+	IsPlaying bool `json:"isPlaying"`
+
+	// Progress into the currently playing track
+	ProgressMs int32 `json:"progressMs"`
+
+	// Set if the track has not been known before and has been imported
+	Imported bool `json:"imported"`
+
+	Track TrackDetail `json:"track"`
+}
+
+// AssertNowPlayingRequired checks if the required fields are not zero-ed
+func AssertNowPlayingRequired(obj NowPlaying) error {
+	elements := map[string]interface{}{
+		"isPlaying":  obj.IsPlaying,
+		"progressMs": obj.ProgressMs,
+		"imported":   obj.Imported,
+		"track":      obj.Track,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertTrackDetailRequired(obj.Track); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AssertRecurseNowPlayingRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of NowPlaying (e.g. [][]NowPlaying), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseNowPlayingRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aNowPlaying, ok := obj.(NowPlaying)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertNowPlayingRequired(aNowPlaying)
+	})
+}
@@ -0,0 +1,46 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type OrphansReconciliation struct {
+	NumberOfOrphanedTracks int32 `json:"numberOfOrphanedTracks"`
+
+	NumberOfRestoredTracks int32 `json:"numberOfRestoredTracks"`
+
+	NumberOfDeletedTracks int32 `json:"numberOfDeletedTracks"`
+}
+
+// AssertOrphansReconciliationRequired checks if the required fields are not zero-ed
+func AssertOrphansReconciliationRequired(obj OrphansReconciliation) error {
+	elements := map[string]interface{}{
+		"numberOfOrphanedTracks": obj.NumberOfOrphanedTracks,
+		"numberOfRestoredTracks": obj.NumberOfRestoredTracks,
+		"numberOfDeletedTracks":  obj.NumberOfDeletedTracks,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseOrphansReconciliationRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of OrphansReconciliation (e.g. [][]OrphansReconciliation), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseOrphansReconciliationRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aOrphansReconciliation, ok := obj.(OrphansReconciliation)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertOrphansReconciliationRequired(aOrphansReconciliation)
+	})
+}
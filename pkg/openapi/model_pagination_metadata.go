@@ -15,6 +15,9 @@ type PaginationMetadata struct {
 	Limit int32 `json:"limit,omitempty"`
 
 	Total int32 `json:"total,omitempty"`
+
+	// Opaque cursor of the next page, if there is one
+	Next string `json:"next,omitempty"`
 }
 
 // AssertPaginationMetadataRequired checks if the required fields are not zero-ed
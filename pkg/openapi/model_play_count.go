@@ -0,0 +1,53 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type PlayCount struct {
+	Track TrackInfo `json:"track"`
+
+	Plays int32 `json:"plays"`
+
+	LastPlayedAt time.Time `json:"lastPlayedAt"`
+}
+
+// AssertPlayCountRequired checks if the required fields are not zero-ed
+func AssertPlayCountRequired(obj PlayCount) error {
+	elements := map[string]interface{}{
+		"track":        obj.Track,
+		"plays":        obj.Plays,
+		"lastPlayedAt": obj.LastPlayedAt,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertTrackInfoRequired(obj.Track); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AssertRecursePlayCountRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of PlayCount (e.g. [][]PlayCount), otherwise ErrTypeAssertionError is thrown.
+func AssertRecursePlayCountRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aPlayCount, ok := obj.(PlayCount)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertPlayCountRequired(aPlayCount)
+	})
+}
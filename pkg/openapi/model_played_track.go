@@ -0,0 +1,50 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type PlayedTrack struct {
+	PlayedAt time.Time `json:"playedAt"`
+
+	Track TrackDetail `json:"track"`
+}
+
+// AssertPlayedTrackRequired checks if the required fields are not zero-ed
+func AssertPlayedTrackRequired(obj PlayedTrack) error {
+	elements := map[string]interface{}{
+		"playedAt": obj.PlayedAt,
+		"track":    obj.Track,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertTrackDetailRequired(obj.Track); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AssertRecursePlayedTrackRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of PlayedTrack (e.g. [][]PlayedTrack), otherwise ErrTypeAssertionError is thrown.
+func AssertRecursePlayedTrackRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aPlayedTrack, ok := obj.(PlayedTrack)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertPlayedTrackRequired(aPlayedTrack)
+	})
+}
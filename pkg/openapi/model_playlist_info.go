@@ -9,6 +9,10 @@
 
 package openapi
 
+import (
+	"time"
+)
+
 type PlaylistInfo struct {
 	SpotifyId string `json:"spotifyId,omitempty"`
 
@@ -23,6 +27,10 @@ type PlaylistInfo struct {
 	IsPublic bool `json:"isPublic,omitempty"`
 
 	IsCollaborative bool `json:"isCollaborative,omitempty"`
+
+	SnapshotId string `json:"snapshotId,omitempty"`
+
+	ImportedAt time.Time `json:"importedAt,omitempty"`
 }
 
 // AssertPlaylistInfoRequired checks if the required fields are not zero-ed
@@ -0,0 +1,64 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type ReviewItem struct {
+	Track TrackInfo `json:"track"`
+
+	// Whether importing the lyrics failed too often or the fetched lyrics have been flagged
+	Reason string `json:"reason"`
+
+	LyricsImportErrorCount int32 `json:"lyricsImportErrorCount"`
+
+	// The most recent errors that occurred while importing the lyrics, the oldest first
+	Errors []LyricsImportError `json:"errors"`
+
+	Review LyricsReview `json:"review,omitempty"`
+}
+
+// AssertReviewItemRequired checks if the required fields are not zero-ed
+func AssertReviewItemRequired(obj ReviewItem) error {
+	elements := map[string]interface{}{
+		"track":                  obj.Track,
+		"reason":                 obj.Reason,
+		"lyricsImportErrorCount": obj.LyricsImportErrorCount,
+		"errors":                 obj.Errors,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	if err := AssertTrackInfoRequired(obj.Track); err != nil {
+		return err
+	}
+	for _, el := range obj.Errors {
+		if err := AssertLyricsImportErrorRequired(el); err != nil {
+			return err
+		}
+	}
+	if err := AssertLyricsReviewRequired(obj.Review); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AssertRecurseReviewItemRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of ReviewItem (e.g. [][]ReviewItem), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseReviewItemRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aReviewItem, ok := obj.(ReviewItem)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertReviewItemRequired(aReviewItem)
+	})
+}
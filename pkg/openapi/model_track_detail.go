@@ -9,6 +9,10 @@
 
 package openapi
 
+import (
+	"time"
+)
+
 type TrackDetail struct {
 	SpotifyId string `json:"spotifyId"`
 
@@ -26,9 +30,29 @@ type TrackDetail struct {
 
 	Language string `json:"language,omitempty"`
 
+	// Set if the track has been removed from the library and all imported playlists
+	OrphanedAt time.Time `json:"orphanedAt,omitempty"`
+
 	Lyrics string `json:"lyrics"`
 
 	LyricsImportErrorCount int32 `json:"lyricsImportErrorCount"`
+
+	ArtistIds []string `json:"artistIds,omitempty"`
+
+	Isrc string `json:"isrc,omitempty"`
+
+	DurationMs int32 `json:"durationMs,omitempty"`
+
+	Explicit bool `json:"explicit,omitempty"`
+
+	ReleaseDate string `json:"releaseDate,omitempty"`
+
+	Popularity int32 `json:"popularity,omitempty"`
+
+	AddedAt time.Time `json:"addedAt,omitempty"`
+
+	// Set if the track has no lyrics, so they are never fetched
+	Instrumental bool `json:"instrumental,omitempty"`
 }
 
 // AssertTrackDetailRequired checks if the required fields are not zero-ed
@@ -9,12 +9,30 @@
 
 package openapi
 
+import (
+	"time"
+)
+
 type TrackDetailAllOf struct {
 	Lyrics string `json:"lyrics"`
 
 	LyricsImportErrorCount int32 `json:"lyricsImportErrorCount"`
 
 	HasLyrics bool `json:"hasLyrics"`
+
+	ArtistIds []string `json:"artistIds,omitempty"`
+
+	Isrc string `json:"isrc,omitempty"`
+
+	DurationMs int32 `json:"durationMs,omitempty"`
+
+	Explicit bool `json:"explicit,omitempty"`
+
+	ReleaseDate string `json:"releaseDate,omitempty"`
+
+	Popularity int32 `json:"popularity,omitempty"`
+
+	AddedAt time.Time `json:"addedAt,omitempty"`
 }
 
 // AssertTrackDetailAllOfRequired checks if the required fields are not zero-ed
@@ -0,0 +1,48 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type TrackGroup struct {
+	Id string `json:"id"`
+
+	Tracks []TrackInfo `json:"tracks"`
+}
+
+// AssertTrackGroupRequired checks if the required fields are not zero-ed
+func AssertTrackGroupRequired(obj TrackGroup) error {
+	elements := map[string]interface{}{
+		"id":     obj.Id,
+		"tracks": obj.Tracks,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	for _, el := range obj.Tracks {
+		if err := AssertTrackInfoRequired(el); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertRecurseTrackGroupRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of TrackGroup (e.g. [][]TrackGroup), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseTrackGroupRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aTrackGroup, ok := obj.(TrackGroup)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertTrackGroupRequired(aTrackGroup)
+	})
+}
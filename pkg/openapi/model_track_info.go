@@ -9,6 +9,10 @@
 
 package openapi
 
+import (
+	"time"
+)
+
 type TrackInfo struct {
 	SpotifyId string `json:"spotifyId"`
 
@@ -25,6 +29,9 @@ type TrackInfo struct {
 	HasLyrics bool `json:"hasLyrics"`
 
 	Language string `json:"language,omitempty"`
+
+	// Set if the track has been removed from the library and all imported playlists
+	OrphanedAt time.Time `json:"orphanedAt,omitempty"`
 }
 
 // AssertTrackInfoRequired checks if the required fields are not zero-ed
@@ -13,6 +13,9 @@ type TracksStats struct {
 	NumberOfTracks int32 `json:"numberOfTracks"`
 
 	NumberOfTracksWithLyrics int32 `json:"numberOfTracksWithLyrics"`
+
+	// Number of tracks without lyrics, they are not counted as tracks whose lyrics are missing
+	NumberOfInstrumentalTracks int32 `json:"numberOfInstrumentalTracks,omitempty"`
 }
 
 // AssertTracksStatsRequired checks if the required fields are not zero-ed
@@ -0,0 +1,53 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type Webhook struct {
+	Id string `json:"id"`
+
+	Url string `json:"url"`
+
+	Events []string `json:"events"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AssertWebhookRequired checks if the required fields are not zero-ed
+func AssertWebhookRequired(obj Webhook) error {
+	elements := map[string]interface{}{
+		"id":        obj.Id,
+		"url":       obj.Url,
+		"events":    obj.Events,
+		"createdAt": obj.CreatedAt,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseWebhookRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of Webhook (e.g. [][]Webhook), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseWebhookRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aWebhook, ok := obj.(Webhook)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertWebhookRequired(aWebhook)
+	})
+}
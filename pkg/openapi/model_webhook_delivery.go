@@ -0,0 +1,69 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+type WebhookDelivery struct {
+	Id string `json:"id"`
+
+	Event string `json:"event"`
+
+	Payload string `json:"payload"`
+
+	Attempts int32 `json:"attempts"`
+
+	// Status code of the response to the last attempt
+	StatusCode int32 `json:"statusCode,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	Success bool `json:"success"`
+
+	// Set until the delivery has succeeded or all attempts have failed
+	Pending bool `json:"pending,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	DeliveredAt time.Time `json:"deliveredAt,omitempty"`
+}
+
+// AssertWebhookDeliveryRequired checks if the required fields are not zero-ed
+func AssertWebhookDeliveryRequired(obj WebhookDelivery) error {
+	elements := map[string]interface{}{
+		"id":        obj.Id,
+		"event":     obj.Event,
+		"payload":   obj.Payload,
+		"attempts":  obj.Attempts,
+		"success":   obj.Success,
+		"createdAt": obj.CreatedAt,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseWebhookDeliveryRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of WebhookDelivery (e.g. [][]WebhookDelivery), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseWebhookDeliveryRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aWebhookDelivery, ok := obj.(WebhookDelivery)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertWebhookDeliveryRequired(aWebhookDelivery)
+	})
+}
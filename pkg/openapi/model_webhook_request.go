@@ -0,0 +1,46 @@
+/*
+ * Spolyr
+ *
+ * No description provided (generated by Openapi Generator https://github.com/openapitools/openapi-generator)
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+type WebhookRequest struct {
+	Url string `json:"url"`
+
+	// Used to sign the payloads. The signature is sent in the X-Spolyr-Signature header.
+	Secret string `json:"secret,omitempty"`
+
+	Events []string `json:"events"`
+}
+
+// AssertWebhookRequestRequired checks if the required fields are not zero-ed
+func AssertWebhookRequestRequired(obj WebhookRequest) error {
+	elements := map[string]interface{}{
+		"url":    obj.Url,
+		"events": obj.Events,
+	}
+	for name, el := range elements {
+		if isZero := IsZeroValue(el); isZero {
+			return &RequiredError{Field: name}
+		}
+	}
+
+	return nil
+}
+
+// AssertRecurseWebhookRequestRequired recursively checks if required fields are not zero-ed in a nested slice.
+// Accepts only nested slice of WebhookRequest (e.g. [][]WebhookRequest), otherwise ErrTypeAssertionError is thrown.
+func AssertRecurseWebhookRequestRequired(objSlice interface{}) error {
+	return AssertRecurseInterfaceRequired(objSlice, func(obj interface{}) error {
+		aWebhookRequest, ok := obj.(WebhookRequest)
+		if !ok {
+			return ErrTypeAssertionError
+		}
+		return AssertWebhookRequestRequired(aWebhookRequest)
+	})
+}
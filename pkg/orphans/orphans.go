@@ -0,0 +1,139 @@
+package orphans
+
+import (
+	"context"
+	"fmt"
+	"github.com/imba28/spolyr/pkg/db"
+	"time"
+)
+
+// Policy defines how tracks are treated that are neither saved in the library nor part of an imported playlist.
+type Policy string
+
+const (
+	// PolicyKeep marks orphaned tracks but keeps them searchable.
+	PolicyKeep Policy = "keep"
+	// PolicyHide removes orphaned tracks from the search results.
+	PolicyHide Policy = "hide"
+	// PolicyDelete removes orphaned tracks from the search results and deletes them after the retention period.
+	PolicyDelete Policy = "delete"
+)
+
+// ParsePolicy returns the policy with the given name.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyKeep, PolicyHide, PolicyDelete:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown orphan policy %q, expected one of keep, hide or delete", s)
+}
+
+// HidesOrphans reports whether orphaned tracks must be excluded from the search results.
+func (p Policy) HidesOrphans() bool {
+	return p == PolicyHide || p == PolicyDelete
+}
+
+type store interface {
+	IterateTracks() *db.TrackIterator
+	SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error
+	DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error)
+}
+
+// Result summarizes a reconciliation.
+type Result struct {
+	Orphaned int
+	Restored int
+	Deleted  int
+}
+
+// Reconciler compares the stored tracks with the library of the user and the imported playlists.
+type Reconciler struct {
+	store     store
+	policy    Policy
+	retention time.Duration
+	now       func() time.Time
+}
+
+// Reconcile marks all tracks as orphaned that are neither part of libraryIDs nor of an imported playlist and restores
+// tracks that have been added again. Tracks imported from albums or artists are never orphaned. libraryIDs must contain the Spotify IDs of all tracks of the library.
+// Tracks imported before their origin has been recorded are skipped, see originUnknown.
+func (r Reconciler) Reconcile(ctx context.Context, libraryIDs []string) (Result, error) {
+	inLibrary := make(map[string]bool, len(libraryIDs))
+	for _, id := range libraryIDs {
+		inLibrary[id] = true
+	}
+	return r.reconcile(ctx, func(t *db.Track) bool {
+		return inLibrary[t.SpotifyID]
+	})
+}
+
+// ReconcilePlaylists marks tracks as orphaned that have been removed from all imported playlists and restores tracks
+// that have been added to a playlist again. The library is not downloaded, so whether a track of the library is still
+// saved is kept as of the last Reconcile.
+func (r Reconciler) ReconcilePlaylists(ctx context.Context) (Result, error) {
+	return r.reconcile(ctx, func(t *db.Track) bool {
+		return !t.AddedAt.IsZero() && t.OrphanedAt.IsZero()
+	})
+}
+
+func (r Reconciler) reconcile(ctx context.Context, inLibrary func(t *db.Track) bool) (Result, error) {
+	var res Result
+	now := r.now()
+
+	it := r.store.IterateTracks()
+	for it.Next(ctx) {
+		t := it.Track()
+		orphaned := !inLibrary(t) && len(t.PlaylistIDs) == 0 && !importedExplicitly(t) && !originUnknown(t)
+		switch {
+		case orphaned && t.OrphanedAt.IsZero():
+			if err := r.store.SetOrphaned(ctx, t.SpotifyID, now); err != nil {
+				return res, err
+			}
+			res.Orphaned++
+		case !orphaned && !t.OrphanedAt.IsZero():
+			if err := r.store.SetOrphaned(ctx, t.SpotifyID, time.Time{}); err != nil {
+				return res, err
+			}
+			res.Restored++
+		}
+	}
+	if err := it.Err(); err != nil {
+		return res, err
+	}
+
+	if r.policy == PolicyDelete {
+		n, err := r.store.DeleteOrphans(ctx, now.Add(-r.retention))
+		if err != nil {
+			return res, err
+		}
+		res.Deleted = n
+	}
+
+	return res, nil
+}
+
+// importedExplicitly reports whether a track has been imported from a source that keeps it, e.g. an album.
+func importedExplicitly(t *db.Track) bool {
+	for _, source := range t.Sources {
+		if source != db.SourcePlaylist {
+			return true
+		}
+	}
+	return false
+}
+
+// originUnknown reports whether it is unknown where a track has been imported from. Library tracks store the time they
+// have been added and playlist tracks their source, but tracks imported before both were recorded have neither.
+// They might still be part of a playlist that has not been imported again, so they must not be orphaned.
+func originUnknown(t *db.Track) bool {
+	return t.AddedAt.IsZero() && len(t.PlaylistIDs) == 0 && len(t.Sources) == 0
+}
+
+func New(s store, policy Policy, retention time.Duration) Reconciler {
+	return Reconciler{
+		store:     s,
+		policy:    policy,
+		retention: retention,
+		now:       time.Now,
+	}
+}
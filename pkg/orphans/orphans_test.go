@@ -0,0 +1,117 @@
+package orphans
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type storeMock struct {
+	mock.Mock
+}
+
+func (s *storeMock) IterateTracks() *db.TrackIterator {
+	return db.NewTrackIterator(func(ctx context.Context, cursor string) ([]*db.Track, string, error) {
+		args := s.MethodCalled("IterateTracks", cursor)
+		return args.Get(0).([]*db.Track), args.String(1), args.Error(2)
+	})
+}
+func (s *storeMock) SetOrphaned(ctx context.Context, spotifyID string, orphanedAt time.Time) error {
+	return s.Called(spotifyID, orphanedAt).Error(0)
+}
+func (s *storeMock) DeleteOrphans(ctx context.Context, orphanedBefore time.Time) (int, error) {
+	args := s.Called(orphanedBefore)
+	return args.Int(0), args.Error(1)
+}
+
+var _ store = &storeMock{}
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{PolicyKeep, PolicyHide, PolicyDelete} {
+		parsed, err := ParsePolicy(string(p))
+		assert.Nil(t, err)
+		assert.Equal(t, p, parsed)
+	}
+
+	_, err := ParsePolicy("forget")
+	assert.Error(t, err)
+}
+
+func TestPolicy_HidesOrphans(t *testing.T) {
+	assert.False(t, PolicyKeep.HidesOrphans())
+	assert.True(t, PolicyHide.HidesOrphans())
+	assert.True(t, PolicyDelete.HidesOrphans())
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	now := time.Date(2021, 6, 24, 12, 0, 0, 0, time.UTC)
+	addedAt := now.Add(-24 * time.Hour)
+	tracks := []*db.Track{
+		{SpotifyID: "library", AddedAt: addedAt},
+		{SpotifyID: "playlist", PlaylistIDs: []string{"p"}, Sources: []string{db.SourcePlaylist}},
+		{SpotifyID: "album", Sources: []string{db.SourceAlbum}},
+		{SpotifyID: "removed", AddedAt: addedAt},
+		{SpotifyID: "already-orphaned", AddedAt: addedAt, OrphanedAt: now.Add(-time.Hour)},
+		{SpotifyID: "saved-again", AddedAt: addedAt, OrphanedAt: now.Add(-time.Hour)},
+		{SpotifyID: "unknown-origin"},
+		{SpotifyID: "unknown-origin-orphaned", OrphanedAt: now.Add(-time.Hour)},
+		{SpotifyID: "removed-from-playlist", Sources: []string{db.SourcePlaylist}},
+	}
+
+	t.Run("marks and restores orphans", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("IterateTracks", "").Return(tracks[:4], "next", nil)
+		s.On("IterateTracks", "next").Return(tracks[4:], "", nil)
+		s.On("SetOrphaned", "removed", now).Return(nil)
+		s.On("SetOrphaned", "saved-again", time.Time{}).Return(nil)
+		s.On("SetOrphaned", "unknown-origin-orphaned", time.Time{}).Return(nil)
+		s.On("SetOrphaned", "removed-from-playlist", now).Return(nil)
+		r := New(s, PolicyHide, 0)
+		r.now = func() time.Time { return now }
+
+		res, err := r.Reconcile(context.Background(), []string{"library", "saved-again"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Orphaned: 2, Restored: 2}, res)
+		s.AssertExpectations(t)
+		s.AssertNotCalled(t, "DeleteOrphans", mock.Anything)
+	})
+
+	t.Run("orphans tracks removed from their only playlist without downloading the library", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("IterateTracks", "").Return([]*db.Track{
+			{SpotifyID: "library", AddedAt: addedAt},
+			{SpotifyID: "removed-from-library", AddedAt: addedAt, OrphanedAt: now.Add(-time.Hour)},
+			{SpotifyID: "removed-from-playlist", Sources: []string{db.SourcePlaylist}},
+			{SpotifyID: "added-to-playlist", AddedAt: addedAt, PlaylistIDs: []string{"p"}, Sources: []string{db.SourcePlaylist}, OrphanedAt: now.Add(-time.Hour)},
+			{SpotifyID: "album", Sources: []string{db.SourcePlaylist, db.SourceAlbum}},
+		}, "", nil)
+		s.On("SetOrphaned", "removed-from-playlist", now).Return(nil)
+		s.On("SetOrphaned", "added-to-playlist", time.Time{}).Return(nil)
+		r := New(s, PolicyKeep, 0)
+		r.now = func() time.Time { return now }
+
+		res, err := r.ReconcilePlaylists(context.Background())
+
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Orphaned: 1, Restored: 1}, res)
+		s.AssertExpectations(t)
+	})
+
+	t.Run("deletes orphans after the retention period", func(t *testing.T) {
+		s := new(storeMock)
+		s.On("IterateTracks", "").Return([]*db.Track{}, "", nil)
+		s.On("DeleteOrphans", now.Add(-24*time.Hour)).Return(3, nil)
+		r := New(s, PolicyDelete, 24*time.Hour)
+		r.now = func() time.Time { return now }
+
+		res, err := r.Reconcile(context.Background(), nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, Result{Deleted: 3}, res)
+		s.AssertExpectations(t)
+	})
+}
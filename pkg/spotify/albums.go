@@ -0,0 +1,206 @@
+package spotify
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/zmb3/spotify/v2"
+)
+
+// pageLimit is the maximum number of items Spotify returns per page.
+const pageLimit = 50
+
+// AlbumProvider imports complete albums, either saved in the library, requested by ID or released by followed artists.
+type AlbumProvider struct {
+	c     *spotify.Client
+	saver trackSaver
+}
+
+// SavedAlbums imports all tracks of the albums saved in the library of the user.
+func (p AlbumProvider) SavedAlbums(ctx context.Context) (ImportResult, error) {
+	var r ImportResult
+
+	page, err := p.c.CurrentUsersAlbums(ctx, spotify.Limit(pageLimit))
+	if err != nil {
+		return r, err
+	}
+
+	for {
+		for i := range page.Albums {
+			album := page.Albums[i].FullAlbum
+			if err := p.saveAlbumTracks(ctx, album.SimpleAlbum, &album.Tracks, db.SourceAlbum, &r); err != nil {
+				return r, err
+			}
+		}
+
+		err = p.c.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			break
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+
+	r.Complete = true
+	return r, nil
+}
+
+// Album imports all tracks of the album with the given Spotify ID.
+func (p AlbumProvider) Album(ctx context.Context, ID string) (ImportResult, error) {
+	var r ImportResult
+
+	album, err := p.c.GetAlbum(ctx, spotify.ID(ID))
+	if err != nil {
+		return r, err
+	}
+
+	if err := p.saveAlbumTracks(ctx, album.SimpleAlbum, &album.Tracks, db.SourceAlbum, &r); err != nil {
+		return r, err
+	}
+
+	r.Complete = true
+	return r, nil
+}
+
+// FollowedArtists imports the top tracks of all artists the user follows. If discography is set, all albums and
+// singles of the artists are imported as well.
+func (p AlbumProvider) FollowedArtists(ctx context.Context, discography bool) (ImportResult, error) {
+	var r ImportResult
+
+	opts := []spotify.RequestOption{spotify.Limit(pageLimit)}
+	for {
+		page, err := p.c.CurrentUsersFollowedArtists(ctx, opts...)
+		if err != nil {
+			return r, err
+		}
+
+		for i := range page.Artists {
+			if err := p.saveTopTracks(ctx, page.Artists[i].ID, &r); err != nil {
+				return r, err
+			}
+			if !discography {
+				continue
+			}
+			if err := p.saveDiscography(ctx, page.Artists[i].ID, &r); err != nil {
+				return r, err
+			}
+		}
+
+		if page.Next == "" || page.Cursor.After == "" {
+			break
+		}
+		opts = []spotify.RequestOption{spotify.Limit(pageLimit), spotify.After(page.Cursor.After)}
+	}
+
+	r.Complete = true
+	return r, nil
+}
+
+func (p AlbumProvider) saveTopTracks(ctx context.Context, artistID spotify.ID, r *ImportResult) error {
+	tracks, err := p.c.GetArtistsTopTracks(ctx, artistID, spotify.MarketFromToken)
+	if err != nil {
+		return err
+	}
+
+	topTracks := make([]*db.Track, len(tracks))
+	for i := range tracks {
+		track := db.NewTrack(tracks[i])
+		track.Sources = []string{db.SourceArtist}
+		topTracks[i] = &track
+	}
+	return saveTracks(ctx, p.saver, p.c, topTracks, r)
+}
+
+func (p AlbumProvider) saveDiscography(ctx context.Context, artistID spotify.ID, r *ImportResult) error {
+	page, err := p.c.GetArtistAlbums(ctx, artistID, []spotify.AlbumType{spotify.AlbumTypeAlbum, spotify.AlbumTypeSingle}, spotify.Limit(pageLimit))
+	if err != nil {
+		return err
+	}
+
+	for {
+		for i := range page.Albums {
+			tracks, err := p.c.GetAlbumTracks(ctx, page.Albums[i].ID, spotify.Limit(pageLimit))
+			if err != nil {
+				return err
+			}
+			if err := p.saveAlbumTracks(ctx, page.Albums[i], tracks, db.SourceArtist, r); err != nil {
+				return err
+			}
+		}
+
+		err = p.c.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// saveAlbumTracks stores all tracks of an album. Album tracks lack the popularity and ISRC of a track, so the full
+// tracks are fetched before they are saved.
+func (p AlbumProvider) saveAlbumTracks(ctx context.Context, album spotify.SimpleAlbum, page *spotify.SimpleTrackPage, source string, r *ImportResult) error {
+	for {
+		tracks, err := p.fullTracks(ctx, album, page.Tracks)
+		if err != nil {
+			return err
+		}
+		for _, track := range tracks {
+			track.Sources = []string{source}
+		}
+		if err := saveTracks(ctx, p.saver, p.c, tracks, r); err != nil {
+			return err
+		}
+
+		if page.Next == "" {
+			return nil
+		}
+		err = p.c.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fullTracks fetches the full tracks of album tracks. Tracks that cannot be found are created from the album track and
+// the album, so they are still imported.
+func (p AlbumProvider) fullTracks(ctx context.Context, album spotify.SimpleAlbum, albumTracks []spotify.SimpleTrack) ([]*db.Track, error) {
+	tracks := make([]*db.Track, 0, len(albumTracks))
+	for start := 0; start < len(albumTracks); start += pageLimit {
+		end := start + pageLimit
+		if end > len(albumTracks) {
+			end = len(albumTracks)
+		}
+
+		ids := make([]spotify.ID, end-start)
+		for i := range ids {
+			ids[i] = albumTracks[start+i].ID
+		}
+		full, err := p.c.GetTracks(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range ids {
+			var track db.Track
+			if i < len(full) && full[i] != nil {
+				track = db.NewTrack(*full[i])
+			} else {
+				track = db.NewAlbumTrack(album, albumTracks[start+i])
+			}
+			tracks = append(tracks, &track)
+		}
+	}
+	return tracks, nil
+}
+
+func NewAlbumProvider(c *spotify.Client, saver trackSaver) AlbumProvider {
+	return AlbumProvider{
+		c:     c,
+		saver: saver,
+	}
+}
@@ -0,0 +1,133 @@
+package spotify
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zmb3/spotify/v2"
+	"net/http"
+	"testing"
+)
+
+func hasSource(source string) interface{} {
+	return mock.MatchedBy(func(tracks []*db.Track) bool {
+		for _, t := range tracks {
+			if len(t.Sources) != 1 || t.Sources[0] != source || t.AlbumName != "An album" {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestAlbumProvider_Album(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "=~/albums/abc",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"id":   "abc",
+				"name": "An album",
+				"tracks": map[string]interface{}{
+					"total": 2,
+					"items": []map[string]interface{}{
+						{"id": "1", "name": "Track A"},
+						{"id": "2", "name": "Track B"},
+					},
+				},
+			})
+		})
+
+	httpmock.RegisterResponder("GET", "=~/tracks\\?ids=1",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"tracks": []interface{}{
+					map[string]interface{}{
+						"id": "1", "name": "Track A", "popularity": 42,
+						"external_ids": map[string]interface{}{"isrc": "GBUM71029604"},
+						"album":        map[string]interface{}{"id": "abc", "name": "An album"},
+					},
+					nil,
+				},
+			})
+		})
+
+	store := new(trackSaverMock)
+	store.On("SaveMany", mock.MatchedBy(func(tracks []*db.Track) bool {
+		return len(tracks) == 2 && tracks[1].Sources[0] == db.SourceAlbum && tracks[0].Popularity == 42 && tracks[0].ISRC == "GBUM71029604" && tracks[1].Name == "Track B"
+	})).Once().Return([]db.SaveResult{{Inserted: true}, {}}, nil)
+
+	r, err := NewAlbumProvider(spotify.New(http.DefaultClient), store).Album(context.Background(), "abc")
+
+	assert.Nil(t, err)
+	assert.Equal(t, ImportResult{New: 1, Unchanged: 1, Complete: true, TrackIDs: []string{"1", "2"}}, r)
+	store.AssertExpectations(t)
+}
+
+func TestAlbumProvider_FollowedArtists(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "=~/me/following",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"artists": map[string]interface{}{
+					"items": []map[string]interface{}{{"id": "artist", "name": "An artist"}},
+				},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/artists/artist/top-tracks",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"tracks": []map[string]interface{}{
+					{"id": "1", "name": "Hit", "album": map[string]interface{}{"name": "An album"}},
+				},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/artists/artist/albums",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"items": []map[string]interface{}{{"id": "abc", "name": "An album"}},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/albums/abc/tracks",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"items": []map[string]interface{}{{"id": "2", "name": "Deep cut"}},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/tracks\\?ids=2",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"tracks": []map[string]interface{}{
+					{"id": "2", "name": "Deep cut", "popularity": 12, "album": map[string]interface{}{"name": "An album"}},
+				},
+			})
+		})
+	c := spotify.New(http.DefaultClient)
+
+	t.Run("imports top tracks", func(t *testing.T) {
+		store := new(trackSaverMock)
+		store.On("SaveMany", hasSource(db.SourceArtist)).Once().Return([]db.SaveResult{{Inserted: true}}, nil)
+
+		r, err := NewAlbumProvider(c, store).FollowedArtists(context.Background(), false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1"}, r.TrackIDs)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("imports discography", func(t *testing.T) {
+		store := new(trackSaverMock)
+		store.On("SaveMany", hasSource(db.SourceArtist)).Times(2).Return([]db.SaveResult{{Inserted: true}}, nil)
+
+		r, err := NewAlbumProvider(c, store).FollowedArtists(context.Background(), true)
+
+		assert.Nil(t, err)
+		assert.Equal(t, ImportResult{New: 2, Complete: true, TrackIDs: []string{"1", "2"}}, r)
+		store.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,78 @@
+package spotify
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/logging"
+	"github.com/zmb3/spotify/v2"
+)
+
+// instrumentalThreshold is the instrumentalness from which Spotify considers a track to contain no vocals.
+const instrumentalThreshold = 0.8
+
+// audioFeaturesLimit is the maximum number of tracks whose audio features can be requested at once.
+const audioFeaturesLimit = 100
+
+type audioFeaturesGetter interface {
+	GetAudioFeatures(ctx context.Context, ids ...spotify.ID) ([]*spotify.AudioFeatures, error)
+}
+
+type instrumentalSetter interface {
+	SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error
+}
+
+// markInstrumental stores which of the given tracks Spotify considers instrumental. It is called with newly imported
+// tracks only, so importing known tracks again does not request their audio features.
+func markInstrumental(ctx context.Context, store instrumentalSetter, c audioFeaturesGetter, tracks []*db.Track) {
+	if len(tracks) == 0 {
+		return
+	}
+	detectInstrumental(ctx, c, tracks)
+	for _, t := range tracks {
+		if !t.Instrumental {
+			continue
+		}
+		if err := store.SetInstrumental(ctx, t.SpotifyID, true); err != nil {
+			logging.FromContext(ctx).Warn("could not mark track as instrumental", "spotify_id", t.SpotifyID, "error", err)
+		}
+	}
+}
+
+// detectInstrumental marks the tracks Spotify considers instrumental, so no lyrics are fetched for them. Audio
+// features are optional, a failed lookup is logged and does not abort the import.
+func detectInstrumental(ctx context.Context, c audioFeaturesGetter, tracks []*db.Track) {
+	byID := make(map[spotify.ID][]*db.Track, len(tracks))
+	ids := make([]spotify.ID, 0, len(tracks))
+	for _, t := range tracks {
+		if t.SpotifyID == "" {
+			continue
+		}
+		id := spotify.ID(t.SpotifyID)
+		if _, ok := byID[id]; !ok {
+			ids = append(ids, id)
+		}
+		byID[id] = append(byID[id], t)
+	}
+
+	for start := 0; start < len(ids); start += audioFeaturesLimit {
+		end := start + audioFeaturesLimit
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		features, err := c.GetAudioFeatures(ctx, ids[start:end]...)
+		if err != nil {
+			logging.FromContext(ctx).Warn("could not fetch audio features", "error", err)
+			return
+		}
+		for _, f := range features {
+			// unknown tracks are returned as null
+			if f == nil || f.Instrumentalness < instrumentalThreshold {
+				continue
+			}
+			for _, t := range byID[f.ID] {
+				t.Instrumental = true
+			}
+		}
+	}
+}
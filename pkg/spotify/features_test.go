@@ -0,0 +1,70 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zmb3/spotify/v2"
+	"strconv"
+	"testing"
+)
+
+type audioFeaturesMock struct {
+	mock.Mock
+}
+
+func (a *audioFeaturesMock) GetAudioFeatures(ctx context.Context, ids ...spotify.ID) ([]*spotify.AudioFeatures, error) {
+	args := a.Called(ids)
+	return args.Get(0).([]*spotify.AudioFeatures), args.Error(1)
+}
+
+var _ audioFeaturesGetter = &audioFeaturesMock{}
+
+func TestDetectInstrumental(t *testing.T) {
+	t.Run("marks tracks with a high instrumentalness", func(t *testing.T) {
+		tracks := []*db.Track{{SpotifyID: "1"}, {SpotifyID: "2"}, {SpotifyID: "3"}, {SpotifyID: "1"}}
+		c := new(audioFeaturesMock)
+		c.On("GetAudioFeatures", []spotify.ID{"1", "2", "3"}).Return([]*spotify.AudioFeatures{
+			{ID: "1", Instrumentalness: 0.92},
+			{ID: "2", Instrumentalness: 0.1},
+			nil,
+		}, nil)
+
+		detectInstrumental(context.Background(), c, tracks)
+
+		assert.True(t, tracks[0].Instrumental)
+		assert.False(t, tracks[1].Instrumental)
+		assert.False(t, tracks[2].Instrumental)
+		assert.True(t, tracks[3].Instrumental)
+		c.AssertExpectations(t)
+	})
+
+	t.Run("requests at most 100 tracks at once", func(t *testing.T) {
+		tracks := make([]*db.Track, 150)
+		for i := range tracks {
+			tracks[i] = &db.Track{SpotifyID: strconv.Itoa(i)}
+		}
+		c := new(audioFeaturesMock)
+		c.On("GetAudioFeatures", mock.MatchedBy(func(ids []spotify.ID) bool { return len(ids) == 100 })).
+			Once().Return([]*spotify.AudioFeatures{}, nil)
+		c.On("GetAudioFeatures", mock.MatchedBy(func(ids []spotify.ID) bool { return len(ids) == 50 })).
+			Once().Return([]*spotify.AudioFeatures{{ID: "149", Instrumentalness: 1}}, nil)
+
+		detectInstrumental(context.Background(), c, tracks)
+
+		assert.True(t, tracks[149].Instrumental)
+		c.AssertExpectations(t)
+	})
+
+	t.Run("ignores failed lookups", func(t *testing.T) {
+		tracks := []*db.Track{{SpotifyID: "1"}}
+		c := new(audioFeaturesMock)
+		c.On("GetAudioFeatures", mock.Anything).Return([]*spotify.AudioFeatures(nil), errors.New("forbidden"))
+
+		detectInstrumental(context.Background(), c, tracks)
+
+		assert.False(t, tracks[0].Instrumental)
+	})
+}
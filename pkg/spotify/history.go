@@ -0,0 +1,105 @@
+package spotify
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/zmb3/spotify/v2"
+	"time"
+)
+
+type historySaver interface {
+	LatestPlayedAt(ctx context.Context) (time.Time, error)
+	SavePlays(ctx context.Context, plays []db.Play) (int, error)
+}
+
+// HistoryProvider imports the listening history of the user.
+type HistoryProvider struct {
+	c       *spotify.Client
+	saver   trackSaver
+	history historySaver
+}
+
+// Import records the recently played tracks as play events and stores the played tracks as well as the top tracks
+// of the user. Spotify only returns the last 50 plays, so the history must be imported regularly to be complete.
+func (p HistoryProvider) Import(ctx context.Context) (ImportResult, error) {
+	var r ImportResult
+
+	since, err := p.history.LatestPlayedAt(ctx)
+	if err != nil {
+		return r, err
+	}
+
+	opts := &spotify.RecentlyPlayedOptions{Limit: pageLimit}
+	if !since.IsZero() {
+		opts.AfterEpochMs = since.UnixNano() / int64(time.Millisecond)
+	}
+	items, err := p.c.PlayerRecentlyPlayedOpt(ctx, opts)
+	if err != nil {
+		return r, err
+	}
+
+	// recently played tracks lack the album information, so unknown tracks are fetched separately
+	var unknown []spotify.ID
+	seen := make(map[spotify.ID]bool)
+	plays := make([]db.Play, 0, len(items))
+	for i := range items {
+		id := items[i].Track.ID
+		plays = append(plays, db.Play{SpotifyID: id.String(), PlayedAt: items[i].PlayedAt})
+
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := p.saver.FindTrack(ctx, id.String()); err != nil {
+			unknown = append(unknown, id)
+		} else {
+			r.Unchanged++
+		}
+	}
+
+	var tracks []*db.Track
+	if len(unknown) > 0 {
+		fullTracks, err := p.c.GetTracks(ctx, unknown)
+		if err != nil {
+			return r, err
+		}
+		for i := range fullTracks {
+			if fullTracks[i] == nil {
+				continue
+			}
+			tracks = append(tracks, historyTrack(*fullTracks[i]))
+		}
+	}
+
+	top, err := p.c.CurrentUsersTopTracks(ctx, spotify.Limit(pageLimit), spotify.Timerange(spotify.ShortTermRange))
+	if err != nil {
+		return r, err
+	}
+	for i := range top.Tracks {
+		if seen[top.Tracks[i].ID] {
+			continue
+		}
+		tracks = append(tracks, historyTrack(top.Tracks[i]))
+	}
+
+	if err := saveTracks(ctx, p.saver, p.c, tracks, &r); err != nil {
+		return r, err
+	}
+
+	r.Plays, err = p.history.SavePlays(ctx, plays)
+	return r, err
+}
+
+func historyTrack(t spotify.FullTrack) *db.Track {
+	track := db.NewTrack(t)
+	track.Sources = []string{db.SourceHistory}
+	return &track
+}
+
+func NewHistoryProvider(c *spotify.Client, saver trackSaver, history historySaver) HistoryProvider {
+	return HistoryProvider{
+		c:       c,
+		saver:   saver,
+		history: history,
+	}
+}
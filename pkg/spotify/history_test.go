@@ -0,0 +1,82 @@
+package spotify
+
+import (
+	"context"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zmb3/spotify/v2"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type historySaverMock struct {
+	mock.Mock
+}
+
+func (h *historySaverMock) LatestPlayedAt(ctx context.Context) (time.Time, error) {
+	args := h.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (h *historySaverMock) SavePlays(ctx context.Context, plays []db.Play) (int, error) {
+	args := h.Called(plays)
+	return args.Int(0), args.Error(1)
+}
+
+var _ historySaver = &historySaverMock{}
+
+func TestHistoryProvider_Import(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	since := time.Date(2021, 6, 24, 19, 0, 0, 0, time.UTC)
+	httpmock.RegisterResponder("GET", "=~/me/player/recently-played",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "1624561200000", req.URL.Query().Get("after"))
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"track": map[string]interface{}{"id": "1"}, "played_at": "2021-06-24T19:59:02Z"},
+					{"track": map[string]interface{}{"id": "2"}, "played_at": "2021-06-24T19:55:00Z"},
+					{"track": map[string]interface{}{"id": "1"}, "played_at": "2021-06-24T19:50:00Z"},
+				},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/tracks\\?ids=2",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"tracks": []map[string]interface{}{{"id": "2", "name": "Track B"}},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/me/top/tracks",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"items": []map[string]interface{}{{"id": "1"}, {"id": "3", "name": "Track C"}},
+			})
+		})
+
+	store := new(trackSaverMock)
+	store.On("FindTrack", "1").Return(&db.Track{SpotifyID: "1"}, nil)
+	store.On("FindTrack", mock.Anything).Return((*db.Track)(nil), db.ErrTrackNotFound)
+	store.On("SaveMany", mock.MatchedBy(func(tracks []*db.Track) bool {
+		for _, t := range tracks {
+			if len(t.Sources) != 1 || t.Sources[0] != db.SourceHistory {
+				return false
+			}
+		}
+		return len(tracks) == 2
+	})).Once().Return([]db.SaveResult{{Inserted: true}, {Inserted: true}}, nil)
+	history := new(historySaverMock)
+	history.On("LatestPlayedAt").Return(since, nil)
+	history.On("SavePlays", mock.MatchedBy(func(plays []db.Play) bool {
+		return len(plays) == 3 && plays[1].SpotifyID == "2"
+	})).Return(3, nil)
+
+	r, err := NewHistoryProvider(spotify.New(http.DefaultClient), store, history).Import(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, ImportResult{New: 2, Unchanged: 1, TrackIDs: []string{"2", "3"}, Plays: 3}, r)
+	store.AssertExpectations(t)
+	history.AssertExpectations(t)
+}
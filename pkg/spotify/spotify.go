@@ -3,16 +3,74 @@ package spotify
 import (
 	"context"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/logging"
 	"github.com/zmb3/spotify/v2"
+	"time"
 )
 
 type userTrackProvider interface {
+	audioFeaturesGetter
 	Tracks(ctx context.Context) ([]*db.Track, error)
 	Next(ctx context.Context) error
+	Total() int
 }
 
 type trackSaver interface {
-	Save(track *db.Track) error
+	SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error)
+	FindTrack(ctx context.Context, spotifyID string) (*db.Track, error)
+	SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error
+}
+
+// ImportResult counts the tracks of an import. New tracks have not been stored before, unchanged tracks were already known.
+type ImportResult struct {
+	New       int
+	Unchanged int
+	// Failed counts the tracks that could not be saved. They do not abort the import.
+	Failed int
+	// Skipped is set if nothing has been downloaded because the source has not changed since the last import.
+	Skipped bool
+	// Complete is set if every track of the source has been visited, so TrackIDs contains all of them.
+	Complete bool
+	TrackIDs []string
+	// Plays counts the play events recorded by an import of the listening history.
+	Plays int
+}
+
+// saveTracks stores a page of tracks at once. Tracks that cannot be saved are logged and counted as failed, only an
+// error of the whole write aborts the import. New tracks are marked if Spotify considers them instrumental.
+func saveTracks(ctx context.Context, store trackSaver, features audioFeaturesGetter, tracks []*db.Track, r *ImportResult) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+	results, err := store.SaveMany(ctx, tracks)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+	var inserted []*db.Track
+	for i, track := range tracks {
+		// failed tracks are still part of the source, so they must not be considered orphaned
+		if track.SpotifyID != "" {
+			r.TrackIDs = append(r.TrackIDs, track.SpotifyID)
+		}
+		if results[i].Err != nil {
+			r.Failed++
+			logger.Warn("could not save track", "spotify_id", track.SpotifyID, "error", results[i].Err)
+			continue
+		}
+
+		if results[i].Inserted {
+			r.New++
+			inserted = append(inserted, track)
+		} else {
+			r.Unchanged++
+		}
+		logger.Debug("saved track", "spotify_id", track.SpotifyID, "new", results[i].Inserted)
+	}
+
+	markInstrumental(ctx, store, features, inserted)
+	return nil
 }
 
 type UserTrackProvider struct {
@@ -31,7 +89,7 @@ func (p *UserTrackProvider) Tracks(ctx context.Context) ([]*db.Track, error) {
 
 	var tracks []*db.Track
 	for i := range p.lastPage.Tracks {
-		track := db.NewTrack(p.lastPage.Tracks[i].FullTrack)
+		track := db.NewSavedTrack(p.lastPage.Tracks[i])
 		tracks = append(tracks, &track)
 	}
 	return tracks, nil
@@ -41,24 +99,44 @@ func (p *UserTrackProvider) Next(ctx context.Context) error {
 	return p.c.NextPage(ctx, p.lastPage)
 }
 
+func (p *UserTrackProvider) GetAudioFeatures(ctx context.Context, ids ...spotify.ID) ([]*spotify.AudioFeatures, error) {
+	return p.c.GetAudioFeatures(ctx, ids...)
+}
+
+func (p *UserTrackProvider) Total() int {
+	if p.lastPage == nil {
+		return 0
+	}
+	return p.lastPage.Total
+}
+
 func NewSpotifyTrackProvider(client *spotify.Client) *UserTrackProvider {
 	return &UserTrackProvider{
 		c: client,
 	}
 }
 
-func SyncTracks(ctx context.Context, client userTrackProvider, store trackSaver) error {
+// SyncTracks imports the library of the user. The library is ordered by the time tracks have been saved, so if since
+// is set, paging stops at the first track that has been saved before since.
+func SyncTracks(ctx context.Context, client userTrackProvider, store trackSaver, since time.Time) (ImportResult, error) {
+	var r ImportResult
+	visited := 0
+
 	for {
 		tracks, err := client.Tracks(ctx)
 		if err != nil {
-			return err
+			return r, err
 		}
 
 		for i := range tracks {
-			err := store.Save(tracks[i])
-			if err != nil {
-				return err
+			if !since.IsZero() && !tracks[i].AddedAt.IsZero() && !tracks[i].AddedAt.After(since) {
+				r.Unchanged += client.Total() - visited
+				return r, saveTracks(ctx, store, client, tracks[:i], &r)
 			}
+			visited++
+		}
+		if err := saveTracks(ctx, store, client, tracks, &r); err != nil {
+			return r, err
 		}
 
 		err = client.Next(ctx)
@@ -66,46 +144,114 @@ func SyncTracks(ctx context.Context, client userTrackProvider, store trackSaver)
 			if err == spotify.ErrNoMorePages {
 				break
 			}
-			return err
+			return r, err
 		}
 	}
 
-	return nil
+	r.Complete = true
+	return r, nil
+}
+
+// LibraryTrackIDs returns the Spotify IDs of all tracks of the library without storing them.
+func LibraryTrackIDs(ctx context.Context, client userTrackProvider) ([]string, error) {
+	var ids []string
+	for {
+		tracks, err := client.Tracks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range tracks {
+			ids = append(ids, tracks[i].SpotifyID)
+		}
+
+		err = client.Next(ctx)
+		if err == spotify.ErrNoMorePages {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type playlistTrackSaver interface {
+	trackSaver
+	SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error
 }
 
+type playlistSaver interface {
+	FindPlaylist(ctx context.Context, spotifyID string) (*db.Playlist, error)
+	SavePlaylist(ctx context.Context, playlist *db.Playlist) error
+}
+
+// playlistFields limits the playlist metadata requested from Spotify, so checking for changes does not download any tracks.
+const playlistFields = "id,name,owner(display_name),snapshot_id,images,public,collaborative"
+
 type PlaylistProvider struct {
-	c     *spotify.Client
-	saver trackSaver
+	c         *spotify.Client
+	saver     playlistTrackSaver
+	playlists playlistSaver
 }
 
-func (p PlaylistProvider) Download(ctx context.Context, ID string) error {
-	playlist, err := p.c.GetPlaylistTracks(ctx, spotify.ID(ID))
+// Download imports all tracks of a playlist and stores the playlist together with its members.
+// Playlists whose snapshot has not changed since the last import are skipped, unless force is set.
+func (p PlaylistProvider) Download(ctx context.Context, ID string, force bool) (ImportResult, error) {
+	var r ImportResult
+
+	playlist, err := p.c.GetPlaylist(ctx, spotify.ID(ID), spotify.Fields(playlistFields))
 	if err != nil {
-		return err
+		return r, err
+	}
+
+	if !force {
+		known, err := p.playlists.FindPlaylist(ctx, ID)
+		if err == nil && known.SnapshotID != "" && known.SnapshotID == playlist.SnapshotID {
+			r.Unchanged = known.TrackCount
+			r.Skipped = true
+			return r, nil
+		}
+	}
+
+	page, err := p.c.GetPlaylistTracks(ctx, spotify.ID(ID))
+	if err != nil {
+		return r, err
 	}
 
 	for {
-		for i := range playlist.Tracks {
-			track := db.NewTrack(playlist.Tracks[i].Track)
-			err = p.saver.Save(&track)
-			if err != nil {
-				return err
-			}
+		tracks := make([]*db.Track, len(page.Tracks))
+		for i := range page.Tracks {
+			track := db.NewTrack(page.Tracks[i].Track)
+			track.Sources = []string{db.SourcePlaylist}
+			tracks[i] = &track
+		}
+		if err := saveTracks(ctx, p.saver, p.c, tracks, &r); err != nil {
+			return r, err
 		}
 
-		err = p.c.NextPage(ctx, playlist)
+		err = p.c.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			break
+		}
 		if err != nil {
-			if err == spotify.ErrNoMorePages {
-				return nil
-			}
-			return err
+			return r, err
 		}
 	}
+
+	r.Complete = true
+	if err := p.saver.SetPlaylistTracks(ctx, ID, r.TrackIDs); err != nil {
+		return r, err
+	}
+
+	pl := db.NewPlaylist(playlist.SimplePlaylist)
+	pl.TrackCount = len(r.TrackIDs)
+	pl.ImportedAt = time.Now()
+	return r, p.playlists.SavePlaylist(ctx, &pl)
 }
 
-func NewPlaylistProvider(c *spotify.Client, saver trackSaver) PlaylistProvider {
+func NewPlaylistProvider(c *spotify.Client, saver playlistTrackSaver, playlists playlistSaver) PlaylistProvider {
 	return PlaylistProvider{
-		c:     c,
-		saver: saver,
+		c:         c,
+		saver:     saver,
+		playlists: playlists,
 	}
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"github.com/imba28/spolyr/pkg/db"
+	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/zmb3/spotify/v2"
 	"io"
+	"net/http"
 	"testing"
+	"time"
 )
 
 type userProviderMock struct {
@@ -22,14 +25,28 @@ func (c userProviderMock) Tracks(ctx context.Context) ([]*db.Track, error) {
 func (c userProviderMock) Next(ctx context.Context) error {
 	return c.Called(ctx).Error(0)
 }
+func (c *userProviderMock) Total() int {
+	return c.Called().Int(0)
+}
+func (c *userProviderMock) GetAudioFeatures(ctx context.Context, ids ...spotify.ID) ([]*spotify.AudioFeatures, error) {
+	args := c.Called(ids)
+	return args.Get(0).([]*spotify.AudioFeatures), args.Error(1)
+}
 
 type trackSaverMock struct {
 	mock.Mock
 }
 
-func (t *trackSaverMock) Save(track *db.Track) error {
-	args := t.Called(track)
-	return args.Error(0)
+func (t *trackSaverMock) SaveMany(ctx context.Context, tracks []*db.Track) ([]db.SaveResult, error) {
+	args := t.Called(tracks)
+	return args.Get(0).([]db.SaveResult), args.Error(1)
+}
+func (t *trackSaverMock) FindTrack(ctx context.Context, spotifyID string) (*db.Track, error) {
+	args := t.Called(spotifyID)
+	return args.Get(0).(*db.Track), args.Error(1)
+}
+func (t *trackSaverMock) SetInstrumental(ctx context.Context, spotifyID string, instrumental bool) error {
+	return t.Called(spotifyID, instrumental).Error(0)
 }
 
 var _ userTrackProvider = &userProviderMock{}
@@ -47,17 +64,35 @@ func TestSyncTracks__saves_tracks_to_store(t *testing.T) {
 	client.On("Next", ctx).Return(spotify.ErrNoMorePages)
 
 	store := new(trackSaverMock)
-	store.
-		On("Save", mock.AnythingOfType("*db.Track")).
-		Times(len(result)).
-		Return(nil)
+	store.On("SaveMany", result).Once().Return([]db.SaveResult{{Inserted: true}, {Inserted: true}}, nil)
 
-	_ = SyncTracks(ctx, client, store)
+	r, err := SyncTracks(ctx, client, store, time.Time{})
 
+	assert.Nil(t, err)
+	assert.Equal(t, 2, r.New)
 	store.AssertExpectations(t)
 	client.AssertExpectations(t)
 }
 
+func TestSyncTracks__continues_after_tracks_that_cannot_be_saved(t *testing.T) {
+	result := []*db.Track{{SpotifyID: "1"}, {SpotifyID: "2"}, {SpotifyID: "3"}}
+	ctx := context.Background()
+
+	client := new(userProviderMock)
+	client.On("Tracks", ctx).Return(result, nil)
+	client.On("Next", ctx).Return(spotify.ErrNoMorePages)
+	client.On("GetAudioFeatures", []spotify.ID{"1"}).Return([]*spotify.AudioFeatures{}, nil)
+
+	store := new(trackSaverMock)
+	store.On("SaveMany", result).Once().Return([]db.SaveResult{{Inserted: true}, {Err: errors.New("invalid document")}, {}}, nil)
+
+	r, err := SyncTracks(ctx, client, store, time.Time{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, ImportResult{New: 1, Unchanged: 1, Failed: 1, Complete: true, TrackIDs: []string{"1", "2", "3"}}, r)
+	store.AssertExpectations(t)
+}
+
 func TestSyncTracks__returns_error_if_fetching_tracks_results_in_error(t *testing.T) {
 	expectedError := errors.New("unexpected error")
 	ctx := context.Background()
@@ -66,7 +101,7 @@ func TestSyncTracks__returns_error_if_fetching_tracks_results_in_error(t *testin
 
 	store := new(trackSaverMock)
 
-	err := SyncTracks(ctx, client, store)
+	_, err := SyncTracks(ctx, client, store, time.Time{})
 
 	assert.EqualError(t, err, expectedError.Error())
 	store.AssertExpectations(t)
@@ -81,7 +116,7 @@ func TestSyncTracks__returns_error_if_fetching_next_page_results_in_error(t *tes
 
 	store := new(trackSaverMock)
 
-	err := SyncTracks(ctx, client, store)
+	_, err := SyncTracks(ctx, client, store, time.Time{})
 
 	assert.EqualError(t, err, io.ErrUnexpectedEOF.Error())
 	store.AssertExpectations(t)
@@ -99,11 +134,166 @@ func TestSyncTracks__returns_error_if_database_returns_error(t *testing.T) {
 	}, nil)
 
 	store := new(trackSaverMock)
-	store.On("Save", mock.Anything).Times(1).Return(expectedError)
+	store.On("SaveMany", mock.Anything).Times(1).Return([]db.SaveResult(nil), expectedError)
 
-	err := SyncTracks(ctx, client, store)
+	_, err := SyncTracks(ctx, client, store, time.Time{})
 
 	assert.EqualError(t, err, expectedError.Error())
 	store.AssertExpectations(t)
 	client.AssertExpectations(t)
 }
+
+func TestSyncTracks__stops_at_known_tracks(t *testing.T) {
+	since := time.Date(2021, 6, 24, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	client := new(userProviderMock)
+	client.On("Tracks", ctx).Times(1).Return([]*db.Track{
+		{SpotifyID: "1", AddedAt: since.Add(2 * time.Hour)},
+		{SpotifyID: "2", AddedAt: since.Add(time.Hour)},
+		{SpotifyID: "3", AddedAt: since},
+		{SpotifyID: "4", AddedAt: since.Add(-time.Hour)},
+	}, nil)
+	client.On("Total").Return(50)
+	client.On("GetAudioFeatures", []spotify.ID{"1"}).Return([]*spotify.AudioFeatures{}, nil)
+
+	store := new(trackSaverMock)
+	store.On("SaveMany", mock.MatchedBy(func(tracks []*db.Track) bool {
+		return len(tracks) == 2
+	})).Once().Return([]db.SaveResult{{Inserted: true}, {}}, nil)
+
+	r, err := SyncTracks(ctx, client, store, since)
+
+	assert.Nil(t, err)
+	assert.Equal(t, ImportResult{New: 1, Unchanged: 49, TrackIDs: []string{"1", "2"}}, r)
+	store.AssertExpectations(t)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "Next", ctx)
+}
+
+func TestSyncTracks__marks_new_instrumental_tracks(t *testing.T) {
+	result := []*db.Track{{SpotifyID: "1"}, {SpotifyID: "2"}, {SpotifyID: "3"}}
+	ctx := context.Background()
+
+	client := new(userProviderMock)
+	client.On("Tracks", ctx).Return(result, nil)
+	client.On("Next", ctx).Return(spotify.ErrNoMorePages)
+	client.On("GetAudioFeatures", []spotify.ID{"1", "3"}).Once().Return([]*spotify.AudioFeatures{
+		{ID: "1", Instrumentalness: 0.1},
+		{ID: "3", Instrumentalness: 0.95},
+	}, nil)
+
+	store := new(trackSaverMock)
+	store.On("SaveMany", result).Once().Return([]db.SaveResult{{Inserted: true}, {}, {Inserted: true}}, nil)
+	store.On("SetInstrumental", "3", true).Once().Return(nil)
+
+	_, err := SyncTracks(ctx, client, store, time.Time{})
+
+	assert.Nil(t, err)
+	assert.True(t, result[2].Instrumental)
+	store.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestSyncTracks__does_not_request_audio_features_of_known_tracks(t *testing.T) {
+	result := []*db.Track{{SpotifyID: "1"}, {SpotifyID: "2"}}
+	ctx := context.Background()
+
+	client := new(userProviderMock)
+	client.On("Tracks", ctx).Return(result, nil)
+	client.On("Next", ctx).Return(spotify.ErrNoMorePages)
+
+	store := new(trackSaverMock)
+	store.On("SaveMany", result).Once().Return([]db.SaveResult{{}, {}}, nil)
+
+	_, err := SyncTracks(ctx, client, store, time.Time{})
+
+	assert.Nil(t, err)
+	client.AssertNotCalled(t, "GetAudioFeatures", mock.Anything)
+}
+
+type playlistStoreMock struct {
+	trackSaverMock
+}
+
+func (p *playlistStoreMock) SetPlaylistTracks(ctx context.Context, playlistID string, spotifyIDs []string) error {
+	return p.Called(playlistID, spotifyIDs).Error(0)
+}
+func (p *playlistStoreMock) FindPlaylist(ctx context.Context, spotifyID string) (*db.Playlist, error) {
+	args := p.Called(spotifyID)
+	return args.Get(0).(*db.Playlist), args.Error(1)
+}
+func (p *playlistStoreMock) SavePlaylist(ctx context.Context, playlist *db.Playlist) error {
+	return p.Called(playlist).Error(0)
+}
+
+var _ playlistTrackSaver = &playlistStoreMock{}
+var _ playlistSaver = &playlistStoreMock{}
+
+func TestPlaylistProvider_Download(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "=~/playlists/abc/tracks",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"total": 2,
+				"items": []map[string]interface{}{
+					{"track": map[string]interface{}{"id": "1", "name": "Track A"}},
+					{"track": map[string]interface{}{"id": "2", "name": "Track B"}},
+				},
+			})
+		})
+	httpmock.RegisterResponder("GET", "=~/playlists/abc",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, map[string]interface{}{
+				"id":          "abc",
+				"name":        "A playlist",
+				"snapshot_id": "new-snapshot",
+			})
+		})
+	c := spotify.New(http.DefaultClient)
+	ctx := context.Background()
+
+	t.Run("imports tracks of changed playlist", func(t *testing.T) {
+		store := new(playlistStoreMock)
+		store.On("FindPlaylist", "abc").Return(&db.Playlist{SpotifyID: "abc", SnapshotID: "old-snapshot"}, nil)
+		store.On("SaveMany", mock.MatchedBy(func(tracks []*db.Track) bool {
+			return len(tracks) == 2 && len(tracks[0].Sources) == 1 && tracks[0].Sources[0] == db.SourcePlaylist
+		})).Once().Return([]db.SaveResult{{Inserted: true}, {}}, nil)
+		store.On("SetPlaylistTracks", "abc", []string{"1", "2"}).Return(nil)
+		store.On("SavePlaylist", mock.MatchedBy(func(p *db.Playlist) bool {
+			return p.SnapshotID == "new-snapshot" && p.TrackCount == 2 && !p.ImportedAt.IsZero()
+		})).Return(nil)
+
+		r, err := NewPlaylistProvider(c, store, store).Download(ctx, "abc", false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, ImportResult{New: 1, Unchanged: 1, Complete: true, TrackIDs: []string{"1", "2"}}, r)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("skips unchanged playlist", func(t *testing.T) {
+		store := new(playlistStoreMock)
+		store.On("FindPlaylist", "abc").Return(&db.Playlist{SpotifyID: "abc", SnapshotID: "new-snapshot", TrackCount: 2}, nil)
+
+		r, err := NewPlaylistProvider(c, store, store).Download(ctx, "abc", false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, ImportResult{Unchanged: 2, Skipped: true}, r)
+		store.AssertExpectations(t)
+		store.AssertNotCalled(t, "SaveMany", mock.Anything)
+	})
+}
+
+func TestLibraryTrackIDs(t *testing.T) {
+	ctx := context.Background()
+	client := new(userProviderMock)
+	client.On("Tracks", ctx).Return([]*db.Track{{SpotifyID: "1"}, {SpotifyID: "2"}}, nil)
+	client.On("Next", ctx).Return(spotify.ErrNoMorePages)
+
+	ids, err := LibraryTrackIDs(ctx, client)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1", "2"}, ids)
+}
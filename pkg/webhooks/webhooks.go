@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/imba28/spolyr/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// EventLyricsSyncFinished is sent when an import of lyrics has finished.
+	EventLyricsSyncFinished = "lyrics.sync.finished"
+	// EventLyricsEdited is sent when the lyrics of a track have been changed manually.
+	EventLyricsEdited = "lyrics.edited"
+	// EventLibraryImported is sent when the library has been imported.
+	EventLibraryImported = "import.library.finished"
+	// EventPlaylistImported is sent when a playlist has been imported.
+	EventPlaylistImported = "import.playlist.finished"
+)
+
+// Events lists all event types webhooks can subscribe to.
+var Events = []string{EventLyricsSyncFinished, EventLyricsEdited, EventLibraryImported, EventPlaylistImported}
+
+const (
+	SignatureHeader = "X-Spolyr-Signature"
+	EventHeader     = "X-Spolyr-Event"
+	DeliveryHeader  = "X-Spolyr-Delivery"
+)
+
+const (
+	maxAttempts    = 5
+	initialBackoff = 2 * time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// IsEvent reports whether webhooks can subscribe to the event type.
+func IsEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the body sent to webhooks.
+type Payload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// LyricsSyncData is the data of EventLyricsSyncFinished.
+type LyricsSyncData struct {
+	Total      int  `json:"total"`
+	Successful int  `json:"successful"`
+	Failed     int  `json:"failed"`
+	Cancelled  bool `json:"cancelled"`
+}
+
+// ImportData is the data of EventLibraryImported and EventPlaylistImported.
+type ImportData struct {
+	PlaylistID string `json:"playlistId,omitempty"`
+	New        int    `json:"new"`
+	Unchanged  int    `json:"unchanged"`
+	Failed     int    `json:"failed"`
+	Skipped    bool   `json:"skipped"`
+}
+
+// LyricsEditedData is the data of EventLyricsEdited.
+type LyricsEditedData struct {
+	SpotifyID string   `json:"spotifyId"`
+	Title     string   `json:"title"`
+	Artists   []string `json:"artists"`
+	Language  string   `json:"language"`
+}
+
+// Sign returns the signature of a payload. Receivers compute the HMAC-SHA256 of the raw request body using the secret
+// of the webhook and compare it with the signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type store interface {
+	Webhooks(ctx context.Context) ([]*db.Webhook, error)
+	SaveDelivery(ctx context.Context, delivery *db.Delivery) error
+}
+
+// Dispatcher sends events to all subscribed webhooks. Failed deliveries are retried with exponential backoff.
+type Dispatcher struct {
+	store   store
+	client  *http.Client
+	backoff time.Duration
+	sleep   func(time.Duration)
+	now     func() time.Time
+	wg      sync.WaitGroup
+}
+
+// Dispatch sends an event to all subscribed webhooks in the background.
+func (d *Dispatcher) Dispatch(event string, data interface{}) {
+	// deliveries outlive the request the event originates from
+	ctx := context.Background()
+	hooks, err := d.store.Webhooks(ctx)
+	if err != nil {
+		logging.Default().Error("could not load webhooks", "event", event, "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		if !hook.Subscribes(event) {
+			continue
+		}
+
+		payload := Payload{
+			ID:        primitive.NewObjectID().Hex(),
+			Event:     event,
+			CreatedAt: d.now(),
+			Data:      data,
+		}
+		d.wg.Add(1)
+		go func(hook *db.Webhook) {
+			defer d.wg.Done()
+			if err := d.deliver(ctx, hook, payload); err != nil {
+				logging.Default().Warn("could not deliver event to webhook", "event", event, "webhook", hook.URL, "delivery", payload.ID, "error", err)
+			}
+		}(hook)
+	}
+}
+
+// Wait blocks until all pending deliveries have finished.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Shutdown waits until all pending deliveries have finished or ctx is done.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, hook *db.Webhook, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	id, _ := primitive.ObjectIDFromHex(payload.ID)
+	delivery := db.Delivery{
+		ID:        id,
+		WebhookID: hook.ID,
+		Event:     payload.Event,
+		Payload:   string(body),
+		CreatedAt: payload.CreatedAt,
+		Pending:   true,
+	}
+	// the delivery is recorded before the first attempt and after every attempt, so pending deliveries are listed
+	// and a shutdown while retrying does not lose the record
+	if err := d.store.SaveDelivery(ctx, &delivery); err != nil {
+		return err
+	}
+
+	backoff := d.backoff
+	for delivery.Pending {
+		if delivery.Attempts > 0 {
+			d.sleep(backoff)
+			backoff *= 2
+		}
+
+		delivery.Attempts++
+		delivery.StatusCode, err = d.send(hook, payload, body)
+		delivery.DeliveredAt = d.now()
+		delivery.Success = err == nil
+		delivery.Pending = !delivery.Success && delivery.Attempts < maxAttempts
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		if err := d.store.SaveDelivery(ctx, &delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) send(hook *db.Webhook, payload Payload, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.ID)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func New(s store) *Dispatcher {
+	return &Dispatcher{
+		store:   s,
+		client:  &http.Client{Timeout: requestTimeout},
+		backoff: initialBackoff,
+		sleep:   time.Sleep,
+		now:     time.Now,
+	}
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/imba28/spolyr/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type storeMock struct {
+	mock.Mock
+	sync.Mutex
+	deliveries []*db.Delivery
+}
+
+func (s *storeMock) Webhooks(ctx context.Context) ([]*db.Webhook, error) {
+	args := s.Called()
+	return args.Get(0).([]*db.Webhook), args.Error(1)
+}
+func (s *storeMock) SaveDelivery(ctx context.Context, delivery *db.Delivery) error {
+	s.Lock()
+	defer s.Unlock()
+	// deliveries are saved after every attempt, so a copy of each state is kept
+	saved := *delivery
+	s.deliveries = append(s.deliveries, &saved)
+	return nil
+}
+
+// last returns the most recently saved state of the delivery.
+func (s *storeMock) last() *db.Delivery {
+	return s.deliveries[len(s.deliveries)-1]
+}
+
+var _ store = &storeMock{}
+
+func newTestDispatcher(s store) (*Dispatcher, *[]time.Duration) {
+	var sleeps []time.Duration
+	d := New(s)
+	d.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	return d, &sleeps
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", Sign("key", []byte("The quick brown fox jumps over the lazy dog")))
+}
+
+func TestIsEvent(t *testing.T) {
+	assert.True(t, IsEvent(EventLyricsEdited))
+	assert.False(t, IsEvent("tracks.deleted"))
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Run("sends signed payload to subscribed webhooks", func(t *testing.T) {
+		var received []byte
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received, _ = io.ReadAll(r.Body)
+			header = r.Header
+		}))
+		defer server.Close()
+
+		s := new(storeMock)
+		s.On("Webhooks").Return([]*db.Webhook{
+			{URL: server.URL, Secret: "secret", Events: []string{EventLyricsEdited}},
+			{URL: server.URL + "/other", Events: []string{EventLibraryImported}},
+		}, nil)
+		d, _ := newTestDispatcher(s)
+
+		d.Dispatch(EventLyricsEdited, LyricsEditedData{SpotifyID: "1"})
+		d.Wait()
+
+		if assert.Len(t, s.deliveries, 2) {
+			assert.True(t, s.deliveries[0].Pending, "the delivery should be recorded before it is sent")
+			assert.Equal(t, 0, s.deliveries[0].Attempts)
+		}
+		assert.True(t, s.last().Success)
+		assert.False(t, s.last().Pending)
+		assert.Equal(t, 1, s.last().Attempts)
+		assert.Equal(t, http.StatusOK, s.last().StatusCode)
+		assert.Equal(t, EventLyricsEdited, header.Get(EventHeader))
+		assert.Equal(t, Sign("secret", received), header.Get(SignatureHeader))
+
+		var p struct {
+			Event string
+			Data  LyricsEditedData
+		}
+		assert.Nil(t, json.Unmarshal(received, &p))
+		assert.Equal(t, EventLyricsEdited, p.Event)
+		assert.Equal(t, "1", p.Data.SpotifyID)
+	})
+
+	t.Run("retries failed deliveries with backoff", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		s := new(storeMock)
+		s.On("Webhooks").Return([]*db.Webhook{{URL: server.URL, Events: Events}}, nil)
+		d, sleeps := newTestDispatcher(s)
+
+		d.Dispatch(EventLibraryImported, ImportData{New: 1})
+		d.Wait()
+
+		assert.Equal(t, []time.Duration{initialBackoff, 2 * initialBackoff}, *sleeps)
+		assert.True(t, s.last().Success)
+		assert.Equal(t, 3, s.last().Attempts)
+		if assert.Len(t, s.deliveries, 4) {
+			assert.True(t, s.deliveries[1].Pending, "failed attempts should be recorded while retrying")
+			assert.Equal(t, 1, s.deliveries[1].Attempts)
+		}
+	})
+
+	t.Run("gives up after the maximum number of attempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		s := new(storeMock)
+		s.On("Webhooks").Return([]*db.Webhook{{URL: server.URL, Events: Events}}, nil)
+		d, _ := newTestDispatcher(s)
+
+		d.Dispatch(EventLyricsSyncFinished, LyricsSyncData{})
+		d.Wait()
+
+		assert.False(t, s.last().Success)
+		assert.False(t, s.last().Pending)
+		assert.Equal(t, maxAttempts, s.last().Attempts)
+		assert.Equal(t, http.StatusInternalServerError, s.last().StatusCode)
+		assert.Contains(t, s.last().Error, "500")
+	})
+}
+
+func TestDispatcher_Shutdown(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	s := new(storeMock)
+	s.On("Webhooks").Return([]*db.Webhook{{URL: server.URL, Events: Events}}, nil)
+	d, _ := newTestDispatcher(s)
+
+	d.Dispatch(EventLyricsSyncFinished, LyricsSyncData{Cancelled: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Shutdown(ctx), context.DeadlineExceeded)
+	s.Lock()
+	if assert.Len(t, s.deliveries, 1, "a delivery in progress should be recorded") {
+		assert.True(t, s.deliveries[0].Pending)
+	}
+	s.Unlock()
+
+	close(release)
+	assert.Nil(t, d.Shutdown(context.Background()))
+	assert.Len(t, s.deliveries, 2)
+	assert.True(t, s.last().Success)
+}
@@ -16,7 +16,8 @@ module.exports = defineConfig({
     config.resolve.alias
         .set('@', path.resolve(__dirname, 'assets'));
   },
-  outputDir: 'public',
+  // embedded into the binary, see pkg/frontend
+  outputDir: 'pkg/frontend/build/public',
   pages: {
     index: {
       entry: 'assets/main.js',